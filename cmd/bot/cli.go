@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/divijg19/Wartracker/internal/bot"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// cliTimeout bounds one-shot maintenance commands (backup, restore, export).
+const cliTimeout = 2 * time.Minute
+
+// runMigrate opens the store, which applies pending migrations, and reports the resulting version.
+func runMigrate(cfg *bot.Config) error {
+	db, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	v, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema is at version %d.\n", v)
+	return nil
+}
+
+// runBackup writes a verified backup to dest; a directory gets a timestamped file name.
+func runBackup(cfg *bot.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: wartracker backup <dest>")
+	}
+	dest := args[0]
+	if fi, err := os.Stat(dest); err == nil && fi.IsDir() {
+		dest = filepath.Join(dest, storage.BackupFileName(time.Now()))
+	}
+	db, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+	if err := db.BackupTo(ctx, dest); err != nil {
+		return err
+	}
+	fmt.Printf("Backup created: %s\n", dest)
+	return nil
+}
+
+// runRestore replaces the database with a backup. It holds the leader lease for the whole restore,
+// so no instance can start serving from the database part-way through.
+func runRestore(cfg *bot.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: wartracker restore <file>")
+	}
+	db, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+	host, _ := os.Hostname()
+	instanceID := fmt.Sprintf("restore-%s-%d", host, os.Getpid())
+	ok, err := db.TryAcquireLeader(ctx, instanceID, leaderLease, true)
+	if err != nil {
+		return fmt.Errorf("take leader lease: %w", err)
+	}
+	if !ok {
+		owner, _, _ := db.LeaderStatus(ctx, leaderLease)
+		if owner == "" {
+			owner = "another instance"
+		}
+		return fmt.Errorf("refusing to restore while %s holds the leader lease; stop the bot first", owner)
+	}
+	stopRenew := renewLease(db, instanceID)
+	defer func() {
+		stopRenew()
+		// After a successful restore the leader row is the backup's own, which has long gone stale
+		_ = db.ReleaseLeader(context.Background(), instanceID)
+	}()
+	if err := db.RestoreFrom(ctx, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Restored from %s.\n", args[0])
+	return nil
+}
+
+// renewLease keeps instanceID's leader lease alive until the returned stop function is called.
+func renewLease(db *storage.DB, instanceID string) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		t := time.NewTicker(leaderLease / 2)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				cctx, cancel := storage.WithTimeout(context.Background())
+				if err := db.RenewLeader(cctx, instanceID); err != nil {
+					log.Printf("leader renew: %v", err)
+				}
+				cancel()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+// runExport writes one guild's roster as CSV, JSON or XLSX.
+func runExport(cfg *bot.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", storage.FormatCSV, "output format: csv, json or xlsx")
+	include := fs.String("include", storage.IncludeAll, "columns: resources, availability or all")
+	guildID := fs.String("guild", "", "guild id (default: first configured guild)")
+	out := fs.String("out", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *guildID == "" {
+		if gl := cfg.GuildList(); len(gl) > 0 {
+			*guildID = gl[0].GuildID
+		}
+	}
+	if *guildID == "" {
+		return errors.New("no guild configured; pass --guild")
+	}
+	db, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+	members, err := db.GetAllMembers(ctx, *guildID)
+	if err != nil {
+		return err
+	}
+	types, err := db.ListResourceTypes(ctx, *guildID)
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		types = cfg.ResourceTypesFor(*guildID)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	return storage.ExportMembers(w, *format, *include, types, members)
+}
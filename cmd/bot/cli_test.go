@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/Wartracker/internal/bot"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestRestoreHoldsLeaderLease(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	cfg := &bot.Config{DBPath: filepath.Join(dir, "live.db"), GuildID: "g1"}
+	db, err := openStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.UpsertMember(ctx, "g1", "1", "before"); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(dir, storage.BackupFileName(time.Now()))
+	if err := db.BackupTo(ctx, backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.UpsertMember(ctx, "g1", "1", "after"); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := db.TryAcquireLeader(ctx, "bot-1", leaderLease, false); !ok || err != nil {
+		t.Fatalf("acquire = %v, %v", ok, err)
+	}
+	if err := runRestore(cfg, []string{backup}); err == nil || !strings.Contains(err.Error(), "bot-1 holds the leader lease") {
+		t.Fatalf("restore while bot-1 leads = %v, want it refused", err)
+	}
+	if err := db.ReleaseLeader(ctx, "bot-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runRestore(cfg, []string{backup}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	members, err := db.GetAllMembers(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].InGameName != "before" {
+		t.Fatalf("members after restore = %+v, want the backed-up name", members)
+	}
+	if _, held, err := db.LeaderStatus(ctx, leaderLease); held || err != nil {
+		t.Fatalf("lease after restore held = %v, %v; want it free for the bot", held, err)
+	}
+}
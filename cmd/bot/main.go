@@ -2,18 +2,64 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/divijg19/Wartracker/internal/bot"
 	"github.com/divijg19/Wartracker/internal/storage"
 )
 
+// leaderLease is how long a leader may go without renewing before a standby takes over.
+const leaderLease = 10 * time.Second
+
+const usage = `usage: wartracker [command] [args]
+
+commands:
+  serve                          run the bot (default)
+  migrate                        apply pending schema migrations and exit
+  backup <dest>                  write a verified online backup to a file or directory
+  restore <file>                 replace the database with a backup (bot must be stopped)
+  export [--format csv|json|xlsx] [--include resources|availability|all] [--guild id] [--out file]
+                                 write a guild roster to stdout or a file
+`
+
 func main() {
-	// Load configuration from file if present, then override with environment variables for smooth container deploys
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
+	}
+	var err error
+	switch cmd {
+	case "serve":
+		runServe(loadConfig())
+		return
+	case "migrate":
+		err = runMigrate(loadConfig())
+	case "backup":
+		err = runBackup(loadConfig(), args)
+	case "restore":
+		err = runRestore(loadConfig(), args)
+	case "export":
+		err = runExport(loadConfig(), args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", cmd, err)
+	}
+}
+
+// loadConfig reads config.json if present, then applies environment overrides for smooth container deploys.
+func loadConfig() *bot.Config {
 	var cfg *bot.Config
 	if _, statErr := os.Stat("config.json"); statErr == nil {
 		c, err := bot.LoadConfig("config.json")
@@ -32,6 +78,22 @@ func main() {
 	if v := os.Getenv("DB_PATH"); v != "" {
 		cfg.DBPath = v
 	}
+	if v := os.Getenv("DATABASE_URL"); v != "" {
+		cfg.DBDriver = "postgres"
+		cfg.DSN = v
+	}
+	if v := os.Getenv("BACKUP_DIR"); v != "" {
+		cfg.BackupDir = v
+	}
+	if v := os.Getenv("BACKUP_INTERVAL"); v != "" {
+		cfg.BackupInterval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("BACKUP_KEEP_DAILY")); err == nil {
+		cfg.BackupKeepDaily = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("BACKUP_KEEP_WEEKLY")); err == nil {
+		cfg.BackupKeepWeekly = v
+	}
 	// Guilds: support single GUILD_ID or comma-separated GUILD_IDS
 	if v := os.Getenv("GUILD_ID"); v != "" {
 		cfg.Guilds = []bot.GuildConfig{{GuildID: v}}
@@ -58,24 +120,19 @@ func main() {
 	if v := os.Getenv("CUSTOM_ROOT_CA_PATH"); v != "" {
 		cfg.CustomRootCAPath = v
 	}
+	return cfg
+}
 
+// runServe connects to Discord and runs the bot until interrupted.
+func runServe(cfg *bot.Config) {
 	// Basic validation to avoid Discord 4004 auth failures with placeholders
 	if cfg.BotToken == "" || cfg.BotToken == "YOUR_DISCORD_BOT_TOKEN_HERE" {
 		log.Fatal("config error: BotToken is missing or placeholder; update config.json with a real token")
 	}
 	// No hard-fail if no guilds configured; we'll register global commands as a fallback
 
-	// Resolve DB path
-	dbPath := cfg.DBPath
-	if dbPath == "" {
-		dbPath = "guild_data.db"
-	}
-	if dir := filepath.Dir(dbPath); dir != "." && dir != "" {
-		_ = os.MkdirAll(dir, 0o755)
-	}
-
 	// Init database
-	db, err := storage.NewConnection(dbPath)
+	db, err := openStore(cfg)
 	if err != nil {
 		log.Fatalf("db: %v", err)
 	}
@@ -90,7 +147,7 @@ func main() {
 	// Leader election (active/standby) for zero-downtime deploys
 	host, _ := os.Hostname()
 	instanceID := fmt.Sprintf("%s-%d", host, os.Getpid())
-	lease := 10 * time.Second
+	lease := leaderLease
 	baseCtx := context.Background()
 
 	// Acquire leadership before connecting to Discord
@@ -119,7 +176,10 @@ func main() {
 			select {
 			case <-t.C:
 				cctx, cancel := storage.WithTimeout(baseCtx)
-				if err := db.RenewLeader(cctx, instanceID); err != nil {
+				if err := db.RenewLeader(cctx, instanceID); errors.Is(err, storage.ErrLeadershipLost) {
+					// Another instance may already be active; exit so the supervisor restarts us as standby
+					log.Fatalf("leader renew: %v", err)
+				} else if err != nil {
 					log.Printf("leader renew error: %v", err)
 				}
 				cancel()
@@ -146,6 +206,40 @@ func main() {
 	os.Exit(0)
 }
 
+// openStore opens the configured storage backend: PostgreSQL when DBDriver is "postgres"
+// (or only a DSN is set), otherwise SQLite at DBPath.
+func openStore(cfg *bot.Config) (*storage.DB, error) {
+	driver := cfg.DBDriver
+	if driver == "" && cfg.DSN != "" {
+		driver = "postgres"
+	}
+	switch driver {
+	case "postgres", "postgresql":
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("DBDriver %q requires DSN or DATABASE_URL", driver)
+		}
+		return storage.NewPostgresConnection(cfg.DSN)
+	case "", "sqlite", "sqlite3":
+	default:
+		return nil, fmt.Errorf("unknown DBDriver %q", driver)
+	}
+
+	// Resolve DB path
+	dbPath := cfg.DBPath
+	if dbPath == "" {
+		dbPath = "guild_data.db"
+	}
+	if dir := filepath.Dir(dbPath); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+	// Rows from single-roster databases are assigned to the first configured guild
+	legacyGuildID := ""
+	if gl := cfg.GuildList(); len(gl) > 0 {
+		legacyGuildID = gl[0].GuildID
+	}
+	return storage.NewConnection(dbPath, legacyGuildID)
+}
+
 // splitAndTrim splits a string by sep and trims whitespace around parts.
 func splitAndTrim(s string, sep rune) []string {
 	out := make([]string, 0)
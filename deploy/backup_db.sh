@@ -1,16 +1,17 @@
 #!/usr/bin/env bash
-# Simple SQLite backup script — uses sqlite3 online backup if available.
+# Simple SQLite backup script — uses the sqlite3 online backup, which is safe while the bot is
+# writing. A plain file copy is not, so sqlite3 is required.
 set -euo pipefail
 DB_PATH="${1:-./data/guild_data.db}"
 DEST_DIR="${2:-./backups}"
+
+if ! command -v sqlite3 >/dev/null 2>&1; then
+  echo "sqlite3 not found; install it (e.g. apt-get install sqlite3) to take backups" >&2
+  exit 1
+fi
+
 mkdir -p "$DEST_DIR"
 TS=$(date -u +"%Y%m%dT%H%M%SZ")
 DEST="$DEST_DIR/guild_data.$TS.db"
-
-if command -v sqlite3 >/dev/null 2>&1; then
-  sqlite3 "$DB_PATH" ".backup '$DEST'"
-  echo "Backup created: $DEST"
-else
-  cp "$DB_PATH" "$DEST"
-  echo "sqlite3 not found; raw copy created: $DEST"
-fi
+sqlite3 "$DB_PATH" ".backup '$DEST'"
+echo "Backup created: $DEST"
@@ -4,6 +4,7 @@ go 1.25
 
 require (
 	github.com/bwmarrin/discordgo v0.29.0
+	github.com/lib/pq v1.12.3
 	github.com/mattn/go-sqlite3 v1.14.32
 )
 
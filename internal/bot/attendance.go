@@ -0,0 +1,410 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	// warAttendancePrefix starts the custom id of the /war attendance menus,
+	// war_attendance:<war id>:<status>:<page>, and of its page buttons, war_attendance:<war id>:page:<page>.
+	warAttendancePrefix  = "war_attendance:"
+	attendancePageAction = "page"
+	// defaultStatsDays is the period /stats attendance covers unless days is given.
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+	// maxSelectOptions is Discord's limit on options per select menu.
+	maxSelectOptions = 25
+)
+
+// signupNames describes signup responses in the attendance menus.
+var signupNames = map[string]string{
+	storage.SignupJoin:    "Joined",
+	storage.SignupMaybe:   "Maybe",
+	storage.SignupDecline: "Declined",
+	"":                    "No response",
+}
+
+// attendanceCandidates lists the roster members the attendance menus offer: those who joined
+// first, then maybe, then everyone else, each by name.
+func attendanceCandidates(members []storage.Member, signups map[string]string) []storage.Member {
+	rank := map[string]int{storage.SignupJoin: 0, storage.SignupMaybe: 1}
+	order := func(m storage.Member) int {
+		if r, ok := rank[signups[m.DiscordID]]; ok {
+			return r
+		}
+		return 2
+	}
+	list := slices.Clone(members)
+	sort.SliceStable(list, func(a, b int) bool {
+		if order(list[a]) != order(list[b]) {
+			return order(list[a]) < order(list[b])
+		}
+		return strings.ToLower(list[a].InGameName) < strings.ToLower(list[b].InGameName)
+	})
+	return list
+}
+
+// attendancePage returns the candidates on page (from 0, clamped to the last page) and the number
+// of pages. Discord menus hold at most 25 options, so larger rosters are split across pages.
+func attendancePage(candidates []storage.Member, page int) ([]storage.Member, int, int) {
+	pages := max(1, (len(candidates)+maxSelectOptions-1)/maxSelectOptions)
+	page = min(max(page, 0), pages-1)
+	start := page * maxSelectOptions
+	return candidates[start:min(len(candidates), start+maxSelectOptions)], page, pages
+}
+
+// anyRecorded reports whether attendance was recorded for any of members.
+func anyRecorded(members []storage.Member, records map[string]string) bool {
+	return slices.ContainsFunc(members, func(m storage.Member) bool { return records[m.DiscordID] != "" })
+}
+
+// warAttendanceMessage builds the /war attendance summary and the Present and Excused menus for one
+// page of the roster, with buttons to the other pages. Until anything is recorded for a page, the
+// members on it who joined are pre-selected as present.
+func warAttendanceMessage(w storage.War, members []storage.Member, signups map[string]string, records map[string]string, page int) (string, []discordgo.MessageComponent) {
+	counts := make(map[string]int)
+	for _, st := range records {
+		counts[st]++
+	}
+	content := "Attendance for " + warTitle(w) + " (" + discordTime(w.StartsAt, "f") + "): "
+	if len(records) == 0 {
+		content += "nothing recorded yet. Members who joined are pre-selected as present."
+	} else {
+		content += fmt.Sprintf("%d present, %d absent, %d excused.", counts[storage.AttendancePresent], counts[storage.AttendanceAbsent], counts[storage.AttendanceExcused])
+	}
+	content += " Members who joined but are not marked present or excused are recorded absent."
+	candidates := attendanceCandidates(members, signups)
+	shown, page, pages := attendancePage(candidates, page)
+	if pages > 1 {
+		first := page*maxSelectOptions + 1
+		content += fmt.Sprintf("\nPage %d of %d: members %d-%d of %d. Each page is recorded on its own; use the buttons to reach the rest.",
+			page+1, pages, first, first+len(shown)-1, len(candidates))
+	}
+	fresh := !anyRecorded(shown, records)
+	id := warAttendancePrefix + strconv.FormatInt(w.ID, 10) + ":"
+	menu := func(status, placeholder string) discordgo.MessageComponent {
+		var opts []discordgo.SelectMenuOption
+		for _, m := range shown {
+			selected := records[m.DiscordID] == status
+			if fresh && status == storage.AttendancePresent {
+				selected = signups[m.DiscordID] == storage.SignupJoin
+			}
+			opts = append(opts, discordgo.SelectMenuOption{Label: m.InGameName, Value: m.DiscordID, Description: signupNames[signups[m.DiscordID]], Default: selected})
+		}
+		minValues := 0
+		return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			&discordgo.SelectMenu{
+				CustomID:    id + status + ":" + strconv.Itoa(page),
+				Placeholder: placeholder,
+				MinValues:   &minValues,
+				MaxValues:   len(opts),
+				Options:     opts,
+			},
+		}}
+	}
+	if len(candidates) == 0 {
+		return content + "\n(no members on the roster)", []discordgo.MessageComponent{}
+	}
+	components := []discordgo.MessageComponent{
+		menu(storage.AttendancePresent, "Who was present?"),
+		menu(storage.AttendanceExcused, "Who was excused?"),
+	}
+	if pages > 1 {
+		components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Previous", Style: discordgo.SecondaryButton, CustomID: id + attendancePageAction + ":" + strconv.Itoa(page-1), Disabled: page == 0},
+			discordgo.Button{Label: "Next", Style: discordgo.SecondaryButton, CustomID: id + attendancePageAction + ":" + strconv.Itoa(page+1), Disabled: page == pages-1},
+		}})
+	}
+	return content, components
+}
+
+// loadWarAttendance loads what the attendance menus need: the roster, signup responses and
+// recorded statuses by Discord ID.
+func loadWarAttendance(ctx context.Context, b *Bot, w storage.War) ([]storage.Member, map[string]string, map[string]string, error) {
+	members, err := b.DB.GetAllMembers(ctx, w.GuildID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	signups, err := b.DB.GetSignups(ctx, w.GuildID, w.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	attendance, err := b.DB.GetAttendance(ctx, w.GuildID, w.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	responses := make(map[string]string, len(signups))
+	for _, su := range signups {
+		responses[su.DiscordID] = su.Response
+	}
+	records := make(map[string]string, len(attendance))
+	for _, a := range attendance {
+		records[a.DiscordID] = a.Status
+	}
+	return members, responses, records, nil
+}
+
+// warAttendanceRespond answers /war attendance [war] with the attendance menus (officer or above).
+func warAttendanceRespond(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, w storage.War) {
+	if !requireTier(ctx, b, s, i, storage.TierOfficer, "/war attendance") {
+		return
+	}
+	if w.StartsAt.After(time.Now()) {
+		ephemeralErrorRespond(s, i, warTitle(w)+" has not started yet. Record attendance from "+discordTimestamp(w.StartsAt)+".")
+		return
+	}
+	members, signups, records, err := loadWarAttendance(ctx, b, w)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load attendance: "+err.Error())
+		return
+	}
+	content, components := warAttendanceMessage(w, members, signups, records, 0)
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Components: components, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// handleWarAttendanceSelect records the Present or Excused menu of /war attendance for the members
+// on its page, or shows another page. Members picked get that status; members dropped from it, and
+// members who joined without any record, are absent. The first submission on a page keeps the
+// Present menu's pre-selection.
+func handleWarAttendanceSelect(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	parts := strings.Split(strings.TrimPrefix(data.CustomID, warAttendancePrefix), ":")
+	if len(parts) < 2 {
+		return
+	}
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	status, page := parts[1], 0
+	if len(parts) > 2 {
+		page, _ = strconv.Atoi(parts[2])
+	}
+	if err != nil || (status != storage.AttendancePresent && status != storage.AttendanceExcused && status != attendancePageAction) {
+		return
+	}
+	ctx, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	if !requireTier(ctx, b, s, i, storage.TierOfficer, "/war attendance") {
+		return
+	}
+	w, err := b.DB.GetWar(ctx, i.GuildID, id)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load war: "+err.Error())
+		return
+	}
+	members, signups, records, err := loadWarAttendance(ctx, b, w)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load attendance: "+err.Error())
+		return
+	}
+	if status == attendancePageAction {
+		content, components := warAttendanceMessage(w, members, signups, records, page)
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+		})
+		return
+	}
+	shown, page, _ := attendancePage(attendanceCandidates(members, signups), page)
+	fresh := !anyRecorded(shown, records)
+	changes := make(map[string]string)
+	for _, m := range shown {
+		member := m.DiscordID
+		switch {
+		case slices.Contains(data.Values, member):
+			changes[member] = status
+		case records[member] == status:
+			changes[member] = storage.AttendanceAbsent
+		case records[member] == "" && signups[member] == storage.SignupJoin:
+			// Until something is recorded on the page its Present menu shows everyone who joined as present
+			if fresh && status == storage.AttendanceExcused {
+				changes[member] = storage.AttendancePresent
+			} else {
+				changes[member] = storage.AttendanceAbsent
+			}
+		}
+	}
+	if err := b.DB.SetAttendance(ctx, i.GuildID, w.ID, i.Member.User.ID, changes); err != nil {
+		ephemeralErrorRespond(s, i, "Failed to record attendance: "+err.Error())
+		return
+	}
+	for id, st := range changes {
+		records[id] = st
+	}
+	content, components := warAttendanceMessage(w, members, signups, records, page)
+	if w.Status == storage.WarComplete {
+		summary, err := settleWarOrders(ctx, b, w, i.Member.User.ID)
+		if err != nil {
+			summary = "Failed to settle War Orders: " + err.Error()
+		}
+		if summary != "" {
+			content += "\n" + summary
+		}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Content: content, Components: components},
+	})
+}
+
+// settleWarOrders applies the guild's /settings wars orders rule to a completed war: members
+// present are charged once, and members no longer present get their orders back. It returns a
+// summary of what changed, or "" if nothing did.
+func settleWarOrders(ctx context.Context, b *Bot, w storage.War, actorID string) (string, error) {
+	settings, err := b.DB.GetGuildSettings(ctx, w.GuildID)
+	if err != nil {
+		return "", err
+	}
+	types, _ := b.resourceTypes(ctx, w.GuildID)
+	orders, ok := findResourceType(types, storage.ResourceOrders)
+	if !ok {
+		return "", nil
+	}
+	changes, err := b.DB.SettleWarOrders(ctx, w.GuildID, w.ID, actorID, settings.WarOrdersCost, orders.Bounds)
+	if err != nil {
+		return "", err
+	}
+	var charged, refunded, total int
+	for _, ch := range changes {
+		if ch.NewValue < ch.OldValue {
+			charged++
+			total += ch.OldValue - ch.NewValue
+		} else {
+			refunded++
+		}
+	}
+	var parts []string
+	if charged > 0 {
+		parts = append(parts, fmt.Sprintf("Deducted %s %s from %d present.", formatNumber(total), orders.Label(), charged))
+	}
+	if refunded > 0 {
+		parts = append(parts, fmt.Sprintf("Returned %s to %d no longer present.", orders.Label(), refunded))
+	}
+	if len(parts) > 0 {
+		parts = append(parts, "See /history to audit.")
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// attendanceStats is one member's record over the /stats attendance period.
+type attendanceStats struct {
+	Name                     string
+	Present, Absent, Excused int
+	NoShows                  int // absent after joining
+	Streak, BestStreak       int // consecutive wars present; excused wars neither count nor break a run
+}
+
+// Rate is the share of wars attended, leaving out excused ones.
+func (a attendanceStats) Rate() float64 {
+	if a.Present+a.Absent == 0 {
+		return 0
+	}
+	return float64(a.Present) / float64(a.Present+a.Absent)
+}
+
+// summarizeAttendance folds records, oldest war first, into per-member stats keyed by Discord ID.
+func summarizeAttendance(records []storage.Attendance) map[string]*attendanceStats {
+	out := make(map[string]*attendanceStats)
+	for _, r := range records {
+		st := out[r.DiscordID]
+		if st == nil {
+			st = &attendanceStats{}
+			out[r.DiscordID] = st
+		}
+		st.Name = r.InGameName
+		switch r.Status {
+		case storage.AttendancePresent:
+			st.Present++
+			st.Streak++
+			st.BestStreak = max(st.BestStreak, st.Streak)
+		case storage.AttendanceAbsent:
+			st.Absent++
+			st.Streak = 0
+			if r.Signup == storage.SignupJoin {
+				st.NoShows++
+			}
+		case storage.AttendanceExcused:
+			st.Excused++
+		}
+	}
+	return out
+}
+
+// /stats attendance [days] (officer or above)
+func handleStats(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name != "attendance" {
+		return
+	}
+	days := defaultStatsDays
+	for _, o := range sub.Options {
+		if o.Name == "days" {
+			days = min(max(int(o.IntValue()), 1), maxStatsDays)
+		}
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	records, err := b.DB.ListAttendance(c, i.GuildID, since)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load attendance: "+err.Error())
+		return
+	}
+	if len(records) == 0 {
+		ephemeralErrorRespond(s, i, "No attendance recorded in the last "+strconv.Itoa(days)+" days. Record it with /war attendance after a war.")
+		return
+	}
+	wars := make(map[int64]bool)
+	for _, r := range records {
+		wars[r.WarID] = true
+	}
+	stats := summarizeAttendance(records)
+	ids := make([]string, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		x, y := stats[ids[a]], stats[ids[b]]
+		if x.Rate() != y.Rate() {
+			return x.Rate() > y.Rate()
+		}
+		if x.Present != y.Present {
+			return x.Present > y.Present
+		}
+		return strings.ToLower(x.Name) < strings.ToLower(y.Name)
+	})
+	var lines []string
+	for _, id := range ids {
+		st := stats[id]
+		line := fmt.Sprintf("<@%s> %s - **%.0f%%** (%d/%d), streak %d (best %d)", id, st.Name, st.Rate()*100, st.Present, st.Present+st.Absent, st.Streak, st.BestStreak)
+		if st.NoShows > 0 {
+			line += fmt.Sprintf(", %d no-show", st.NoShows)
+			if st.NoShows > 1 {
+				line += "s"
+			}
+		}
+		if st.Excused > 0 {
+			line += fmt.Sprintf(", %d excused", st.Excused)
+		}
+		lines = append(lines, line)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "War Attendance - last " + strconv.Itoa(days) + " days",
+		Description: joinLinesLimit(lines, embedDescriptionLimit),
+		Color:       0x00CC66,
+		Footer:      &discordgo.MessageEmbedFooter{Text: strconv.Itoa(len(wars)) + " wars with attendance recorded. Excused wars don't count against the rate or break streaks; a no-show joined but was absent."},
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, AllowedMentions: &discordgo.MessageAllowedMentions{}},
+	})
+}
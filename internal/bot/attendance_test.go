@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// componentClick builds the interaction Discord sends when a server administrator uses a
+// message component.
+func componentClick(guildID, customID string, values ...string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		Token:   "token",
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: guildID,
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "officer"}, Permissions: discordgo.PermissionAdministrator},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: customID, Values: values},
+	}}
+}
+
+// lastMenus decodes the latest interaction response into its content, the values offered by each
+// select menu by custom ID, and the custom IDs of its enabled buttons.
+func lastMenus(t *testing.T, fake *fakeDiscord) (string, map[string][]string, []string) {
+	t.Helper()
+	sent := fake.sent("/callback")
+	if len(sent) == 0 {
+		t.Fatal("no interaction response sent")
+	}
+	var resp struct {
+		Data struct {
+			Content    string `json:"content"`
+			Components []struct {
+				Components []struct {
+					Type     discordgo.ComponentType `json:"type"`
+					CustomID string                  `json:"custom_id"`
+					Disabled bool                    `json:"disabled"`
+					Options  []struct {
+						Value string `json:"value"`
+					} `json:"options"`
+				} `json:"components"`
+			} `json:"components"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(sent[len(sent)-1].Body, &resp); err != nil {
+		t.Fatalf("decode interaction response: %v", err)
+	}
+	menus := make(map[string][]string)
+	var buttons []string
+	for _, row := range resp.Data.Components {
+		for _, c := range row.Components {
+			switch {
+			case c.Type == discordgo.ButtonComponent && !c.Disabled:
+				buttons = append(buttons, c.CustomID)
+			case c.Type == discordgo.SelectMenuComponent:
+				for _, o := range c.Options {
+					menus[c.CustomID] = append(menus[c.CustomID], o.Value)
+				}
+			}
+		}
+	}
+	return resp.Data.Content, menus, buttons
+}
+
+func TestWarAttendancePages(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	id, err := b.DB.CreateWar(ctx, storage.War{GuildID: "g", StartsAt: time.Now().Add(-time.Hour), CreatedBy: "officer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := range 30 {
+		member := fmt.Sprintf("%02d", n)
+		if err := b.DB.UpsertMember(ctx, "g", member, "member "+member); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.DB.SetSignup(ctx, "g", id, member, storage.SignupJoin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prefix := fmt.Sprintf("%s%d:", warAttendancePrefix, id)
+
+	handleComponentInteraction(b, b.Session, componentClick("g", prefix+"page:1"))
+	content, menus, buttons := lastMenus(t, fake)
+	if !strings.Contains(content, "Page 2 of 2: members 26-30 of 30.") {
+		t.Fatalf("second page content = %q", content)
+	}
+	if got := menus[prefix+"present:1"]; len(got) != 5 || got[0] != "25" || got[4] != "29" {
+		t.Fatalf("second page Present menu = %v", got)
+	}
+	if len(buttons) != 1 || buttons[0] != prefix+"page:0" {
+		t.Fatalf("enabled page buttons on the last page = %v", buttons)
+	}
+
+	// Recording the second page leaves the first one untouched
+	handleComponentInteraction(b, b.Session, componentClick("g", prefix+"present:1", "25", "26"))
+	attendance, err := b.DB.GetAttendance(ctx, "g", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]string)
+	for _, a := range attendance {
+		statuses[a.DiscordID] = a.Status
+	}
+	want := map[string]string{"25": storage.AttendancePresent, "26": storage.AttendancePresent, "27": storage.AttendanceAbsent, "28": storage.AttendanceAbsent, "29": storage.AttendanceAbsent}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Fatalf("attendance = %v, want %v", statuses, want)
+	}
+
+	// The first page still offers its own 25 members
+	handleComponentInteraction(b, b.Session, componentClick("g", prefix+"page:0"))
+	_, menus, buttons = lastMenus(t, fake)
+	if got := menus[prefix+"present:0"]; len(got) != maxSelectOptions || got[0] != "00" {
+		t.Fatalf("first page Present menu = %v", got)
+	}
+	if len(buttons) != 1 || buttons[0] != prefix+"page:1" {
+		t.Fatalf("enabled page buttons on the first page = %v", buttons)
+	}
+}
+
+func TestSummarizeAttendance(t *testing.T) {
+	var records []storage.Attendance
+	for _, st := range []string{
+		storage.AttendancePresent, storage.AttendancePresent, storage.AttendanceExcused, storage.AttendancePresent,
+		storage.AttendanceAbsent, storage.AttendancePresent,
+	} {
+		records = append(records, storage.Attendance{DiscordID: "1", InGameName: "Alpha", Status: st, Signup: storage.SignupJoin})
+	}
+	records = append(records, storage.Attendance{DiscordID: "2", InGameName: "Bravo", Status: storage.AttendanceAbsent})
+	stats := summarizeAttendance(records)
+	alpha := stats["1"]
+	// The excused war neither counts nor breaks the run of three
+	if alpha.Present != 4 || alpha.Absent != 1 || alpha.Excused != 1 || alpha.NoShows != 1 || alpha.Streak != 1 || alpha.BestStreak != 3 {
+		t.Fatalf("Alpha = %+v", alpha)
+	}
+	if alpha.Rate() != 0.8 {
+		t.Fatalf("Alpha rate = %v, want 0.8", alpha.Rate())
+	}
+	// Absent without joining is not a no-show
+	if bravo := stats["2"]; bravo.NoShows != 0 || bravo.Rate() != 0 {
+		t.Fatalf("Bravo = %+v", bravo)
+	}
+	if (attendanceStats{Excused: 2}).Rate() != 0 {
+		t.Fatal("rate with only excused wars is not 0")
+	}
+}
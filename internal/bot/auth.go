@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// commandTiers is the minimum tier required for each slash command. Commands not listed need no tier.
+var commandTiers = map[string]storage.Tier{
+	"register":         storage.TierMember,
+	"order":            storage.TierMember,
+	"lumber":           storage.TierMember,
+	"resource":         storage.TierMember,
+	"availability":     storage.TierMember,
+	"timezone":         storage.TierMember,
+	"reminders":        storage.TierMember,
+	"profile":          storage.TierMember,
+	"war":              storage.TierMember, // subcommands other than lineup and history check for officer themselves
+	profileContextMenu: storage.TierMember,
+	"history":          storage.TierOfficer,
+	"roster":           storage.TierOfficer,
+	"list":             storage.TierOfficer,
+	"export":           storage.TierOfficer,
+	"recommend":        storage.TierOfficer,
+	"stats":            storage.TierOfficer,
+	"season":           storage.TierOfficer, // start and end check for leader themselves
+	"syncroles":        storage.TierLeader,
+	"perm":             storage.TierLeader,
+	"resourcetype":     storage.TierLeader,
+	"settings":         storage.TierLeader,
+	"backup":           storage.TierLeader,
+}
+
+// restrictedDefaultPermissions hides officer and leader commands from regular members in the Discord client.
+// Server admins can grant visibility to officer/leader roles under Server Settings > Integrations.
+var restrictedDefaultPermissions int64 = discordgo.PermissionManageGuild
+
+// memberTier resolves the member's effective tier in a guild: the highest tier granted to the user
+// or any of their roles. Server administrators are always leaders. Until a guild grants the member
+// tier to someone explicitly, everyone in the server counts as a member.
+func memberTier(ctx context.Context, b *Bot, guildID string, member *discordgo.Member) (storage.Tier, error) {
+	if member == nil || member.User == nil {
+		return storage.TierNone, nil
+	}
+	if member.Permissions&discordgo.PermissionAdministrator != 0 {
+		return storage.TierLeader, nil
+	}
+	grants, err := b.DB.ListPermissions(ctx, guildID)
+	if err != nil {
+		return storage.TierNone, err
+	}
+	tier := storage.TierMember
+	for _, g := range grants {
+		if g.Tier == storage.TierMember {
+			tier = storage.TierNone
+			break
+		}
+	}
+	roles := make(map[string]bool, len(member.Roles))
+	for _, r := range member.Roles {
+		roles[r] = true
+	}
+	for _, g := range grants {
+		match := (g.SubjectType == storage.SubjectUser && g.SubjectID == member.User.ID) ||
+			(g.SubjectType == storage.SubjectRole && roles[g.SubjectID])
+		if match && g.Tier > tier {
+			tier = g.Tier
+		}
+	}
+	return tier, nil
+}
+
+// requireTier rejects callers below the required tier and reports whether handling should continue.
+func requireTier(ctx context.Context, b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, required storage.Tier, what string) bool {
+	if i.Member == nil {
+		ephemeralErrorRespond(s, i, "Wartracker commands can only be used inside a server.")
+		return false
+	}
+	if required == storage.TierNone {
+		return true
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	tier, err := memberTier(c, b, i.GuildID, i.Member)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to check permissions: "+err.Error())
+		return false
+	}
+	if tier < required {
+		ephemeralErrorRespond(s, i, "You need the "+required.String()+" tier or higher to use "+what+".")
+		return false
+	}
+	return true
+}
+
+// authorizeCommand checks the caller against commandTiers before dispatch.
+func authorizeCommand(ctx context.Context, b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, name string) bool {
+	return requireTier(ctx, b, s, i, commandTiers[name], "/"+name)
+}
+
+// seedPermissions grants the leader tier to config.json LeaderRoleIDs/LeaderUserIDs in guilds
+// that have no stored grants yet.
+func (b *Bot) seedPermissions() {
+	for _, g := range b.Config.GuildList() {
+		if g.GuildID == "" {
+			continue
+		}
+		var grants []storage.PermissionGrant
+		for _, id := range g.LeaderRoleIDs {
+			grants = append(grants, storage.PermissionGrant{GuildID: g.GuildID, SubjectType: storage.SubjectRole, SubjectID: id, Tier: storage.TierLeader})
+		}
+		for _, id := range g.LeaderUserIDs {
+			grants = append(grants, storage.PermissionGrant{GuildID: g.GuildID, SubjectType: storage.SubjectUser, SubjectID: id, Tier: storage.TierLeader})
+		}
+		if len(grants) == 0 {
+			continue
+		}
+		ctx, cancel := storage.WithTimeout(context.Background())
+		if err := b.DB.SeedPermissions(ctx, g.GuildID, grants); err != nil {
+			log.Printf("WARN: seed permissions for guild %s: %v", g.GuildID, err)
+		}
+		cancel()
+	}
+}
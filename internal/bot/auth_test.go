@@ -0,0 +1,57 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestCommandTiers(t *testing.T) {
+	b, fake := newTestBot(t)
+	b.Config.Guilds = []GuildConfig{{GuildID: "g", LeaderRoleIDs: []string{"lead"}}}
+	b.seedPermissions()
+	run := func(i *discordgo.InteractionCreate) discordgo.InteractionResponse {
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t)
+	}
+
+	if resp := run(slashCommand("g", "u1", "list", subcommand("current"))); resp.Data.Content != "You need the officer tier or higher to use /list." {
+		t.Fatalf("/list as a member = %q", resp.Data.Content)
+	}
+	byRole := slashCommand("g", "u1", "list", subcommand("current"))
+	byRole.Member.Roles = []string{"lead"}
+	admin := slashCommand("g", "u2", "list", subcommand("current"))
+	admin.Member.Permissions = discordgo.PermissionAdministrator
+	for name, i := range map[string]*discordgo.InteractionCreate{"seeded leader role": byRole, "administrator": admin} {
+		if resp := run(i); len(resp.Data.Embeds) != 1 {
+			t.Fatalf("/list as %s = %+v, want the roster embed", name, resp.Data)
+		}
+	}
+	// Grants are per guild
+	other := slashCommand("other", "u1", "list", subcommand("current"))
+	other.Member.Roles = []string{"lead"}
+	if resp := run(other); resp.Data.Content != "You need the officer tier or higher to use /list." {
+		t.Fatalf("/list with a role that leads another guild = %q", resp.Data.Content)
+	}
+
+	// Everyone is a member until the member tier is granted to someone explicitly
+	if resp := run(slashCommand("g", "u1", "register", stringOption("in-game-name", "Alpha"))); resp.Data.Content != "You have been registered as Alpha." {
+		t.Fatalf("/register before any member grant = %q", resp.Data.Content)
+	}
+	if err := b.DB.GrantPermission(t.Context(), "g", storage.SubjectUser, "u2", storage.TierMember); err != nil {
+		t.Fatal(err)
+	}
+	if resp := run(slashCommand("g", "u3", "register", stringOption("in-game-name", "Nobody"))); resp.Data.Content != "You need the member tier or higher to use /register." {
+		t.Fatalf("/register without the member tier = %q", resp.Data.Content)
+	}
+	if ok, _ := b.DB.EnsureMemberExists(t.Context(), "g", "u3"); ok {
+		t.Fatal("rejected /register still stored the member")
+	}
+
+	dm := slashCommand("", "u1", "register", stringOption("in-game-name", "Alpha"))
+	dm.Member, dm.User = nil, &discordgo.User{ID: "u1"}
+	if resp := run(dm); resp.Data.Content != "Wartracker commands can only be used inside a server." {
+		t.Fatalf("/register in a DM = %q", resp.Data.Content)
+	}
+}
@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	// maxAutocompleteChoices is Discord's limit on choices per autocomplete response.
+	maxAutocompleteChoices = 25
+	// minMemberScore drops weak fuzzy matches so typos still match but unrelated names do not.
+	minMemberScore = 0.45
+)
+
+// handleAutocomplete answers autocomplete requests for the focused option of any command.
+func handleAutocomplete(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
+	data := i.ApplicationCommandData()
+	focused := focusedOption(data.Options)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if focused != nil {
+		ctx, cancel := storage.WithTimeout(context.Background())
+		defer cancel()
+		switch focused.Name {
+		case "character":
+			choices = characterChoices(ctx, b, i, data.Name, focused.StringValue())
+		case "member":
+			choices = memberChoices(ctx, b, s, i.GuildID, focused.StringValue())
+		case "type", "resource":
+			choices = resourceTypeChoices(ctx, b, i.GuildID, focused.StringValue())
+		case "zone":
+			choices = timeZoneChoices(focused.StringValue())
+		case "slot":
+			choices = availabilitySlotChoices(ctx, b, i.GuildID, focused.StringValue())
+		case "war":
+			choices = warChoices(ctx, b, i.GuildID, focused.StringValue())
+		case "opponent":
+			choices = opponentChoices(ctx, b, i.GuildID, focused.StringValue())
+		case "season":
+			choices = seasonChoices(ctx, b, i.GuildID, focused.StringValue())
+		}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// focusedOption finds the option being typed, descending into subcommands and groups.
+func focusedOption(opts []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	for _, o := range opts {
+		if o.Focused {
+			return o
+		}
+		if f := focusedOption(o.Options); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// memberNames lists the names a roster member can be found by: main and alt in-game names,
+// plus their Discord username, display name and server nickname when the member is cached.
+func memberNames(s *discordgo.Session, m storage.Member, alts []storage.Character) []string {
+	names := []string{m.InGameName}
+	for _, a := range alts {
+		names = append(names, a.Name)
+	}
+	if dm, err := s.State.Member(m.GuildID, m.DiscordID); err == nil && dm.User != nil {
+		names = append(names, dm.User.Username)
+		if dm.User.GlobalName != "" {
+			names = append(names, dm.User.GlobalName)
+		}
+		if dm.Nick != "" {
+			names = append(names, dm.Nick)
+		}
+	}
+	return names
+}
+
+// memberChoices ranks roster members by how closely any of their names matches typed.
+// Choice values are Discord IDs; an empty query lists the roster alphabetically.
+func memberChoices(ctx context.Context, b *Bot, s *discordgo.Session, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	members, err := b.DB.GetAllMembers(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	alts := make(map[string][]storage.Character)
+	if chars, err := b.DB.GetCharacters(ctx, guildID, ""); err == nil {
+		for _, c := range chars {
+			alts[c.DiscordID] = append(alts[c.DiscordID], c)
+		}
+	}
+	type ranked struct {
+		member storage.Member
+		label  string
+		score  float64
+	}
+	query := strings.ToLower(strings.TrimSpace(typed))
+	var list []ranked
+	for _, m := range members {
+		names := memberNames(s, m, alts[m.DiscordID])
+		best, bestName := 0.0, ""
+		for _, n := range names {
+			if sc := similarity(query, strings.ToLower(n)); sc > best {
+				best, bestName = sc, n
+			}
+		}
+		if query != "" && best < minMemberScore {
+			continue
+		}
+		label := m.InGameName
+		if bestName != "" && bestName != m.InGameName {
+			label += " (" + bestName + ")"
+		}
+		list = append(list, ranked{member: m, label: label, score: best})
+	}
+	// GetAllMembers is sorted by name, so a stable sort keeps ties alphabetical
+	sort.SliceStable(list, func(a, b int) bool { return list[a].score > list[b].score })
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, r := range list {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		label := r.label
+		if utf8.RuneCountInString(label) > 100 {
+			label = string([]rune(label)[:99]) + "…"
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: label, Value: r.member.DiscordID})
+	}
+	return choices
+}
+
+// similarity scores how well a lowercase query matches a lowercase name, from 0 to 1.
+// Exact, prefix and substring matches rank highest; otherwise edit distance decides, also
+// against the name's prefix so partially typed names with typos still match.
+func similarity(query, name string) float64 {
+	switch {
+	case query == "":
+		return 0
+	case query == name:
+		return 1
+	case strings.HasPrefix(name, query):
+		return 0.9
+	case strings.Contains(name, query):
+		return 0.8
+	}
+	q, n := []rune(query), []rune(name)
+	whole := 1 - float64(editDistance(q, n))/float64(max(len(q), len(n)))
+	prefix := 0.0
+	if len(n) > len(q) {
+		prefix = 0.9 * (1 - float64(editDistance(q, n[:len(q)]))/float64(len(q)))
+	}
+	return max(whole, prefix) * 0.75
+}
+
+// editDistance is the optimal string alignment distance between a and b: insertions,
+// deletions, substitutions and adjacent transpositions (the usual typing slips) each cost 1.
+func editDistance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// resolveMember maps a member option value to a roster row. Autocomplete submits Discord IDs,
+// but a typed in-game or alt name is accepted when it matches exactly one member.
+func resolveMember(ctx context.Context, b *Bot, guildID, value string) (*storage.Member, error) {
+	members, err := b.DB.GetAllMembers(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	value = strings.TrimSpace(value)
+	for n := range members {
+		if members[n].DiscordID == value {
+			return &members[n], nil
+		}
+	}
+	owners := make(map[string]bool)
+	for _, m := range members {
+		if strings.EqualFold(m.InGameName, value) {
+			owners[m.DiscordID] = true
+		}
+	}
+	if chars, err := b.DB.GetCharacters(ctx, guildID, ""); err == nil {
+		for _, c := range chars {
+			if strings.EqualFold(c.Name, value) {
+				owners[c.DiscordID] = true
+			}
+		}
+	}
+	if len(owners) != 1 {
+		return nil, nil
+	}
+	for n := range members {
+		if owners[members[n].DiscordID] {
+			return &members[n], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"ragnar", "rangar", 1}, // adjacent transposition
+		{"same", "same", 0},
+	} {
+		if got := editDistance([]rune(c.a), []rune(c.b)); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSimilarityOrder(t *testing.T) {
+	exact, prefix, substring := similarity("rag", "rag"), similarity("rag", "ragnar"), similarity("rag", "foragnar")
+	typo, unrelated := similarity("ragnra", "ragnar"), similarity("zzz", "ragnar")
+	if !(exact > prefix && prefix > substring && substring > typo && typo >= minMemberScore && unrelated < minMemberScore) {
+		t.Fatalf("scores exact %.2f, prefix %.2f, substring %.2f, typo %.2f, unrelated %.2f out of order", exact, prefix, substring, typo, unrelated)
+	}
+	// A partially typed name with a slip still matches through its prefix
+	if sc := similarity("rgan", "ragnarok the bold"); sc < minMemberScore {
+		t.Fatalf("prefix typo score %.2f, want at least %.2f", sc, minMemberScore)
+	}
+}
+
+func TestMemberChoicesAndResolve(t *testing.T) {
+	b, _ := newTestBot(t)
+	ctx := t.Context()
+	for id, name := range map[string]string{"1": "Ragnar", "2": "Bjorn", "3": "Lagertha"} {
+		if err := b.DB.UpsertMember(ctx, "g", id, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.DB.AddCharacter(ctx, "g", "2", "Ragnhild"); err != nil {
+		t.Fatal(err)
+	}
+	values := func(choices []*discordgo.ApplicationCommandOptionChoice) (list []string) {
+		for _, c := range choices {
+			list = append(list, c.Value.(string)+"="+c.Name)
+		}
+		return list
+	}
+
+	if got := values(memberChoices(ctx, b, b.Session, "g", "")); len(got) != 3 || got[0] != "2=Bjorn" || got[2] != "1=Ragnar" {
+		t.Fatalf("empty query = %q, want the roster alphabetically", got)
+	}
+	if got := values(memberChoices(ctx, b, b.Session, "g", "ragn")); len(got) != 2 || got[0] != "2=Bjorn (Ragnhild)" || got[1] != "1=Ragnar" {
+		t.Fatalf("prefix query = %q, want both prefix matches with the alt named", got)
+	}
+	if got := values(memberChoices(ctx, b, b.Session, "g", "lagretha")); len(got) != 1 || got[0] != "3=Lagertha" {
+		t.Fatalf("typo query = %q", got)
+	}
+
+	for value, want := range map[string]string{"1": "Ragnar", "ragnhild": "Bjorn", " LAGERTHA ": "Lagertha", "Floki": ""} {
+		m, err := resolveMember(ctx, b, "g", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if m != nil {
+			got = m.InGameName
+		}
+		if got != want {
+			t.Errorf("resolveMember(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// availabilitySelectID prefixes the /availability select menu's custom id; the day option value follows.
+const availabilitySelectID = "availability_select_menu"
+
+// notAvailableSlot marks a day the member cannot play; it excludes every other slot. It has no
+// time, so it is stored on the member's local weekday.
+const notAvailableSlot = "Not Available"
+
+// dayGroups are the multi-day values of the /availability day option.
+var dayGroups = map[string][]time.Weekday{
+	"all":      storage.Week,
+	"weekdays": storage.Week[:5],
+	"weekend":  storage.Week[5:],
+}
+
+// dayChoices lists the weekdays as option choices, preceded by the day groups when groups is set.
+func dayChoices(groups bool) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if groups {
+		choices = append(choices,
+			&discordgo.ApplicationCommandOptionChoice{Name: "Every day", Value: "all"},
+			&discordgo.ApplicationCommandOptionChoice{Name: "Weekdays (Mon-Fri)", Value: "weekdays"},
+			&discordgo.ApplicationCommandOptionChoice{Name: "Weekend (Sat-Sun)", Value: "weekend"},
+		)
+	}
+	for _, d := range storage.Week {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: d.String(), Value: strings.ToLower(storage.DayName(d))})
+	}
+	return choices
+}
+
+// parseDays maps a day option value to the weekdays it covers.
+func parseDays(v string) ([]time.Weekday, bool) {
+	if days, ok := dayGroups[v]; ok {
+		return days, true
+	}
+	if d, ok := storage.ParseWeekday(v); ok {
+		return []time.Weekday{d}, true
+	}
+	return nil, false
+}
+
+// describeDays names a day option value for replies, e.g. "on Monday" or "on weekdays".
+func describeDays(v string) string {
+	switch v {
+	case "all":
+		return "every day"
+	case "weekdays", "weekend":
+		return "on " + v
+	}
+	if d, ok := storage.ParseWeekday(v); ok {
+		return "on " + d.String()
+	}
+	return v
+}
+
+// availabilitySlots returns the slots members of a guild can pick, in menu order. Guilds that
+// never used /settings availability get the default evening windows.
+func (b *Bot) availabilitySlots(ctx context.Context, guildID string) ([]storage.AvailabilitySlot, error) {
+	slots, err := b.DB.ListAvailabilitySlots(ctx, guildID)
+	if err != nil || len(slots) > 0 {
+		return slots, err
+	}
+	return storage.DefaultAvailabilitySlots(), nil
+}
+
+func findSlot(slots []storage.AvailabilitySlot, label string) (storage.AvailabilitySlot, bool) {
+	for _, sl := range slots {
+		if sl.Label == label {
+			return sl, true
+		}
+	}
+	return storage.AvailabilitySlot{}, false
+}
+
+// validAvailability reports whether v can be stored in a schedule.
+func validAvailability(slots []storage.AvailabilitySlot, v string) bool {
+	_, ok := findSlot(slots, v)
+	return ok || v == notAvailableSlot
+}
+
+// nextDay returns midnight in loc of the first day from now (today included) falling on d.
+func nextDay(now time.Time, loc *time.Location, d time.Weekday) time.Time {
+	n := now.In(loc)
+	today := time.Date(n.Year(), n.Month(), n.Day(), 0, 0, 0, 0, loc)
+	return today.AddDate(0, 0, (int(d)-int(n.Weekday())+7)%7)
+}
+
+// slotStart returns when slot starts during the local day beginning at day. ok is false if it
+// does not start that day, which only happens around daylight saving changes.
+func slotStart(day time.Time, slot storage.AvailabilitySlot) (time.Time, bool) {
+	next := day.AddDate(0, 0, 1)
+	u := day.UTC()
+	midnight := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+	for off := -1; off <= 1; off++ {
+		t := midnight.AddDate(0, 0, off).Add(time.Duration(slot.Start) * time.Minute)
+		if !t.Before(day) && t.Before(next) {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// localSchedule converts a stored schedule, keyed by UTC weekday, to the weekdays the slots fall
+// on in loc. Labels without a slot time (Not Available, removed slots) keep their day.
+func localSchedule(sched storage.Schedule, loc *time.Location, slots []storage.AvailabilitySlot, now time.Time) storage.Schedule {
+	out := make(storage.Schedule)
+	for day, labels := range sched {
+		for _, label := range labels {
+			local := day
+			if sl, ok := findSlot(slots, label); ok {
+				local = nextDay(now, time.UTC, day).Add(time.Duration(sl.Start) * time.Minute).In(loc).Weekday()
+			}
+			out[local] = append(out[local], label)
+		}
+	}
+	return out
+}
+
+// utcSchedule is the inverse of localSchedule: it keys a schedule picked in loc by UTC weekday.
+func utcSchedule(sched storage.Schedule, loc *time.Location, slots []storage.AvailabilitySlot, now time.Time) storage.Schedule {
+	out := make(storage.Schedule)
+	for day, labels := range sched {
+		for _, label := range labels {
+			utc := day
+			if sl, ok := findSlot(slots, label); ok {
+				if t, ok := slotStart(nextDay(now, loc, day), sl); ok {
+					utc = t.UTC().Weekday()
+				}
+			}
+			out[utc] = append(out[utc], label)
+		}
+	}
+	return out
+}
+
+// slotTimes names a slot by its stored UTC times, e.g. "18:00-20:00 GMT".
+func slotTimes(slot storage.AvailabilitySlot) string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d GMT", slot.Start/60, slot.Start%60, slot.End/60, slot.End%60)
+}
+
+// slotLabel shows a slot in loc on the local day beginning at day, e.g. "14:00-16:00 EDT", or
+// "Raid prime (14:00-16:00 EDT)" for slots with a custom label. Members in UTC see the stored label.
+func slotLabel(slot storage.AvailabilitySlot, loc *time.Location, day time.Time) string {
+	if loc == time.UTC {
+		return slot.Label
+	}
+	start, ok := slotStart(day, slot)
+	if !ok {
+		return slot.Label
+	}
+	end := start.Add(slot.Duration())
+	local := start.In(loc).Format("15:04") + "-" + end.In(loc).Format("15:04 MST")
+	if slot.Label != slotTimes(slot) {
+		return slot.Label + " (" + local + ")"
+	}
+	return local
+}
+
+// displaySchedule renders a stored schedule with days and slot times in loc, each day's slots
+// in the order they start.
+func displaySchedule(sched storage.Schedule, loc *time.Location, slots []storage.AvailabilitySlot, now time.Time) storage.Schedule {
+	out := make(storage.Schedule)
+	for day, labels := range localSchedule(sched, loc, slots, now) {
+		start := nextDay(now, loc, day)
+		var timed []storage.AvailabilitySlot
+		for _, label := range labels {
+			if sl, ok := findSlot(slots, label); ok {
+				timed = append(timed, sl)
+			} else {
+				out[day] = append(out[day], label)
+			}
+		}
+		sort.Slice(timed, func(a, b int) bool {
+			ta, _ := slotStart(start, timed[a])
+			tb, _ := slotStart(start, timed[b])
+			return ta.Before(tb)
+		})
+		for _, sl := range timed {
+			out[day] = append(out[day], slotLabel(sl, loc, start))
+		}
+	}
+	return out
+}
+
+// /availability [day] shows a multi-select menu of slots, in the member's time zone, for the
+// chosen local day (default every day)
+func handleAvailability(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	day := "all"
+	for _, o := range i.ApplicationCommandData().Options {
+		if o.Name == "day" {
+			day = o.StringValue()
+		}
+	}
+	days, ok := parseDays(day)
+	if !ok {
+		ephemeralErrorRespond(s, i, "Unknown day: "+day)
+		return
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	mem, err := findMember(c, b, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+		return
+	}
+	if mem == nil {
+		ephemeralErrorRespond(s, i, "You must /register first.")
+		return
+	}
+	slots, err := b.availabilitySlots(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+		return
+	}
+	loc, now := memberLocation(mem), time.Now()
+	local := localSchedule(mem.Availability, loc, slots, now)
+	// Pre-select the current slots when every chosen day has the same ones
+	current := local[days[0]]
+	for _, d := range days[1:] {
+		if !sameSlots(local[d], current) {
+			current = nil
+			break
+		}
+	}
+	var opts []discordgo.SelectMenuOption
+	for _, sl := range slots {
+		opt := discordgo.SelectMenuOption{Label: slotLabel(sl, loc, nextDay(now, loc, days[0])), Value: sl.Label, Default: slices.Contains(current, sl.Label)}
+		if opt.Label != slotTimes(sl) {
+			opt.Description = slotTimes(sl)
+		}
+		opts = append(opts, opt)
+	}
+	opts = append(opts, discordgo.SelectMenuOption{Label: notAvailableSlot, Value: notAvailableSlot, Default: slices.Contains(current, notAvailableSlot)})
+	zone := "GMT"
+	if loc != time.UTC {
+		zone = "your time zone, " + loc.String()
+	}
+	minValues := 0
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Select every slot you can play " + describeDays(day) + " (times in " + zone + "; change it with /timezone):",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					&discordgo.SelectMenu{
+						CustomID:    availabilitySelectID + ":" + day,
+						Placeholder: "Choose time slots (none to clear)",
+						MinValues:   &minValues,
+						MaxValues:   len(opts),
+						Options:     opts,
+					},
+				}},
+			},
+		},
+	})
+}
+
+// sameSlots compares two days' labels ignoring order.
+func sameSlots(a, b []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
+// handleAvailabilitySelect stores the slots picked in the /availability menu.
+func handleAvailabilitySelect(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData) {
+	// Menus sent before availability was per weekday have no day suffix and apply to every day
+	day := "all"
+	if _, v, ok := strings.Cut(data.CustomID, ":"); ok {
+		day = v
+	}
+	days, ok := parseDays(day)
+	if !ok {
+		ephemeralErrorRespond(s, i, "Unknown day: "+day)
+		return
+	}
+	picked := data.Values
+	if slices.Contains(picked, notAvailableSlot) {
+		picked = []string{notAvailableSlot}
+	}
+	ctx, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	if !requireTier(ctx, b, s, i, storage.TierMember, "/availability") {
+		return
+	}
+	respond := func(content string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+	mem, err := findMember(ctx, b, i.GuildID, i.Member.User.ID)
+	if err == nil && mem == nil {
+		err = storage.ErrMemberNotRegistered
+	}
+	if err != nil {
+		respond("Failed to set availability: " + err.Error())
+		return
+	}
+	slots, err := b.availabilitySlots(ctx, i.GuildID)
+	if err != nil {
+		respond("Failed to set availability: " + err.Error())
+		return
+	}
+	for _, v := range picked {
+		if !validAvailability(slots, v) {
+			respond("The slot " + v + " no longer exists. Run /availability again.")
+			return
+		}
+	}
+	loc, now := memberLocation(mem), time.Now()
+	local := localSchedule(mem.Availability, loc, slots, now)
+	for _, d := range days {
+		local[d] = picked
+	}
+	if err := b.DB.SetAvailability(ctx, i.GuildID, i.Member.User.ID, utcSchedule(local, loc, slots, now)); err != nil {
+		respond("Failed to set availability: " + err.Error())
+		return
+	}
+	if len(picked) == 0 {
+		respond("Your availability " + describeDays(day) + " has been cleared.")
+		return
+	}
+	var labels []string
+	for _, v := range picked {
+		label := v
+		if sl, ok := findSlot(slots, v); ok {
+			label = slotLabel(sl, loc, nextDay(now, loc, days[0]))
+		}
+		labels = append(labels, label)
+	}
+	respond("Your availability " + describeDays(day) + " has been set to " + strings.Join(labels, ", ") + ".")
+}
+
+// availabilityWeekLines lists each member's week with days and times in the viewer's zone.
+func availabilityWeekLines(members []storage.Member, loc *time.Location, slots []storage.AvailabilitySlot, now time.Time) []string {
+	var lines []string
+	for _, m := range members {
+		lines = append(lines, m.InGameName+" - "+displaySchedule(m.Availability, loc, slots, now).String())
+	}
+	return lines
+}
+
+// availabilityDayLines lists each member's slots on the viewer's local day starting at day as
+// Discord timestamps, which every reader sees in their own local time.
+func availabilityDayLines(members []storage.Member, day time.Time, slots []storage.AvailabilitySlot) []string {
+	var lines []string
+	for _, m := range members {
+		type window struct {
+			start time.Time
+			text  string
+		}
+		var windows []window
+		var other []string
+		for utcDay, labels := range m.Availability {
+			for _, label := range labels {
+				sl, ok := findSlot(slots, label)
+				if !ok {
+					// No time to convert; stored on the member's local day
+					if utcDay == day.Weekday() {
+						other = append(other, label)
+					}
+					continue
+				}
+				if t, ok := slotStart(day, sl); ok && t.UTC().Weekday() == utcDay {
+					windows = append(windows, window{t, discordTime(t, "t") + "-" + discordTime(t.Add(sl.Duration()), "t")})
+				}
+			}
+		}
+		sort.Slice(windows, func(a, b int) bool { return windows[a].start.Before(windows[b].start) })
+		var parts []string
+		for _, w := range windows {
+			parts = append(parts, w.text)
+		}
+		parts = append(parts, other...)
+		value := "Not Set"
+		if len(parts) > 0 {
+			value = strings.Join(parts, ", ")
+		}
+		lines = append(lines, m.InGameName+" - "+value)
+	}
+	return lines
+}
+
+// discordTime formats t as a Discord timestamp with the given style (t, f, D, R...).
+func discordTime(t time.Time, style string) string {
+	return "<t:" + strconv.FormatInt(t.Unix(), 10) + ":" + style + ">"
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAvailabilityMenu(t *testing.T) {
+	b, fake := newTestBot(t)
+	handleSlashCommand(b, b.Session, slashCommand("g", "u1", "register", stringOption("in-game-name", "Alpha")))
+	pick := func(customID string, values ...string) string {
+		handleComponentInteraction(b, b.Session, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			ID: "interaction", Token: "token", Type: discordgo.InteractionMessageComponent, GuildID: "g",
+			Member: &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+			Data:   discordgo.MessageComponentInteractionData{CustomID: customID, Values: values},
+		}})
+		return fake.lastResponse(t).Data.Content
+	}
+
+	if got := pick(availabilitySelectID+":weekend", "18:00-20:00 GMT", "16:00-18:00 GMT"); got != "Your availability on weekend has been set to 18:00-20:00 GMT, 16:00-18:00 GMT." {
+		t.Fatalf("weekend pick = %q", got)
+	}
+	// Not Available wins over any other slot picked with it
+	if got := pick(availabilitySelectID+":mon", "20:00-22:00 GMT", notAvailableSlot); got != "Your availability on Monday has been set to Not Available." {
+		t.Fatalf("Monday pick = %q", got)
+	}
+	// A menu from before the day suffix covers the whole week
+	if got := pick(availabilitySelectID); got != "Your availability every day has been cleared." {
+		t.Fatalf("legacy menu pick = %q", got)
+	}
+	pick(availabilitySelectID+":sun", "16:00-18:00 GMT")
+	members, err := b.DB.GetAllMembers(t.Context(), "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := members[0].Availability.String(); got != "Sun: 16:00-18:00 GMT" {
+		t.Fatalf("availability = %q", got)
+	}
+
+	// The menu for a single day pre-selects that day's slots
+	handleSlashCommand(b, b.Session, slashCommand("g", "u1", "availability", stringOption("day", "sun")))
+	sent := fake.sent("/callback")
+	var resp struct {
+		Data struct {
+			Components []struct {
+				Components []struct {
+					CustomID string                       `json:"custom_id"`
+					Options  []discordgo.SelectMenuOption `json:"options"`
+				} `json:"components"`
+			} `json:"components"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(sent[len(sent)-1].Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	menu := resp.Data.Components[0].Components[0]
+	if menu.CustomID != availabilitySelectID+":sun" {
+		t.Fatalf("menu id = %q", menu.CustomID)
+	}
+	for _, o := range menu.Options {
+		if o.Default != (o.Value == "16:00-18:00 GMT") {
+			t.Errorf("option %q default = %v", o.Value, o.Default)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	defaultBackupInterval   = 24 * time.Hour
+	defaultBackupKeepDaily  = 7
+	defaultBackupKeepWeekly = 4
+	backupTimeout           = 2 * time.Minute
+)
+
+// backupInterval parses Config.BackupInterval, falling back to the default on empty or invalid input.
+func (c *Config) backupInterval() time.Duration {
+	if c.BackupInterval == "" {
+		return defaultBackupInterval
+	}
+	d, err := time.ParseDuration(c.BackupInterval)
+	if err != nil || d <= 0 {
+		log.Printf("WARN: invalid BackupInterval %q; using %s", c.BackupInterval, defaultBackupInterval)
+		return defaultBackupInterval
+	}
+	return d
+}
+
+func (c *Config) backupRetention() (daily, weekly int) {
+	daily, weekly = c.BackupKeepDaily, c.BackupKeepWeekly
+	if daily <= 0 {
+		daily = defaultBackupKeepDaily
+	}
+	if weekly <= 0 {
+		weekly = defaultBackupKeepWeekly
+	}
+	return daily, weekly
+}
+
+// runBackup takes a verified backup into Config.BackupDir and prunes old copies.
+func (b *Bot) runBackup(ctx context.Context) (string, error) {
+	bk, ok := b.DB.(storage.Backuper)
+	if !ok {
+		return "", storage.ErrBackupUnsupported
+	}
+	dir := b.Config.BackupDir
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, storage.BackupFileName(time.Now()))
+	if err := bk.BackupTo(ctx, dest); err != nil {
+		return "", err
+	}
+	daily, weekly := b.Config.backupRetention()
+	removed, err := storage.PruneBackups(dir, daily, weekly)
+	if err != nil {
+		log.Printf("WARN: prune backups: %v", err)
+	}
+	for _, p := range removed {
+		log.Printf("Backup pruned: %s", p)
+	}
+	return dest, nil
+}
+
+// backgroundBackups takes a backup on every interval while the bot runs.
+func (b *Bot) backgroundBackups() {
+	ticker := time.NewTicker(b.Config.backupInterval())
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), backupTimeout)
+		if path, err := b.runBackup(ctx); err != nil {
+			log.Printf("ERROR: backup: %v", err)
+		} else {
+			log.Printf("Backup created: %s", path)
+		}
+		cancel()
+	}
+}
@@ -26,6 +26,14 @@ type Config struct {
 	// New multi-guild settings
 	Guilds []GuildConfig `json:"Guilds,omitempty"`
 	DBPath string        `json:"DBPath,omitempty"`
+	// Storage backend: "sqlite" (default, uses DBPath) or "postgres" (uses DSN)
+	DBDriver string `json:"DBDriver,omitempty"`
+	DSN      string `json:"DSN,omitempty"`
+	// Built-in SQLite backups; disabled when BackupDir is empty
+	BackupDir        string `json:"BackupDir,omitempty"`
+	BackupInterval   string `json:"BackupInterval,omitempty"` // Go duration, default 24h
+	BackupKeepDaily  int    `json:"BackupKeepDaily,omitempty"`
+	BackupKeepWeekly int    `json:"BackupKeepWeekly,omitempty"`
 	// TLS options for environments with intercepting proxies or custom CAs
 	TLSInsecureSkipVerify bool   `json:"TLSInsecureSkipVerify,omitempty"`
 	CustomRootCAPath      string `json:"CustomRootCAPath,omitempty"`
@@ -36,6 +44,10 @@ type GuildConfig struct {
 	GuildID       string   `json:"GuildID"`
 	LeaderRoleIDs []string `json:"LeaderRoleIDs,omitempty"`
 	LeaderUserIDs []string `json:"LeaderUserIDs,omitempty"`
+	// Allowed range per resource ("orders", "lumber"); unset resources use storage.DefaultBounds
+	ResourceBounds map[string]storage.Bounds `json:"ResourceBounds,omitempty"`
+	// Resources to track instead of War Orders and Lumber, until edited with /resourcetype
+	ResourceTypes []storage.ResourceType `json:"ResourceTypes,omitempty"`
 }
 
 // GuildList returns configured guilds, falling back to deprecated fields.
@@ -74,11 +86,50 @@ func (c *Config) GuildConfigFor(guildID string) *GuildConfig {
 	return nil
 }
 
-// Bot holds session, config, and database handle.
+// BoundsFor returns the allowed range for a resource in a guild. A configured Max of 0 keeps
+// the default ceiling, so {"Min": -10} only lowers the floor.
+func (c *Config) BoundsFor(guildID, resource string) storage.Bounds {
+	bounds := storage.DefaultBounds
+	if g := c.GuildConfigFor(guildID); g != nil {
+		if b, ok := g.ResourceBounds[resource]; ok {
+			bounds.Min = b.Min
+			if b.Max != 0 {
+				bounds.Max = b.Max
+			}
+		}
+	}
+	return bounds
+}
+
+// ResourceTypesFor returns the resource types a guild tracks until a leader edits them with
+// /resourcetype: the guild's ResourceTypes, else War Orders and Lumber within ResourceBounds.
+// As with ResourceBounds, a Max of 0 keeps the default ceiling.
+func (c *Config) ResourceTypesFor(guildID string) []storage.ResourceType {
+	if g := c.GuildConfigFor(guildID); g != nil && len(g.ResourceTypes) > 0 {
+		types := make([]storage.ResourceType, len(g.ResourceTypes))
+		for n, t := range g.ResourceTypes {
+			t.GuildID, t.Position = guildID, n
+			if t.Max == 0 {
+				t.Max = storage.DefaultBounds.Max
+			}
+			types[n] = t
+		}
+		return types
+	}
+	types := storage.DefaultResourceTypes(guildID)
+	for n := range types {
+		types[n].Bounds = c.BoundsFor(guildID, types[n].Key)
+	}
+	return types
+}
+
+// Bot holds session, config, and storage handle.
 type Bot struct {
 	Session *discordgo.Session
 	Config  *Config
-	DB      *storage.DB
+	DB      storage.Store
+
+	imports importQueue
 }
 
 // LoadConfig reads a JSON config file into Config struct.
@@ -95,7 +146,7 @@ func LoadConfig(path string) (*Config, error) {
 }
 
 // New creates a new Bot and wires handlers (but does not open the session).
-func New(cfg *Config, db *storage.DB) (*Bot, error) {
+func New(cfg *Config, db storage.Store) (*Bot, error) {
 	s, err := discordgo.New("Bot " + cfg.BotToken)
 	if err != nil {
 		return nil, err
@@ -132,11 +183,16 @@ func (b *Bot) Open(ctx context.Context) error {
 	if err := b.Session.Open(); err != nil {
 		return err
 	}
+	b.seedPermissions()
 	if err := b.registerSlashCommands(); err != nil {
 		return fmt.Errorf("register commands: %w", err)
 	}
 	// Start background monthly role resync
 	go b.backgroundMonthlyRoleResync()
+	if b.Config.BackupDir != "" {
+		go b.backgroundBackups()
+	}
+	go b.backgroundReminders()
 	return nil
 }
 
@@ -178,10 +234,10 @@ func (b *Bot) resyncAllGuildRoles() {
 			for _, m := range members {
 				// Insert placeholder name if needed
 				ctx, cancel := storage.WithTimeout(context.Background())
-				_ = b.DB.InsertMemberIfMissing(ctx, m.User.ID, m.User.Username)
+				_ = b.DB.InsertMemberIfMissing(ctx, g.GuildID, m.User.ID, m.User.Username)
 				// snapshot role
 				if role := firstConfiguredRole(b, g.GuildID, m); role != "" {
-					_ = b.DB.UpdateMemberRole(ctx, m.User.ID, role)
+					_ = b.DB.UpdateMemberRole(ctx, g.GuildID, m.User.ID, role)
 				}
 				cancel()
 			}
@@ -0,0 +1,113 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// fakeDiscord stands in for the Discord REST API: it records every request and answers each
+// with an empty object, or with 400 Bad Request when fail reports true for it.
+type fakeDiscord struct {
+	mu       sync.Mutex
+	requests []fakeRequest
+	fail     func(fakeRequest) bool
+}
+
+type fakeRequest struct {
+	Method, Path string
+	Body         []byte
+}
+
+func (f *fakeDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	var body []byte
+	if r.Body != nil {
+		body, _ = io.ReadAll(r.Body)
+	}
+	req := fakeRequest{Method: r.Method, Path: strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion), Body: body}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	fail := f.fail
+	f.mu.Unlock()
+	status := http.StatusOK
+	if fail != nil && fail(req) {
+		status = http.StatusBadRequest
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"id":"1"}`))),
+		Request:    r,
+	}, nil
+}
+
+// sent returns the requests made to paths ending in suffix, in order.
+func (f *fakeDiscord) sent(suffix string) []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []fakeRequest
+	for _, r := range f.requests {
+		if strings.HasSuffix(r.Path, suffix) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// lastResponse decodes the latest interaction response.
+func (f *fakeDiscord) lastResponse(t *testing.T) discordgo.InteractionResponse {
+	t.Helper()
+	sent := f.sent("/callback")
+	if len(sent) == 0 {
+		t.Fatal("no interaction response sent")
+	}
+	var resp discordgo.InteractionResponse
+	if err := json.Unmarshal(sent[len(sent)-1].Body, &resp); err != nil {
+		t.Fatalf("decode interaction response: %v", err)
+	}
+	return resp
+}
+
+// newTestBot returns a bot backed by a MemoryStore whose Discord calls go to a fakeDiscord.
+func newTestBot(t *testing.T) (*Bot, *fakeDiscord) {
+	t.Helper()
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeDiscord{}
+	s.Client = &http.Client{Transport: fake}
+	return &Bot{Session: s, Config: &Config{}, DB: storage.NewMemoryStore()}, fake
+}
+
+// slashCommand builds the interaction Discord sends when userID runs a command in guildID.
+func slashCommand(guildID, userID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		Token:   "token",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: guildID,
+		Member:  &discordgo.Member{User: &discordgo.User{ID: userID}},
+		Data:    discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}}
+}
+
+func subcommand(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// newCharacterChoice is the /register character value that adds in-game-name as a new alt.
+const newCharacterChoice = "+new"
+
+// findMember returns the caller's roster row, or nil if they are not registered in the guild.
+func findMember(ctx context.Context, b *Bot, guildID, discordID string) (*storage.Member, error) {
+	members, err := b.DB.GetAllMembers(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	for n := range members {
+		if members[n].DiscordID == discordID {
+			return &members[n], nil
+		}
+	}
+	return nil, nil
+}
+
+// resolveCharacter maps a character option value to the alt name storage expects: "" for the
+// member's main character (including when input is empty), otherwise the stored alt name.
+func resolveCharacter(ctx context.Context, b *Bot, guildID, discordID, input string) (string, error) {
+	if input == "" {
+		return "", nil
+	}
+	mem, err := findMember(ctx, b, guildID, discordID)
+	if err != nil {
+		return "", err
+	}
+	if mem == nil {
+		return "", storage.ErrMemberNotRegistered
+	}
+	if strings.EqualFold(mem.InGameName, input) {
+		return "", nil
+	}
+	alts, err := b.DB.GetCharacters(ctx, guildID, discordID)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range alts {
+		if strings.EqualFold(c.Name, input) {
+			return c.Name, nil
+		}
+	}
+	return "", storage.ErrCharacterNotFound
+}
+
+// characterChoices suggests the caller's characters for the character option of /register, /order, /lumber and /resource.
+func characterChoices(ctx context.Context, b *Bot, i *discordgo.InteractionCreate, command, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if command == "register" {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "➕ Add in-game-name as a new alt", Value: newCharacterChoice})
+	}
+	mem, err := findMember(ctx, b, i.GuildID, i.Member.User.ID)
+	if err != nil || mem == nil {
+		return choices
+	}
+	if strings.Contains(strings.ToLower(mem.InGameName), typed) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: mem.InGameName + " (main)", Value: mem.InGameName})
+	}
+	alts, _ := b.DB.GetCharacters(ctx, i.GuildID, i.Member.User.ID)
+	for _, c := range alts {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(c.Name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: c.Name, Value: c.Name})
+		}
+	}
+	return choices
+}
@@ -0,0 +1,64 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestCharacterCommands(t *testing.T) {
+	b, fake := newTestBot(t)
+	run := func(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		handleSlashCommand(b, b.Session, slashCommand("g", "u1", name, options...))
+		return fake.lastResponse(t).Data.Content
+	}
+
+	if got := run("register", stringOption("in-game-name", "Scout"), stringOption("character", newCharacterChoice)); got != "Register your main character with /register first." {
+		t.Fatalf("adding an alt before /register = %q", got)
+	}
+	run("register", stringOption("in-game-name", "Main"))
+	if got := run("register", stringOption("in-game-name", "Scout"), stringOption("character", newCharacterChoice)); got != "Scout has been added as one of your characters." {
+		t.Fatalf("adding an alt = %q", got)
+	}
+	if got := run("register", stringOption("in-game-name", "main"), stringOption("character", newCharacterChoice)); got != "You already have a character named main." {
+		t.Fatalf("adding an alt named like the main = %q", got)
+	}
+	if got := run("order", subcommand("set", intOption("amount", 12), stringOption("character", "scout"))); got != "War Orders for Scout set to 12." {
+		t.Fatalf("/order for the alt = %q", got)
+	}
+	if got := run("order", subcommand("add", intOption("amount", 3), stringOption("character", "Main"))); got != "Your War Orders changed by +3 to 3." {
+		t.Fatalf("/order naming the main = %q", got)
+	}
+	if got := run("lumber", subcommand("set", intOption("amount", 1), stringOption("character", "Ghost"))); got != `You have no character named Ghost. Add it with /register character:"Add as a new alt".` {
+		t.Fatalf("/lumber for an unknown alt = %q", got)
+	}
+	if got := run("register", stringOption("in-game-name", "Ranger"), stringOption("character", "Scout")); got != "Your character Scout has been renamed to Ranger." {
+		t.Fatalf("renaming the alt = %q", got)
+	}
+
+	chars, err := b.DB.GetCharacters(t.Context(), "g", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chars) != 1 || chars[0].Name != "Ranger" || chars[0].Resources[storage.ResourceOrders] != 12 {
+		t.Fatalf("characters = %+v", chars)
+	}
+	if m, _ := findMember(t.Context(), b, "g", "u1"); m == nil || m.InGameName != "Main" || m.Resources[storage.ResourceOrders] != 3 {
+		t.Fatalf("main = %+v", m)
+	}
+
+	// Autocomplete offers the new-alt choice on /register, then the caller's matching characters
+	i := slashCommand("g", "u1", "register", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "character", Type: discordgo.ApplicationCommandOptionString, Value: "a", Focused: true,
+	})
+	i.Type = discordgo.InteractionApplicationCommandAutocomplete
+	handleAutocomplete(b, b.Session, i)
+	var names []string
+	for _, c := range fake.lastResponse(t).Data.Choices {
+		names = append(names, c.Name)
+	}
+	if len(names) != 3 || names[1] != "Main (main)" || names[2] != "Ranger" {
+		t.Fatalf("autocomplete choices = %q", names)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
 )
 
 // RegisterHandlers wires the interaction and component handlers.
@@ -17,6 +19,8 @@ func RegisterHandlers(b *Bot) {
 			handleSlashCommand(b, s, i)
 		case discordgo.InteractionMessageComponent:
 			handleComponentInteraction(b, s, i)
+		case discordgo.InteractionApplicationCommandAutocomplete:
+			handleAutocomplete(b, s, i)
 		}
 	})
 }
@@ -26,35 +30,61 @@ func (b *Bot) registerSlashCommands() error {
 	commands := []*discordgo.ApplicationCommand{
 		{
 			Name:        "register",
-			Description: "Register or update your in-game name",
-			Options: []*discordgo.ApplicationCommandOption{{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "in-game-name",
-				Description: "Your in-game name",
-				Required:    true,
-			}},
+			Description: "Register or update your in-game name, or add an alt",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "in-game-name",
+					Description: "Your in-game name",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionString,
+					Name:         "character",
+					Description:  "Character to rename, or \"Add as a new alt\" (default: your main)",
+					Required:     false,
+					Autocomplete: true,
+				},
+			},
 		},
 		{
 			Name:        "order",
-			Description: "Set your current War Orders count",
-			Options: []*discordgo.ApplicationCommandOption{{
-				Type:        discordgo.ApplicationCommandOptionInteger,
-				Name:        "amount",
-				Description: "Number of War Orders",
-				Required:    true,
-			}},
+			Description: "Set, add to or subtract from your War Orders",
+			Options:     resourceSubcommands("War Orders", false),
 		},
 		{
 			Name:        "lumber",
-			Description: "Set your current Lumber count",
-			Options: []*discordgo.ApplicationCommandOption{{
-				Type:        discordgo.ApplicationCommandOptionInteger,
-				Name:        "amount",
-				Description: "Amount of Lumber",
-				Required:    true,
-			}},
-		},
-		{Name: "availability", Description: "Set your availability time slot"},
+			Description: "Set, add to or subtract from your Lumber",
+			Options:     resourceSubcommands("Lumber", false),
+		},
+		{
+			Name:        "resource",
+			Description: "Set, add to or subtract from any resource this server tracks",
+			Options:     resourceSubcommands("the resource", true),
+		},
+		{
+			Name:        "availability",
+			Description: "Set the time slots you can play, per weekday",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "day", Description: "Day to set (default: every day)", Required: false, Choices: dayChoices(true)},
+			},
+		},
+		{
+			Name:        "timezone",
+			Description: "Show or set your time zone, used to show availability slots in local time",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionString, Name: "zone", Description: "IANA time zone, e.g. America/New_York", Required: false, Autocomplete: true},
+			},
+		},
+		{
+			Name:        "profile",
+			Description: "Show a member's stored in-game name, resources, availability and role",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Discord user (default: you)", Required: false},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "member", Description: "Search by in-game name or Discord username", Required: false, Autocomplete: true},
+			},
+		},
+		{Name: profileContextMenu, Type: discordgo.UserApplicationCommand},
 		{Name: "help", Description: "Show bot commands and usage"},
 		{Name: "tutorial", Description: "Show a short getting-started tutorial"},
 		{
@@ -73,9 +103,18 @@ func (b *Bot) registerSlashCommands() error {
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "remove",
-					Description: "Remove a member",
+					Description: "Remove a member (pick a Discord user or search by name)",
 					Options: []*discordgo.ApplicationCommandOption{
-						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Discord user", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "member", Description: "In-game name or Discord username", Required: false, Autocomplete: true},
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Discord user", Required: false},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "import",
+					Description: "Bulk add or update members from a CSV or JSON file (dry run first)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionAttachment, Name: "file", Description: "CSV or JSON with discord_id or username, in_game_name, optional resource keys, availability", Required: true},
 					},
 				},
 			},
@@ -84,8 +123,286 @@ func (b *Bot) registerSlashCommands() error {
 			Name:        "list",
 			Description: "List guild data",
 			Options: []*discordgo.ApplicationCommandOption{
-				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "availability", Description: "Show availability list"},
-				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "current", Description: "Show current orders and lumber"},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "availability",
+					Description: "Show availability list",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "day", Description: "Only show this day (default: whole week)", Required: false, Choices: dayChoices(false)},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "coverage",
+					Description: "Show headcount and War Orders per availability slot",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "day", Description: "Only show this day, with names (default: whole week)", Required: false, Choices: dayChoices(false)},
+						{Type: discordgo.ApplicationCommandOptionBoolean, Name: "heatmap", Description: "Attach a PNG heatmap (default true)", Required: false},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "current", Description: "Show current resources per character"},
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "totals", Description: "Show resource totals per member across all characters"},
+			},
+		},
+		{
+			Name:        "war",
+			Description: "Schedule wars and manage their lineups",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "create",
+					Description: "Post a war with Join/Maybe/Decline buttons (officers)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "Start in your time zone: 2026-10-24 20:00, sat 20:00, or a date/weekday with slot", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "slot", Description: "Availability slot the war falls in", Required: false, Autocomplete: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "opponent", Description: "Opposing guild", Required: false, Autocomplete: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "lineup",
+					Description: "Show who joined a war and their War Orders",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "war", Description: "War (default: the next one)", Required: false, Autocomplete: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "lock",
+					Description: "Freeze a war's signups (officers)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "war", Description: "War (default: the next one)", Required: false, Autocomplete: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "attendance",
+					Description: "Record who was present, absent or excused (officers)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "war", Description: "War (default: the next one)", Required: false, Autocomplete: true},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "result",
+					Description: "Record a war's outcome and mark it complete (officers)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "war", Description: "War", Required: true, Autocomplete: true},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "result",
+							Description: "Outcome",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Win", Value: storage.ResultWin},
+								{Name: "Loss", Value: storage.ResultLoss},
+								{Name: "Draw", Value: storage.ResultDraw},
+							},
+						},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "score", Description: "Final score, e.g. 3-1", Required: false, MaxLength: maxScoreLength},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "notes", Description: "Anything worth remembering", Required: false, MaxLength: maxWarNotesLength},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "Win/loss record overall, per opponent or per season",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "opponent", Description: "Only wars against this guild", Required: false, Autocomplete: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "season", Description: "Only wars in this season", Required: false, Autocomplete: true},
+					},
+				},
+			},
+		},
+		{
+			Name:        "season",
+			Description: "Group wars into seasons and archive resources when they end",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Start a new season (leaders)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Season name, e.g. Autumn 2026", Required: true, MaxLength: maxSeasonNameLength},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "end",
+					Description: "End the active season and archive every member's resources (leaders)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "Compare seasons: war record and archived resource totals",
+				},
+			},
+		},
+		{
+			Name:        "stats",
+			Description: "Guild statistics",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "attendance",
+					Description: "Participation rate, streaks and no-shows per member",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "days", Description: "How many days back (default 30)", Required: false},
+					},
+				},
+			},
+		},
+		{
+			Name:        "recommend",
+			Description: "Suggest when to schedule guild activities",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "wartime",
+					Description: "Rank slots by available members weighted by their War Orders",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "top", Description: "How many slots to list (default 5, max 10)", Required: false},
+					},
+				},
+			},
+		},
+		{
+			Name:        "export",
+			Description: "Download the roster as a spreadsheet file",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "format",
+					Description: "File format (default csv)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "CSV", Value: storage.FormatCSV},
+						{Name: "JSON", Value: storage.FormatJSON},
+						{Name: "Excel (xlsx)", Value: storage.FormatXLSX},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "include",
+					Description: "Columns to include (default all)",
+					Required:    false,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Resources", Value: storage.IncludeResources},
+						{Name: "Availability", Value: storage.IncludeAvailability},
+						{Name: "All", Value: storage.IncludeAll},
+					},
+				},
+			},
+		},
+		{
+			Name:        "history",
+			Description: "Show resource changes over time",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Only show this member", Required: false},
+				{Type: discordgo.ApplicationCommandOptionString, Name: "resource", Description: "Only show this resource", Required: false, Autocomplete: true},
+				{Type: discordgo.ApplicationCommandOptionInteger, Name: "days", Description: "How many days back (default 14)", Required: false},
+			},
+		},
+		{
+			Name:        "resourcetype",
+			Description: "Configure which resources this server tracks",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "add",
+					Description: "Track a new resource, or update an existing one",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "key", Description: "Short id used in exports and imports, e.g. stone", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "name", Description: "Display name, e.g. Stone", Required: true},
+						{Type: discordgo.ApplicationCommandOptionString, Name: "emoji", Description: "Emoji shown next to the name", Required: false},
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "min", Description: "Lowest allowed amount (default 0)", Required: false, MinValue: &resourceLimitMin, MaxValue: resourceLimitMax},
+						{Type: discordgo.ApplicationCommandOptionInteger, Name: "max", Description: "Highest allowed amount (default 1,000,000,000)", Required: false, MinValue: &resourceLimitMin, MaxValue: resourceLimitMax},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "remove",
+					Description: "Stop tracking a resource and delete its stored amounts",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "type", Description: "Resource to remove", Required: true, Autocomplete: true},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "Show tracked resources and their allowed ranges"},
+			},
+		},
+		{
+			Name:        "settings",
+			Description: "Configure this server's war tracking",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "availability",
+					Description: "Time slots members can pick in /availability",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "add",
+							Description: "Offer a new time slot",
+							Options: []*discordgo.ApplicationCommandOption{
+								{Type: discordgo.ApplicationCommandOptionString, Name: "start", Description: "Start time in UTC, HH:MM (e.g. 18:00)", Required: true},
+								{Type: discordgo.ApplicationCommandOptionString, Name: "end", Description: "End time in UTC, HH:MM; before start means the next day", Required: true},
+								{Type: discordgo.ApplicationCommandOptionString, Name: "label", Description: "Name shown in menus (default: the times, e.g. 18:00-20:00 GMT)", Required: false},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "remove",
+							Description: "Stop offering a slot and remove it from member schedules",
+							Options: []*discordgo.ApplicationCommandOption{
+								{Type: discordgo.ApplicationCommandOptionString, Name: "slot", Description: "Slot to remove", Required: true, Autocomplete: true},
+							},
+						},
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "Show the slots members can pick"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "wars",
+					Description: "What happens when a war is completed",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "orders",
+							Description: "War Orders deducted from each member present when a war is completed",
+							Options: []*discordgo.ApplicationCommandOption{
+								{Type: discordgo.ApplicationCommandOptionInteger, Name: "amount", Description: "Orders per member; 0 turns deduction off (omit to show the current rule)", Required: false},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+					Name:        "reminders",
+					Description: "Remind members whose resources or availability go stale",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionSubCommand,
+							Name:        "set",
+							Description: "Turn reminders on or change when they go out",
+							Options: []*discordgo.ApplicationCommandOption{
+								{Type: discordgo.ApplicationCommandOptionInteger, Name: "stale-days", Description: "Remind members whose data hasn't changed in this many days (1-90)", Required: true},
+								{Type: discordgo.ApplicationCommandOptionString, Name: "time", Description: "When to send, UTC HH:MM (e.g. 18:00)", Required: true},
+								{Type: discordgo.ApplicationCommandOptionString, Name: "days", Description: "Which days to send on (default every day)", Required: false, Choices: dayChoices(true)},
+								{Type: discordgo.ApplicationCommandOptionChannel, Name: "channel", Description: "Ping members in this channel instead of sending DMs", Required: false, ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText}},
+							},
+						},
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "off", Description: "Stop sending reminders"},
+						{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "status", Description: "Show the schedule, who opted out and who the last run reminded"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "reminders",
+			Description: "Reminders to update your resources and availability",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "on", Description: "Get reminders when your data goes stale"},
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "off", Description: "Stop getting reminders"},
 			},
 		},
 		{
@@ -95,6 +412,49 @@ func (b *Bot) registerSlashCommands() error {
 				{Type: discordgo.ApplicationCommandOptionString, Name: "role-id", Description: "Only sync members who have this role", Required: false},
 			},
 		},
+		{
+			Name:        "backup",
+			Description: "Database backups",
+			Options: []*discordgo.ApplicationCommandOption{
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "now", Description: "Take a verified backup immediately"},
+			},
+		},
+		{
+			Name:        "perm",
+			Description: "Manage member, officer and leader permission tiers",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "grant",
+					Description: "Grant a tier to a user or role",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionString, Name: "tier", Description: "Tier to grant", Required: true, Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "Member", Value: storage.TierMember.String()},
+							{Name: "Officer", Value: storage.TierOfficer.String()},
+							{Name: "Leader", Value: storage.TierLeader.String()},
+						}},
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Discord user", Required: false},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Discord role", Required: false},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "revoke",
+					Description: "Revoke the tier granted to a user or role",
+					Options: []*discordgo.ApplicationCommandOption{
+						{Type: discordgo.ApplicationCommandOptionUser, Name: "user", Description: "Discord user", Required: false},
+						{Type: discordgo.ApplicationCommandOptionRole, Name: "role", Description: "Discord role", Required: false},
+					},
+				},
+				{Type: discordgo.ApplicationCommandOptionSubCommand, Name: "list", Description: "Show all permission grants"},
+			},
+		},
+	}
+
+	for _, cmd := range commands {
+		if commandTiers[cmd.Name] >= storage.TierOfficer {
+			cmd.DefaultMemberPermissions = &restrictedDefaultPermissions
+		}
 	}
 
 	guilds := b.Config.GuildList()
@@ -136,19 +496,54 @@ func (b *Bot) registerSlashCommands() error {
 	return nil
 }
 
+// resourceSubcommands builds the set|add|subtract subcommands shared by /order, /lumber and
+// /resource. typed adds the autocompleted resource type option /resource needs.
+func resourceSubcommands(label string, typed bool) []*discordgo.ApplicationCommandOption {
+	sub := func(name, desc string) *discordgo.ApplicationCommandOption {
+		var opts []*discordgo.ApplicationCommandOption
+		if typed {
+			opts = append(opts, &discordgo.ApplicationCommandOption{Type: discordgo.ApplicationCommandOptionString, Name: "type", Description: "Resource to update", Required: true, Autocomplete: true})
+		}
+		return &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        name,
+			Description: desc,
+			Options: append(opts,
+				&discordgo.ApplicationCommandOption{Type: discordgo.ApplicationCommandOptionInteger, Name: "amount", Description: "Amount of " + label, Required: true},
+				&discordgo.ApplicationCommandOption{Type: discordgo.ApplicationCommandOptionString, Name: "character", Description: "Which of your characters (default: your main)", Required: false, Autocomplete: true},
+			),
+		}
+	}
+	return []*discordgo.ApplicationCommandOption{
+		sub("set", "Set your current "+label),
+		sub("add", "Add to your "+label),
+		sub("subtract", "Subtract from your "+label),
+	}
+}
+
 // handleSlashCommand routes slash command invocations.
 func handleSlashCommand(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	name := i.ApplicationCommandData().Name
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	switch i.ApplicationCommandData().Name {
+	if !authorizeCommand(ctx, b, s, i, name) {
+		return
+	}
+	switch name {
 	case "register":
 		handleRegister(b, s, i, ctx)
 	case "order":
 		handleOrder(b, s, i, ctx)
 	case "lumber":
 		handleLumber(b, s, i, ctx)
+	case "resource":
+		handleResource(b, s, i, ctx)
 	case "availability":
 		handleAvailability(b, s, i, ctx)
+	case "timezone":
+		handleTimeZone(b, s, i, ctx)
+	case "profile", profileContextMenu:
+		handleProfile(b, s, i, ctx)
 	case "help":
 		handleHelp(b, s, i, ctx)
 	case "tutorial":
@@ -157,31 +552,53 @@ func handleSlashCommand(b *Bot, s *discordgo.Session, i *discordgo.InteractionCr
 		handleRoster(b, s, i, ctx)
 	case "list":
 		handleList(b, s, i, ctx)
+	case "export":
+		handleExport(b, s, i, ctx)
+	case "history":
+		handleHistory(b, s, i, ctx)
 	case "syncroles":
 		handleSyncRoles(b, s, i, ctx)
+	case "perm":
+		handlePerm(b, s, i, ctx)
+	case "resourcetype":
+		handleResourceType(b, s, i, ctx)
+	case "settings":
+		handleSettings(b, s, i, ctx)
+	case "reminders":
+		handleReminders(b, s, i, ctx)
+	case "recommend":
+		handleRecommend(b, s, i, ctx)
+	case "war":
+		handleWar(b, s, i, ctx)
+	case "season":
+		handleSeason(b, s, i, ctx)
+	case "stats":
+		handleStats(b, s, i, ctx)
+	case "backup":
+		handleBackup(b, s, i, ctx)
 	}
 }
 
-// handleComponentInteraction processes select menu submissions for availability.
+// handleComponentInteraction processes select menu submissions for availability and war attendance,
+// import buttons and war signup buttons.
 func handleComponentInteraction(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Member == nil {
+		return
+	}
 	data := i.MessageComponentData()
-	if data.CustomID == availabilitySelectID {
-		sel := "Not Set"
-		if len(data.Values) > 0 {
-			sel = data.Values[0]
-		}
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		err := b.DB.UpdateAvailability(ctx, i.Member.User.ID, sel)
-		var content string
-		if err != nil {
-			content = "Failed to set availability: " + err.Error()
-		} else {
-			content = "Your availability has been set to " + sel
-		}
-		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
-		})
+	if strings.HasPrefix(data.CustomID, importConfirmPrefix) || strings.HasPrefix(data.CustomID, importCancelPrefix) {
+		handleImportButton(b, s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, warSignupPrefix) {
+		handleWarSignup(b, s, i, data.CustomID)
+		return
+	}
+	if strings.HasPrefix(data.CustomID, warAttendancePrefix) {
+		handleWarAttendanceSelect(b, s, i, data)
+		return
+	}
+	if data.CustomID == availabilitySelectID || strings.HasPrefix(data.CustomID, availabilitySelectID+":") {
+		handleAvailabilitySelect(b, s, i, data)
 	}
 }
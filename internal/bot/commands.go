@@ -1,23 +1,22 @@
 package bot
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/divijg19/Wartracker/internal/storage"
 )
 
-const availabilitySelectID = "availability_select_menu"
-
-var availabilityOptions = []string{
-	"16:00-18:00 GMT",
-	"18:00-20:00 GMT",
-	"20:00-22:00 GMT",
-	"22:00-00:00 GMT",
-	"Not Available",
-}
+const (
+	defaultHistoryDays    = 14
+	embedDescriptionLimit = 4096
+)
 
 // find a reasonable role snapshot to store with the member (first matching configured role, else first role)
 func firstConfiguredRole(b *Bot, guildID string, member *discordgo.Member) string {
@@ -58,15 +57,59 @@ func ephemeralOK(s *discordgo.Session, i *discordgo.InteractionCreate, msg strin
 	})
 }
 
-// /register
+// /register in-game-name [character]
 func handleRegister(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
-	name := i.ApplicationCommandData().Options[0].StringValue()
+	var name, character string
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "in-game-name":
+			name = o.StringValue()
+		case "character":
+			character = o.StringValue()
+		}
+	}
 	c, cancel := storage.WithTimeout(ctx)
 	defer cancel()
-	exists, _ := b.DB.EnsureMemberExists(c, i.Member.User.ID)
-	_ = b.DB.UpsertMember(c, i.Member.User.ID, name)
+	if character == newCharacterChoice {
+		switch err := b.DB.AddCharacter(c, i.GuildID, i.Member.User.ID, name); {
+		case errors.Is(err, storage.ErrMemberNotRegistered):
+			ephemeralErrorRespond(s, i, "Register your main character with /register first.")
+		case errors.Is(err, storage.ErrCharacterExists):
+			ephemeralErrorRespond(s, i, "You already have a character named "+name+".")
+		case err != nil:
+			ephemeralErrorRespond(s, i, "Failed to add character: "+err.Error())
+		default:
+			ephemeralOK(s, i, name+" has been added as one of your characters.")
+		}
+		return
+	}
+	alt, err := resolveCharacter(c, b, i.GuildID, i.Member.User.ID, character)
+	if err != nil {
+		ephemeralErrorRespond(s, i, characterErrorMessage(err, character))
+		return
+	}
+	if alt != "" {
+		switch err := b.DB.RenameCharacter(c, i.GuildID, i.Member.User.ID, alt, name); {
+		case errors.Is(err, storage.ErrCharacterExists):
+			ephemeralErrorRespond(s, i, "You already have a character named "+name+".")
+		case err != nil:
+			ephemeralErrorRespond(s, i, "Failed to rename character: "+err.Error())
+		default:
+			ephemeralOK(s, i, "Your character "+alt+" has been renamed to "+name+".")
+		}
+		return
+	}
+	alts, _ := b.DB.GetCharacters(c, i.GuildID, i.Member.User.ID)
+	for _, a := range alts {
+		if strings.EqualFold(a.Name, name) {
+			ephemeralErrorRespond(s, i, "You already have a character named "+name+".")
+			return
+		}
+	}
+	exists, _ := b.DB.EnsureMemberExists(c, i.GuildID, i.Member.User.ID)
+	_ = b.DB.UpsertMember(c, i.GuildID, i.Member.User.ID, name)
 	if role := firstConfiguredRole(b, i.GuildID, i.Member); role != "" {
-		_ = b.DB.UpdateMemberRole(c, i.Member.User.ID, role)
+		_ = b.DB.UpdateMemberRole(c, i.GuildID, i.Member.User.ID, role)
 	}
 	if exists {
 		ephemeralOK(s, i, "Your in-game name has been updated to "+name+".")
@@ -75,49 +118,102 @@ func handleRegister(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate
 	}
 }
 
-// /order
-func handleOrder(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
-	amount := int(i.ApplicationCommandData().Options[0].IntValue())
-	c, cancel := storage.WithTimeout(ctx)
-	defer cancel()
-	if err := b.DB.UpdateOrders(c, i.Member.User.ID, amount); err != nil {
-		ephemeralErrorRespond(s, i, "You must /register first.")
-		return
+// characterErrorMessage explains why a character option could not be used.
+func characterErrorMessage(err error, character string) string {
+	switch {
+	case errors.Is(err, storage.ErrMemberNotRegistered):
+		return "You must /register first."
+	case errors.Is(err, storage.ErrCharacterNotFound):
+		return "You have no character named " + character + ". Add it with /register character:\"Add as a new alt\"."
 	}
-	ephemeralOK(s, i, "Your War Orders have been set to "+strconv.Itoa(amount)+".")
+	return "Failed to look up character: " + err.Error()
+}
+
+// /order set|add|subtract amount [character]
+func handleOrder(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	handleResourceUpdate(b, s, i, ctx, storage.ResourceOrders)
 }
 
-// /lumber
+// /lumber set|add|subtract amount [character]
 func handleLumber(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
-	amount := int(i.ApplicationCommandData().Options[0].IntValue())
+	handleResourceUpdate(b, s, i, ctx, storage.ResourceLumber)
+}
+
+// handleResourceUpdate applies a set|add|subtract subcommand to the caller's main character or
+// the picked alt, keeping the result within the bounds of the guild's resource type.
+func handleResourceUpdate(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, resource string) {
+	sub := i.ApplicationCommandData().Options[0]
+	var amount int
+	var character string
+	for _, o := range sub.Options {
+		switch o.Name {
+		case "amount":
+			amount = int(o.IntValue())
+		case "character":
+			character = o.StringValue()
+		}
+	}
 	c, cancel := storage.WithTimeout(ctx)
 	defer cancel()
-	if err := b.DB.UpdateLumber(c, i.Member.User.ID, amount); err != nil {
-		ephemeralErrorRespond(s, i, "You must /register first.")
+	types, err := b.resourceTypes(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load resource types: "+err.Error())
+		return
+	}
+	rt, ok := findResourceType(types, resource)
+	if !ok {
+		ephemeralErrorRespond(s, i, "This server doesn't track "+resource+". Leaders can add it with /resourcetype add.")
+		return
+	}
+	title, bounds := rt.Name, rt.Bounds
+	rangeText := formatNumber(bounds.Min) + "–" + formatNumber(bounds.Max)
+	if sub.Name == "set" && !bounds.Contains(amount) {
+		ephemeralErrorRespond(s, i, title+" must be between "+formatNumber(bounds.Min)+" and "+formatNumber(bounds.Max)+".")
+		return
+	}
+	if sub.Name != "set" && amount <= 0 {
+		ephemeralErrorRespond(s, i, "The amount to "+sub.Name+" must be greater than 0.")
 		return
 	}
-	ephemeralOK(s, i, "Your Lumbers have been set to "+strconv.Itoa(amount)+".")
+	alt, err := resolveCharacter(c, b, i.GuildID, i.Member.User.ID, character)
+	if err != nil {
+		ephemeralErrorRespond(s, i, characterErrorMessage(err, character))
+		return
+	}
+	owner := "Your " + title
+	if alt != "" {
+		owner = title + " for " + alt
+	}
+	if sub.Name == "set" {
+		if err := b.DB.SetResource(c, i.GuildID, i.Member.User.ID, alt, i.Member.User.ID, rt.Key, amount); err != nil {
+			ephemeralErrorRespond(s, i, characterErrorMessage(err, character))
+			return
+		}
+		ephemeralOK(s, i, owner+" set to "+formatNumber(amount)+".")
+		return
+	}
+	delta := amount
+	if sub.Name == "subtract" {
+		delta = -amount
+	}
+	total, err := b.DB.AdjustResource(c, i.GuildID, i.Member.User.ID, alt, i.Member.User.ID, rt.Key, delta, bounds)
+	switch {
+	case errors.Is(err, storage.ErrOutOfBounds):
+		ephemeralErrorRespond(s, i, "Can't "+sub.Name+" "+formatNumber(amount)+": "+owner+" would be "+formatNumber(total+delta)+
+			", outside the allowed range of "+rangeText+" (currently "+formatNumber(total)+").")
+	case err != nil:
+		ephemeralErrorRespond(s, i, characterErrorMessage(err, character))
+	default:
+		ephemeralOK(s, i, owner+" changed by "+signedNumber(delta)+" to "+formatNumber(total)+".")
+	}
 }
 
-// /availability shows select menu
-func handleAvailability(_ *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, _ context.Context) {
-	// Build select menu options
-	var opts []discordgo.SelectMenuOption
-	for _, o := range availabilityOptions {
-		opts = append(opts, discordgo.SelectMenuOption{Label: o, Value: o})
+// signedNumber formats n with an explicit sign.
+func signedNumber(n int) string {
+	if n < 0 {
+		return "-" + formatNumber(-n)
 	}
-	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseChannelMessageWithSource,
-		Data: &discordgo.InteractionResponseData{
-			Content: "Select your availability:",
-			Flags:   discordgo.MessageFlagsEphemeral,
-			Components: []discordgo.MessageComponent{
-				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
-					&discordgo.SelectMenu{CustomID: availabilitySelectID, Placeholder: "Choose time slot", Options: opts},
-				}},
-			},
-		},
-	})
+	return "+" + formatNumber(n)
 }
 
 // /help shows a concise command overview
@@ -127,12 +223,28 @@ func handleHelp(_ *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, _
 		Description: "Slash commands overview",
 		Color:       0x7289DA,
 		Fields: []*discordgo.MessageEmbedField{
-			{Name: "/register in-game-name", Value: "Register or update your in-game name.", Inline: false},
-			{Name: "/order amount", Value: "Set your current War Orders.", Inline: false},
-			{Name: "/lumber amount", Value: "Set your current Lumber.", Inline: false},
-			{Name: "/availability", Value: "Pick your 2-hour GMT window via a dropdown.", Inline: false},
-			{Name: "/roster add/remove", Value: "Manage members in the roster.", Inline: false},
-			{Name: "/list availability|current", Value: "Show availability or current resources.", Inline: false},
+			{Name: "/register in-game-name [character]", Value: "Register or update your in-game name; pick a character to rename an alt or add a new one.", Inline: false},
+			{Name: "/order set|add|subtract amount [character]", Value: "Update your War Orders (main character unless you pick an alt).", Inline: false},
+			{Name: "/lumber set|add|subtract amount [character]", Value: "Update your Lumber (main character unless you pick an alt).", Inline: false},
+			{Name: "/resource set|add|subtract type amount [character]", Value: "Update any resource this server tracks.", Inline: false},
+			{Name: "/availability [day]", Value: "Pick every time slot you can play, per weekday or for the whole week, shown in your time zone.", Inline: false},
+			{Name: "/timezone [zone]", Value: "Show or set your time zone (IANA name, e.g. Europe/Berlin).", Inline: false},
+			{Name: "/profile [user|member]", Value: "Show what the bot has stored for you or another member (also: right-click a member > Apps > War Profile).", Inline: false},
+			{Name: "/roster add/remove/import", Value: "Manage members in the roster, or bulk import a CSV/JSON file (officers).", Inline: false},
+			{Name: "/list availability [day]|coverage [day]|current|totals", Value: "Show availability, headcount and War Orders per slot with a heatmap, resources per character, or per-member totals (officers).", Inline: false},
+			{Name: "/war create|lineup|lock|attendance|result|history", Value: "Post a war members sign up for with Join/Maybe/Decline buttons, see who joined with their War Orders, lock the lineup, record who showed up and the outcome, and review win/loss records per opponent or season (create, lock, attendance, result: officers).", Inline: false},
+			{Name: "/season start name|end|list", Value: "Group wars into named seasons; ending one archives every member's resources so seasons can be compared (start, end: leaders; list: officers).", Inline: false},
+			{Name: "/stats attendance [days]", Value: "Participation rate, streaks and no-shows per member (officers).", Inline: false},
+			{Name: "/recommend wartime [top]", Value: "Rank slots by available members weighted by their War Orders (officers).", Inline: false},
+			{Name: "/export [format] [include]", Value: "Download the roster as CSV, JSON or Excel (officers).", Inline: false},
+			{Name: "/history [user] [resource] [days]", Value: "Show how resources changed over time (officers).", Inline: false},
+			{Name: "/perm grant|revoke|list", Value: "Manage member, officer and leader tiers (leaders).", Inline: false},
+			{Name: "/resourcetype add|remove|list", Value: "Choose which resources this server tracks (leaders).", Inline: false},
+			{Name: "/settings availability add|remove|list", Value: "Choose the time slots members can pick in /availability (leaders).", Inline: false},
+			{Name: "/settings wars orders [amount]", Value: "Deduct War Orders from each member present when a war is completed; 0 turns it off (leaders).", Inline: false},
+			{Name: "/settings reminders set|off|status", Value: "Remind members by DM or in a channel when their resources or availability go unchanged for a number of days, and see who was reminded (leaders).", Inline: false},
+			{Name: "/reminders on|off", Value: "Opt in to or out of reminders to update your data.", Inline: false},
+			{Name: "/backup now", Value: "Take a verified database backup (leaders).", Inline: false},
 			{Name: "/tutorial", Value: "Quick start walkthrough.", Inline: false},
 		},
 		Footer: &discordgo.MessageEmbedFooter{Text: "All user commands reply ephemerally."},
@@ -150,8 +262,8 @@ func handleTutorial(_ *Bot, s *discordgo.Session, i *discordgo.InteractionCreate
 		Color: 0x00CC99,
 		Description: strings.Join([]string{
 			"1) Use /register to set your in-game name.",
-			"2) Use /order and /lumber to set your current resources.",
-			"3) Use /availability to choose your usual 2-hour GMT time slot.",
+			"2) Use /resource (or /order and /lumber) to set, add or subtract your resources.",
+			"3) Use /timezone to set your time zone, then /availability to choose the slots you can play (add a day for a different schedule on that day).",
 			"4) Use /roster to manage members and /list to share lists.",
 		}, "\n"),
 	}
@@ -161,7 +273,7 @@ func handleTutorial(_ *Bot, s *discordgo.Session, i *discordgo.InteractionCreate
 	})
 }
 
-// /roster add|remove (leader only)
+// /roster add|remove|import (officer or above)
 func handleRoster(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
 	data := i.ApplicationCommandData()
 	sub := data.Options[0]
@@ -171,7 +283,7 @@ func handleRoster(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate,
 		name := sub.Options[1].StringValue()
 		c, cancel := storage.WithTimeout(ctx)
 		defer cancel()
-		_ = b.DB.UpsertMember(c, user.ID, name)
+		_ = b.DB.UpsertMember(c, i.GuildID, user.ID, name)
 		// Try cache then API for member to snapshot a role
 		var mem *discordgo.Member
 		if m, err := s.State.Member(i.GuildID, user.ID); err == nil {
@@ -181,47 +293,154 @@ func handleRoster(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate,
 		}
 		if mem != nil {
 			if role := firstConfiguredRole(b, i.GuildID, mem); role != "" {
-				_ = b.DB.UpdateMemberRole(c, user.ID, role)
+				_ = b.DB.UpdateMemberRole(c, i.GuildID, user.ID, role)
 			}
 		}
 		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{Content: user.Mention() + " has been added to the roster as " + name + "."},
 		})
+	case "import":
+		handleRosterImport(b, s, i, ctx, sub)
 	case "remove":
-		user := sub.Options[0].UserValue(s)
 		c, cancel := storage.WithTimeout(ctx)
 		defer cancel()
-		_ = b.DB.DeleteMember(c, user.ID)
+		var userID, label string
+		for _, o := range sub.Options {
+			switch o.Name {
+			case "user":
+				userID = o.UserValue(s).ID
+			case "member":
+				m, err := resolveMember(c, b, i.GuildID, o.StringValue())
+				if err != nil {
+					ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+					return
+				}
+				if m == nil {
+					ephemeralErrorRespond(s, i, "No roster member matches "+o.StringValue()+". Pick one of the suggestions.")
+					return
+				}
+				userID, label = m.DiscordID, " ("+m.InGameName+")"
+			}
+		}
+		if userID == "" {
+			ephemeralErrorRespond(s, i, "Pick a member or a Discord user to remove.")
+			return
+		}
+		_ = b.DB.DeleteMember(c, i.GuildID, userID)
 		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{Content: user.Mention() + " has been removed from the roster."},
+			Data: &discordgo.InteractionResponseData{Content: "<@" + userID + ">" + label + " has been removed from the roster."},
 		})
 	}
 }
 
-// /list availability|current (leader only)
+// /list availability|current|totals (officer or above)
 func handleList(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
 	data := i.ApplicationCommandData()
 	sub := data.Options[0]
 	c, cancel := storage.WithTimeout(ctx)
 	defer cancel()
-	members, _ := b.DB.GetAllMembers(c)
+	members, _ := b.DB.GetAllMembers(c, i.GuildID)
+	alts := make(map[string][]storage.Character)
+	var types []storage.ResourceType
+	if sub.Name != "availability" {
+		types, _ = b.resourceTypes(c, i.GuildID)
+		chars, _ := b.DB.GetCharacters(c, i.GuildID, "")
+		for _, ch := range chars {
+			alts[ch.DiscordID] = append(alts[ch.DiscordID], ch)
+		}
+	}
 	switch sub.Name {
 	case "availability":
-		lines := storage.FormatMembers(members, func(m storage.Member) string { return m.InGameName + " - " + m.Availability })
-		embed := &discordgo.MessageEmbed{Title: "Guild Availability", Description: lines, Color: 0x00AAFF}
+		var day string
+		for _, o := range sub.Options {
+			if o.Name == "day" {
+				day = o.StringValue()
+			}
+		}
+		slots, err := b.availabilitySlots(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+			return
+		}
+		// Days are the viewer's: a member's evening slot may fall on the next day elsewhere
+		var viewer *storage.Member
+		for n := range members {
+			if members[n].DiscordID == i.Member.User.ID {
+				viewer = &members[n]
+			}
+		}
+		loc, now := memberLocation(viewer), time.Now()
+		embed := &discordgo.MessageEmbed{Title: "Guild Availability", Color: 0x00AAFF}
+		var lines []string
+		if d, ok := storage.ParseWeekday(day); ok {
+			start := nextDay(now, loc, d)
+			lines = availabilityDayLines(members, start, slots)
+			embed.Title += " on " + d.String()
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: "Times are shown in your local time."}
+			embed.Description = discordTime(start, "D") + "\n"
+		} else {
+			lines = availabilityWeekLines(members, loc, slots, now)
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: "Days and times in " + loc.String() + ". Set your zone with /timezone, or pick a day for times in your local time."}
+		}
+		if len(lines) == 0 {
+			lines = []string{"(no members)"}
+		}
+		embed.Description += joinLinesLimit(lines, embedDescriptionLimit-len(embed.Description))
 		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseChannelMessageWithSource, Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}})
+	case "coverage":
+		coverageRespond(b, s, i, c, sub, members, alts, types)
 	case "current":
-		lines := storage.FormatMembers(members, func(m storage.Member) string {
-			return m.InGameName + " - Orders: " + strconv.Itoa(m.WarOrders) + ", Lumber: " + formatNumber(m.Lumber)
-		})
-		embed := &discordgo.MessageEmbed{Title: "Current Guild Resources", Description: lines, Color: 0x00CC66}
+		var lines []string
+		for _, m := range members {
+			lines = append(lines, m.InGameName+" - "+formatResources(types, m.Resources))
+			for _, a := range alts[m.DiscordID] {
+				lines = append(lines, "↳ "+a.Name+" (alt) - "+formatResources(types, a.Resources))
+			}
+		}
+		desc := "(no members)"
+		if len(lines) > 0 {
+			desc = joinLinesLimit(lines, embedDescriptionLimit)
+		}
+		embed := &discordgo.MessageEmbed{Title: "Current Guild Resources", Description: desc, Color: 0x00CC66}
 		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseChannelMessageWithSource, Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}})
+	case "totals":
+		var lines []string
+		guildTotals := make(map[string]int)
+		for _, m := range members {
+			totals := make(map[string]int)
+			for _, t := range types {
+				totals[t.Key] = m.Resources[t.Key]
+				for _, a := range alts[m.DiscordID] {
+					totals[t.Key] += a.Resources[t.Key]
+				}
+				guildTotals[t.Key] += totals[t.Key]
+			}
+			line := "<@" + m.DiscordID + "> " + m.InGameName
+			if n := len(alts[m.DiscordID]); n > 0 {
+				line += " (+" + strconv.Itoa(n) + " alts)"
+			}
+			lines = append(lines, line+" - "+formatResources(types, totals))
+		}
+		desc := "(no members)"
+		if len(lines) > 0 {
+			desc = joinLinesLimit(lines, embedDescriptionLimit)
+		}
+		embed := &discordgo.MessageEmbed{
+			Title:       "Guild Resource Totals",
+			Description: desc,
+			Color:       0x00CC66,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Guild total - " + formatResources(types, guildTotals)},
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, AllowedMentions: &discordgo.MessageAllowedMentions{}},
+		})
 	}
 }
 
-// /syncroles [role-id]
+// /syncroles [role-id] (leader only)
 func handleSyncRoles(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
 	var filterRole string
 	if opts := i.ApplicationCommandData().Options; len(opts) > 0 {
@@ -258,9 +477,9 @@ func handleSyncRoles(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreat
 				}
 			}
 			c2, cancel := storage.WithTimeout(ctx)
-			_ = b.DB.InsertMemberIfMissing(c2, m.User.ID, m.User.Username)
+			_ = b.DB.InsertMemberIfMissing(c2, guildID, m.User.ID, m.User.Username)
 			if role := firstConfiguredRole(b, guildID, m); role != "" {
-				_ = b.DB.UpdateMemberRole(c2, m.User.ID, role)
+				_ = b.DB.UpdateMemberRole(c2, guildID, m.User.ID, role)
 				updated++
 			}
 			cancel()
@@ -274,12 +493,268 @@ func handleSyncRoles(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreat
 	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: "Role sync complete. Updated: " + strconv.Itoa(updated)})
 }
 
+// exportContentTypes maps export formats to attachment MIME types.
+var exportContentTypes = map[string]string{
+	storage.FormatCSV:  "text/csv",
+	storage.FormatJSON: "application/json",
+	storage.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// /export [format] [include] (officer or above)
+func handleExport(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	format, include := storage.FormatCSV, storage.IncludeAll
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "format":
+			format = o.StringValue()
+		case "include":
+			include = o.StringValue()
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	members, err := b.DB.GetAllMembers(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load roster: "+err.Error())
+		return
+	}
+	types, err := b.resourceTypes(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load resource types: "+err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err := storage.ExportMembers(&buf, format, include, types, members); err != nil {
+		ephemeralErrorRespond(s, i, "Export failed: "+err.Error())
+		return
+	}
+	name := "roster-" + include + "-" + time.Now().UTC().Format("20060102") + "." + format
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Roster export: " + strconv.Itoa(len(members)) + " members.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files:   []*discordgo.File{{Name: name, ContentType: exportContentTypes[format], Reader: &buf}},
+		},
+	})
+}
+
+// /history [user] [resource] [days]
+func handleHistory(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	var userID, resource string
+	days := defaultHistoryDays
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "user":
+			userID = o.UserValue(s).ID
+		case "resource":
+			resource = o.StringValue()
+		case "days":
+			if v := int(o.IntValue()); v > 0 {
+				days = v
+			}
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	types, _ := b.resourceTypes(c, i.GuildID)
+	if t, ok := findResourceType(types, resource); ok {
+		resource = t.Key
+	}
+	since := time.Now().AddDate(0, 0, -days)
+	entries, err := b.DB.GetResourceHistory(c, i.GuildID, userID, resource, since)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load history: "+err.Error())
+		return
+	}
+	var lines []string
+	for _, e := range entries {
+		name := e.InGameName
+		if name == "" {
+			name = "<@" + e.DiscordID + ">"
+		}
+		if e.Character != "" {
+			name = e.Character + " (alt of " + name + ")"
+		}
+		line := e.ChangedAt.Format("2006-01-02 15:04") + " " + name + " - " + resourceLabel(types, e.Resource) + ": " +
+			formatNumber(e.OldValue) + " → " + formatNumber(e.NewValue)
+		if e.ActorID != e.DiscordID {
+			line += " (by <@" + e.ActorID + ">)"
+		}
+		lines = append(lines, line)
+	}
+	desc := "(no changes)"
+	if len(lines) > 0 {
+		desc = joinLinesLimit(lines, embedDescriptionLimit)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Resource History (last " + strconv.Itoa(days) + " days)",
+		Description: desc,
+		Color:       0xCC9900,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Times are GMT."},
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseChannelMessageWithSource, Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}})
+}
+
+// /perm grant|revoke|list (leader only)
+func handlePerm(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	var tierName, subjectType, subjectID, mention string
+	for _, o := range sub.Options {
+		switch o.Name {
+		case "tier":
+			tierName = o.StringValue()
+		case "user":
+			u := o.UserValue(s)
+			subjectType, subjectID, mention = storage.SubjectUser, u.ID, u.Mention()
+		case "role":
+			r := o.RoleValue(s, i.GuildID)
+			subjectType, subjectID, mention = storage.SubjectRole, r.ID, "<@&"+r.ID+">"
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	switch sub.Name {
+	case "grant":
+		tier, ok := storage.ParseTier(tierName)
+		if !ok || tier == storage.TierNone {
+			ephemeralErrorRespond(s, i, "Unknown tier: "+tierName)
+			return
+		}
+		if subjectID == "" {
+			ephemeralErrorRespond(s, i, "Pick a user or a role to grant the tier to.")
+			return
+		}
+		if err := b.DB.GrantPermission(c, i.GuildID, subjectType, subjectID, tier); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to grant permission: "+err.Error())
+			return
+		}
+		ephemeralOK(s, i, mention+" now has the "+tier.String()+" tier.")
+	case "revoke":
+		if subjectID == "" {
+			ephemeralErrorRespond(s, i, "Pick a user or a role to revoke.")
+			return
+		}
+		if err := b.DB.RevokePermission(c, i.GuildID, subjectType, subjectID); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to revoke permission: "+err.Error())
+			return
+		}
+		ephemeralOK(s, i, "Revoked the tier granted to "+mention+".")
+	case "list":
+		grants, err := b.DB.ListPermissions(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to list permissions: "+err.Error())
+			return
+		}
+		var lines []string
+		hasMember := false
+		for _, g := range grants {
+			who := "<@" + g.SubjectID + ">"
+			if g.SubjectType == storage.SubjectRole {
+				who = "<@&" + g.SubjectID + ">"
+			}
+			if g.Tier == storage.TierMember {
+				hasMember = true
+			}
+			lines = append(lines, g.Tier.String()+" - "+who)
+		}
+		desc := "(no grants; only server administrators are leaders)"
+		if len(lines) > 0 {
+			desc = joinLinesLimit(lines, embedDescriptionLimit)
+		}
+		footer := "Everyone in the server has the member tier."
+		if hasMember {
+			footer = "Only listed users and roles have the member tier."
+		}
+		embed := &discordgo.MessageEmbed{Title: "Permission Tiers", Description: desc, Color: 0x9966CC, Footer: &discordgo.MessageEmbedFooter{Text: footer}}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+}
+
+// /backup now (leader only)
+func handleBackup(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	if b.Config.BackupDir == "" {
+		ephemeralErrorRespond(s, i, "Backups are disabled; set BackupDir (or BACKUP_DIR) to enable them.")
+		return
+	}
+	// Acknowledge
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: "Backing up...", Flags: discordgo.MessageFlagsEphemeral},
+	})
+	c, cancel := context.WithTimeout(ctx, backupTimeout)
+	defer cancel()
+	path, err := b.runBackup(c)
+	content := "Backup created and verified: " + filepath.Base(path)
+	if err != nil {
+		content = "Backup failed: " + err.Error()
+	}
+	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{Content: content, Flags: discordgo.MessageFlagsEphemeral})
+}
+
+// joinLinesLimit joins lines with newlines, dropping trailing lines that would exceed limit
+// (leaving room for a "… and N more" note).
+func joinLinesLimit(lines []string, limit int) string {
+	var b strings.Builder
+	for n, l := range lines {
+		if b.Len()+len(l)+1 > limit-32 {
+			b.WriteString("… and " + strconv.Itoa(len(lines)-n) + " more")
+			break
+		}
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// joinNamesLimit joins names with ", ", dropping trailing names that would exceed limit
+// (leaving room for a "… and N more" note).
+func joinNamesLimit(names []string, limit int) string {
+	var b strings.Builder
+	for n, name := range names {
+		if n > 0 {
+			b.WriteString(", ")
+		}
+		if b.Len()+len(name) > limit-32 {
+			b.WriteString("… and " + strconv.Itoa(len(names)-n) + " more")
+			break
+		}
+		b.WriteString(name)
+	}
+	return b.String()
+}
+
+// splitLines groups lines, in order, so that each group joined by newlines fits in limit
+// characters. A line longer than limit gets a group of its own.
+func splitLines(lines []string, limit int) [][]string {
+	var groups [][]string
+	size := 0
+	for _, l := range lines {
+		if n := len(groups); n > 0 && size+1+len(l) <= limit {
+			groups[n-1] = append(groups[n-1], l)
+			size += 1 + len(l)
+			continue
+		}
+		groups = append(groups, []string{l})
+		size = len(l)
+	}
+	return groups
+}
+
+// formatNumber groups digits in thousands, e.g. -1234567 as "-1,234,567".
 func formatNumber(n int) string {
-	in := strconv.Itoa(n)
+	in, sign := strconv.Itoa(n), ""
+	if n < 0 {
+		in, sign = in[1:], "-"
+	}
 	if len(in) <= 3 {
-		return in
+		return sign + in
 	}
 	var b strings.Builder
+	b.WriteString(sign)
 	pre := len(in) % 3
 	if pre == 0 {
 		pre = 3
@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestRegisterAndUpdateOrders(t *testing.T) {
+	b, fake := newTestBot(t)
+	run := func(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		handleSlashCommand(b, b.Session, slashCommand("g", "u1", name, options...))
+		return fake.lastResponse(t).Data.Content
+	}
+
+	if got := run("order", subcommand("add", intOption("amount", 5))); got != "You must /register first." {
+		t.Fatalf("/order before /register = %q", got)
+	}
+	if got := run("register", stringOption("in-game-name", "Alpha")); got != "You have been registered as Alpha." {
+		t.Fatalf("/register = %q", got)
+	}
+	if got := run("order", subcommand("add", intOption("amount", 1500))); got != "Your War Orders changed by +1,500 to 1,500." {
+		t.Fatalf("/order add = %q", got)
+	}
+	if got := run("order", subcommand("subtract", intOption("amount", 2000))); !strings.Contains(got, "outside the allowed range") {
+		t.Fatalf("/order subtract below 0 = %q", got)
+	}
+	if got := run("order", subcommand("set", intOption("amount", -1))); got != "War Orders must be between 0 and 1,000,000,000." {
+		t.Fatalf("/order set below 0 = %q", got)
+	}
+	if got := run("lumber", subcommand("subtract", intOption("amount", 0))); got != "The amount to subtract must be greater than 0." {
+		t.Fatalf("/lumber subtract 0 = %q", got)
+	}
+	if got := run("lumber", subcommand("set", intOption("amount", 30))); got != "Your Lumber set to 30." {
+		t.Fatalf("/lumber set = %q", got)
+	}
+
+	members, err := b.DB.GetAllMembers(t.Context(), "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].InGameName != "Alpha" || members[0].Resources[storage.ResourceOrders] != 1500 || members[0].Resources[storage.ResourceLumber] != 30 {
+		t.Fatalf("stored members = %+v", members)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	for n, want := range map[int]string{
+		0:              "0",
+		999:            "999",
+		1000:           "1,000",
+		-5:             "-5",
+		-999:           "-999",
+		-1000:          "-1,000",
+		-100000:        "-100,000",
+		1_000_000_000:  "1,000,000,000",
+		-1_000_000_000: "-1,000,000,000",
+	} {
+		if got := formatNumber(n); got != want {
+			t.Errorf("formatNumber(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	// defaultRecommendations is how many slots /recommend wartime lists unless top is given.
+	defaultRecommendations = 5
+	maxRecommendations     = 10
+)
+
+// slotCoverage is who can play one slot on one weekday, and the War Orders they hold.
+type slotCoverage struct {
+	Day     time.Weekday
+	Slot    storage.AvailabilitySlot
+	Members []string // in-game names
+	Orders  int
+	Score   float64
+}
+
+// coverageGrid counts members per weekday and slot, in Week then slot order. Orders are summed
+// over each member's main and alts. A member's weight in Score is 1 plus their orders relative to
+// the guild average, so a member with average orders counts 2 and one with none counts 1.
+func coverageGrid(members []storage.Member, alts map[string][]storage.Character, slots []storage.AvailabilitySlot) []slotCoverage {
+	orders := make(map[string]int, len(members))
+	total := 0
+	for _, m := range members {
+		n := m.Resources[storage.ResourceOrders]
+		for _, a := range alts[m.DiscordID] {
+			n += a.Resources[storage.ResourceOrders]
+		}
+		orders[m.DiscordID] = n
+		total += n
+	}
+	var avg float64
+	if len(members) > 0 {
+		avg = float64(total) / float64(len(members))
+	}
+	var grid []slotCoverage
+	for _, day := range storage.Week {
+		for _, sl := range slots {
+			c := slotCoverage{Day: day, Slot: sl}
+			for _, m := range members {
+				if !slices.Contains(m.Availability[day], sl.Label) {
+					continue
+				}
+				c.Members = append(c.Members, m.InGameName)
+				c.Orders += orders[m.DiscordID]
+				c.Score++
+				if avg > 0 {
+					c.Score += float64(orders[m.DiscordID]) / avg
+				}
+			}
+			grid = append(grid, c)
+		}
+	}
+	return grid
+}
+
+// rankCoverage returns the slots anyone can play, best first: highest score, then most members,
+// then earliest in the week.
+func rankCoverage(grid []slotCoverage) []slotCoverage {
+	var ranked []slotCoverage
+	for _, c := range grid {
+		if len(c.Members) > 0 {
+			ranked = append(ranked, c)
+		}
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		if ranked[a].Score != ranked[b].Score {
+			return ranked[a].Score > ranked[b].Score
+		}
+		return len(ranked[a].Members) > len(ranked[b].Members)
+	})
+	return ranked
+}
+
+// nextSlotStart returns the next time slot starts on UTC weekday day, from now on.
+func nextSlotStart(now time.Time, day time.Weekday, slot storage.AvailabilitySlot) time.Time {
+	t := nextDay(now, time.UTC, day).Add(time.Duration(slot.Start) * time.Minute)
+	if t.Before(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+	return t
+}
+
+// coverageRespond answers /list coverage [day] [heatmap] with headcount and War Orders per slot.
+func coverageRespond(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, c context.Context, sub *discordgo.ApplicationCommandInteractionDataOption, members []storage.Member, alts map[string][]storage.Character, types []storage.ResourceType) {
+	var day string
+	heatmap := true
+	for _, o := range sub.Options {
+		switch o.Name {
+		case "day":
+			day = o.StringValue()
+		case "heatmap":
+			heatmap = o.BoolValue()
+		}
+	}
+	slots, err := b.availabilitySlots(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+		return
+	}
+	grid := coverageGrid(members, alts, slots)
+	orders := resourceLabel(types, storage.ResourceOrders)
+	embed := &discordgo.MessageEmbed{
+		Title:  "Availability Coverage",
+		Color:  0x00AAFF,
+		Footer: &discordgo.MessageEmbedFooter{Text: "Days and slots are in GMT, as set with /settings availability. Members (" + orders + ")."},
+	}
+	if d, ok := storage.ParseWeekday(day); ok {
+		embed.Title += " on " + d.String()
+		var lines []string
+		for _, cov := range grid {
+			if cov.Day != d {
+				continue
+			}
+			line := "**" + cov.Slot.Label + "** - " + strconv.Itoa(len(cov.Members)) + " (" + formatNumber(cov.Orders) + ")"
+			if len(cov.Members) > 0 {
+				line += ": " + strings.Join(cov.Members, ", ")
+			}
+			lines = append(lines, line)
+		}
+		embed.Description = joinLinesLimit(lines, embedDescriptionLimit)
+	} else {
+		for n, sl := range slots {
+			var cells []string
+			for _, cov := range grid {
+				if cov.Slot.Label == sl.Label {
+					cells = append(cells, "`"+storage.DayName(cov.Day)+"` "+strconv.Itoa(len(cov.Members))+" ("+formatNumber(cov.Orders)+")")
+				}
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: strconv.Itoa(n+1) + ". " + sl.Label, Value: strings.Join(cells, "\n"), Inline: true})
+		}
+	}
+	data := &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}
+	if heatmap {
+		var buf bytes.Buffer
+		if err := renderHeatmap(&buf, grid, slots); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to draw heatmap: "+err.Error())
+			return
+		}
+		embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://coverage.png"}
+		data.Files = []*discordgo.File{{Name: "coverage.png", ContentType: "image/png", Reader: &buf}}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseChannelMessageWithSource, Data: data})
+}
+
+// /recommend wartime [top] (officer or above) ranks slots by members weighted by War Orders
+func handleRecommend(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	if sub.Name != "wartime" {
+		return
+	}
+	top := defaultRecommendations
+	for _, o := range sub.Options {
+		if o.Name == "top" {
+			top = min(max(int(o.IntValue()), 1), maxRecommendations)
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	members, err := b.DB.GetAllMembers(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load roster: "+err.Error())
+		return
+	}
+	chars, err := b.DB.GetCharacters(c, i.GuildID, "")
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load characters: "+err.Error())
+		return
+	}
+	alts := make(map[string][]storage.Character)
+	for _, ch := range chars {
+		alts[ch.DiscordID] = append(alts[ch.DiscordID], ch)
+	}
+	slots, err := b.availabilitySlots(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+		return
+	}
+	types, _ := b.resourceTypes(c, i.GuildID)
+	ranked := rankCoverage(coverageGrid(members, alts, slots))
+	if len(ranked) == 0 {
+		ephemeralErrorRespond(s, i, "No member has set their availability yet. Ask them to use /availability.")
+		return
+	}
+	now := time.Now()
+	var lines []string
+	for n, cov := range ranked[:min(top, len(ranked))] {
+		lines = append(lines, fmt.Sprintf("%d. **%s %s** (next %s) - %d members, %s %s, score %.1f",
+			n+1, storage.DayName(cov.Day), cov.Slot.Label, discordTime(nextSlotStart(now, cov.Day, cov.Slot), "f"),
+			len(cov.Members), formatNumber(cov.Orders), resourceLabel(types, storage.ResourceOrders), cov.Score))
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Best War Times",
+		Description: joinLinesLimit(lines, embedDescriptionLimit),
+		Color:       0x00AAFF,
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Each available member scores 1, plus their War Orders divided by the guild average."},
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{Type: discordgo.InteractionResponseChannelMessageWithSource, Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}}})
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestCoverageGrid(t *testing.T) {
+	slots := storage.DefaultAvailabilitySlots()
+	early, evening := slots[0].Label, slots[1].Label
+	members := []storage.Member{
+		{DiscordID: "1", InGameName: "Alpha", Resources: map[string]int{storage.ResourceOrders: 30}, Availability: storage.Schedule{time.Monday: {evening}}},
+		{DiscordID: "2", InGameName: "Bravo", Availability: storage.Schedule{time.Monday: {evening}}},
+		{DiscordID: "3", InGameName: "Charlie", Resources: map[string]int{storage.ResourceOrders: 20}, Availability: storage.Schedule{time.Monday: {early}, time.Tuesday: {evening}}},
+	}
+	alts := map[string][]storage.Character{"1": {{Name: "Alpha Alt", Resources: map[string]int{storage.ResourceOrders: 10}}}}
+	grid := coverageGrid(members, alts, slots)
+	if len(grid) != 7*len(slots) || grid[0].Day != time.Monday || grid[len(slots)].Day != time.Tuesday {
+		t.Fatalf("grid has %d cells, want Week then slot order", len(grid))
+	}
+
+	// Orders average 20 (Alpha's 40 with the alt, Bravo's 0, Charlie's 20): Alpha weighs 3, Bravo 1
+	mon := grid[1]
+	if mon.Slot.Label != evening || len(mon.Members) != 2 || mon.Orders != 40 || mon.Score != 4 {
+		t.Fatalf("Monday %s = %+v, want Alpha and Bravo with 40 orders scoring 4", evening, mon)
+	}
+	if cov := grid[len(slots)+1]; len(cov.Members) != 1 || cov.Score != 2 {
+		t.Fatalf("Tuesday %s = %+v, want Charlie scoring 2", evening, cov)
+	}
+
+	ranked := rankCoverage(grid)
+	if len(ranked) != 3 {
+		t.Fatalf("ranked %d slots, want only the 3 anyone can play", len(ranked))
+	}
+	// Equal scores and headcounts keep week order
+	if ranked[0].Score != 4 || ranked[1].Day != time.Monday || ranked[1].Slot.Label != early || ranked[2].Day != time.Tuesday {
+		t.Fatalf("ranking = %+v", ranked)
+	}
+
+	// Without any orders each member counts 1
+	for n := range members {
+		members[n].Resources = nil
+	}
+	if cov := coverageGrid(members, nil, slots)[1]; cov.Score != 2 {
+		t.Fatalf("score without orders = %v, want the headcount", cov.Score)
+	}
+}
+
+func TestNextSlotStart(t *testing.T) {
+	slot := storage.AvailabilitySlot{Label: "18:00-20:00 GMT", Start: 18 * 60, End: 20 * 60}
+	now := time.Date(2024, 1, 10, 19, 0, 0, 0, time.UTC) // a Wednesday
+	if got := nextSlotStart(now, time.Wednesday, slot); !got.Equal(time.Date(2024, 1, 17, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("slot started an hour ago: next = %v, want a week later", got)
+	}
+	if got := nextSlotStart(now, time.Monday, slot); !got.Equal(time.Date(2024, 1, 15, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("next Monday slot = %v", got)
+	}
+}
+
+func TestRenderHeatmap(t *testing.T) {
+	slots := storage.DefaultAvailabilitySlots()
+	members := []storage.Member{{DiscordID: "1", InGameName: "Alpha", Availability: storage.Schedule{time.Friday: {slots[2].Label}}}}
+	var buf bytes.Buffer
+	if err := renderHeatmap(&buf, coverageGrid(members, nil, slots), slots); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("heatmap is not a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() == 0 || b.Dy() == 0 {
+		t.Fatalf("heatmap bounds = %v", b)
+	}
+}
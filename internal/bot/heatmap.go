@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// Heatmap layout in pixels. Glyphs are drawn from a 3x5 bitmap font scaled by glyphScale.
+const (
+	heatCellWidth  = 56
+	heatCellHeight = 32
+	heatMarginLeft = 40
+	heatMarginTop  = 28
+	glyphScale     = 3
+)
+
+// glyphs covers the digits and the letters of the three-letter day names.
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'A': {"###", "#.#", "###", "#.#", "#.#"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {"###", "#.#", "#.#", "#.#", "###"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {"###", "#..", "###", "..#", "###"},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+}
+
+var (
+	heatBackground = color.RGBA{0x2B, 0x2D, 0x31, 0xFF} // Discord dark theme
+	heatEmpty      = color.RGBA{0x40, 0x44, 0x4B, 0xFF}
+	heatFull       = color.RGBA{0x23, 0xA5, 0x5A, 0xFF}
+	heatText       = color.RGBA{0xF2, 0xF3, 0xF5, 0xFF}
+)
+
+// textWidth is the drawn width of s in pixels.
+func textWidth(s string) int {
+	n := len([]rune(s))
+	if n == 0 {
+		return 0
+	}
+	return (4*n - 1) * glyphScale
+}
+
+// drawText draws s with its top-left corner at (x, y). Characters without a glyph are left blank.
+func drawText(img draw.Image, x, y int, s string, c color.Color) {
+	for _, r := range s {
+		for row, bits := range glyphs[r] {
+			for col, bit := range bits {
+				if bit == '#' {
+					px := image.Rect(x+col*glyphScale, y+row*glyphScale, x+(col+1)*glyphScale, y+(row+1)*glyphScale)
+					draw.Draw(img, px, &image.Uniform{c}, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
+
+// heatColor blends from heatEmpty at 0 to heatFull at most.
+func heatColor(n, most int) color.Color {
+	if most == 0 || n == 0 {
+		return heatEmpty
+	}
+	f := float64(n) / float64(most)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*f) }
+	return color.RGBA{mix(heatEmpty.R, heatFull.R), mix(heatEmpty.G, heatFull.G), mix(heatEmpty.B, heatFull.B), 0xFF}
+}
+
+// renderHeatmap writes a PNG of member headcount per weekday (columns) and slot (rows, numbered
+// as in /list coverage).
+func renderHeatmap(w io.Writer, grid []slotCoverage, slots []storage.AvailabilitySlot) error {
+	row := make(map[string]int, len(slots))
+	for n, sl := range slots {
+		row[sl.Label] = n
+	}
+	width := heatMarginLeft + len(storage.Week)*heatCellWidth
+	height := heatMarginTop + len(slots)*heatCellHeight
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{heatBackground}, image.Point{}, draw.Src)
+	glyphHeight := 5 * glyphScale
+	col := make(map[int]int, len(storage.Week))
+	for n, d := range storage.Week {
+		col[int(d)] = n
+		name := strings.ToUpper(storage.DayName(d))
+		drawText(img, heatMarginLeft+n*heatCellWidth+(heatCellWidth-textWidth(name))/2, (heatMarginTop-glyphHeight)/2, name, heatText)
+	}
+	for n := range slots {
+		label := strconv.Itoa(n + 1)
+		drawText(img, (heatMarginLeft-textWidth(label))/2, heatMarginTop+n*heatCellHeight+(heatCellHeight-glyphHeight)/2, label, heatText)
+	}
+	most := 0
+	for _, c := range grid {
+		most = max(most, len(c.Members))
+	}
+	for _, c := range grid {
+		x := heatMarginLeft + col[int(c.Day)]*heatCellWidth
+		y := heatMarginTop + row[c.Slot.Label]*heatCellHeight
+		cell := image.Rect(x+1, y+1, x+heatCellWidth-1, y+heatCellHeight-1)
+		draw.Draw(img, cell, &image.Uniform{heatColor(len(c.Members), most)}, image.Point{}, draw.Src)
+		count := strconv.Itoa(len(c.Members))
+		drawText(img, x+(heatCellWidth-textWidth(count))/2, y+(heatCellHeight-glyphHeight)/2, count, heatText)
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,491 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	importConfirmPrefix = "roster_import_confirm:"
+	importCancelPrefix  = "roster_import_cancel:"
+	maxImportBytes      = 256 << 10
+	maxImportRows       = 200
+	importTTL           = 10 * time.Minute
+)
+
+// pendingImport is a validated import waiting for the uploader to confirm the dry-run preview.
+type pendingImport struct {
+	guildID string
+	userID  string
+	rows    []storage.ImportMember
+	expires time.Time
+}
+
+// importQueue holds pending imports keyed by the token embedded in the confirm/cancel button ids.
+type importQueue struct {
+	mu      sync.Mutex
+	pending map[string]*pendingImport
+}
+
+func (q *importQueue) put(p *pendingImport) string {
+	var raw [8]byte
+	_, _ = rand.Read(raw[:])
+	token := hex.EncodeToString(raw[:])
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		q.pending = make(map[string]*pendingImport)
+	}
+	now := time.Now()
+	for k, v := range q.pending {
+		if now.After(v.expires) {
+			delete(q.pending, k)
+		}
+	}
+	q.pending[token] = p
+	return token
+}
+
+// take removes and returns the pending import for token if it exists, has not expired and was
+// uploaded by userID in guildID. ok is false when the import exists but belongs to someone else.
+func (q *importQueue) take(token, guildID, userID string) (p *pendingImport, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	p = q.pending[token]
+	if p == nil {
+		return nil, true
+	}
+	if p.guildID != guildID || p.userID != userID {
+		return nil, false
+	}
+	delete(q.pending, token)
+	if time.Now().After(p.expires) {
+		return nil, true
+	}
+	return p, true
+}
+
+// importRow is one parsed line of an import file before it becomes a storage.ImportMember.
+type importRow struct {
+	line     int
+	username string
+	member   storage.ImportMember
+	errs     []string
+}
+
+// importColumnAliases maps accepted header names to canonical column names. Export headers
+// round-trip; resource columns are the guild's resource type keys (see canonicalImportColumn).
+var importColumnAliases = map[string]string{
+	"discord_id":    "discord_id",
+	"discordid":     "discord_id",
+	"user_id":       "discord_id",
+	"id":            "discord_id",
+	"username":      "username",
+	"user":          "username",
+	"discord_user":  "username",
+	"in_game_name":  "in_game_name",
+	"ign":           "in_game_name",
+	"name":          "in_game_name",
+	"availability":  "availability",
+	"guild_role_id": "", // exported but not importable
+	"updated_at":    "",
+}
+
+// importResourcePrefix marks canonical columns that hold a resource amount, e.g. "resource:orders".
+const importResourcePrefix = "resource:"
+
+// canonicalImportColumn maps a header to its canonical column. Resource type keys are accepted as
+// columns, and war_orders (the column name before resource types were configurable) means orders.
+func canonicalImportColumn(h string, types []storage.ResourceType) (string, bool) {
+	h = strings.ToLower(strings.TrimSpace(h))
+	h = strings.NewReplacer(" ", "_", "-", "_").Replace(h)
+	if h == "war_orders" {
+		h = storage.ResourceOrders
+	}
+	for _, t := range types {
+		if t.Key == h {
+			return importResourcePrefix + t.Key, true
+		}
+	}
+	c, ok := importColumnAliases[h]
+	return c, ok
+}
+
+// parseImportFile decodes a CSV (with header row) or JSON array of objects into rows.
+// The format is chosen by file extension, falling back to sniffing for a leading '['.
+func parseImportFile(filename string, data []byte, types []storage.ResourceType) ([]importRow, error) {
+	ext := strings.ToLower(path.Ext(filename))
+	trimmed := bytes.TrimSpace(data)
+	if ext == ".json" || (ext != ".csv" && len(trimmed) > 0 && trimmed[0] == '[') {
+		return parseImportJSON(trimmed, types)
+	}
+	return parseImportCSV(data, types)
+}
+
+func parseImportCSV(data []byte, types []storage.ResourceType) ([]importRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, errors.New("CSV needs a header row and at least one data row")
+	}
+	cols := make([]string, len(records[0]))
+	for n, h := range records[0] {
+		c, ok := canonicalImportColumn(h, types)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", h)
+		}
+		cols[n] = c
+	}
+	var rows []importRow
+	for n, rec := range records[1:] {
+		fields := make(map[string]string)
+		for c, v := range rec {
+			if c < len(cols) && cols[c] != "" {
+				fields[cols[c]] = strings.TrimSpace(v)
+			}
+		}
+		rows = append(rows, rowFromFields(n+2, fields))
+	}
+	return rows, nil
+}
+
+func parseImportJSON(data []byte, types []storage.ResourceType) ([]importRow, error) {
+	var objs []map[string]any
+	if err := json.Unmarshal(data, &objs); err != nil {
+		return nil, fmt.Errorf("invalid JSON (want an array of objects): %w", err)
+	}
+	var rows []importRow
+	for n, obj := range objs {
+		fields := make(map[string]string)
+		for k, v := range obj {
+			c, ok := canonicalImportColumn(k, types)
+			if !ok {
+				return nil, fmt.Errorf("unknown field %q", k)
+			}
+			if c == "" || v == nil {
+				continue
+			}
+			switch v := v.(type) {
+			case string:
+				fields[c] = strings.TrimSpace(v)
+			case float64:
+				fields[c] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				fields[c] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, rowFromFields(n+1, fields))
+	}
+	return rows, nil
+}
+
+// rowFromFields validates one row's canonical fields. Blank optional fields are left unset.
+func rowFromFields(line int, f map[string]string) importRow {
+	row := importRow{line: line, username: f["username"]}
+	row.member.DiscordID = strings.TrimPrefix(strings.TrimSuffix(strings.TrimPrefix(f["discord_id"], "<@"), ">"), "!")
+	row.member.InGameName = f["in_game_name"]
+	if row.member.DiscordID == "" && row.username == "" {
+		row.errs = append(row.errs, "needs discord_id or username")
+	}
+	if row.member.DiscordID != "" {
+		if _, err := strconv.ParseUint(row.member.DiscordID, 10, 64); err != nil {
+			row.errs = append(row.errs, "discord_id must be numeric")
+		}
+	}
+	if row.member.InGameName == "" {
+		row.errs = append(row.errs, "in_game_name is required")
+	}
+	for _, col := range slices.Sorted(maps.Keys(f)) {
+		v := f[col]
+		key, ok := strings.CutPrefix(col, importResourcePrefix)
+		if !ok || v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.ReplaceAll(v, ",", ""))
+		if err != nil {
+			row.errs = append(row.errs, key+" must be a whole number")
+			continue
+		}
+		if row.member.Resources == nil {
+			row.member.Resources = make(map[string]int)
+		}
+		row.member.Resources[key] = n
+	}
+	if v := f["availability"]; v != "" {
+		sched, err := storage.ParseSchedule(v)
+		if err != nil {
+			row.errs = append(row.errs, "invalid availability: "+err.Error())
+		}
+		row.member.Availability = sched
+	}
+	return row
+}
+
+// checkImportAvailability flags schedules naming slots the guild does not offer. Imported
+// schedules use stored (UTC) days and slot labels, as written by /export.
+func checkImportAvailability(slots []storage.AvailabilitySlot, rows []importRow) {
+	for n := range rows {
+		r := &rows[n]
+		bad := make(map[string]bool)
+		for _, d := range storage.Week {
+			for _, slot := range r.member.Availability[d] {
+				if !validAvailability(slots, slot) && !bad[slot] {
+					bad[slot] = true
+					r.errs = append(r.errs, "unknown availability "+strconv.Quote(slot))
+				}
+			}
+			if len(r.member.Availability[d]) > 1 && slices.Contains(r.member.Availability[d], notAvailableSlot) && !bad[notAvailableSlot] {
+				bad[notAvailableSlot] = true
+				r.errs = append(r.errs, notAvailableSlot+" can't be combined with other slots")
+			}
+		}
+	}
+}
+
+// resolveImportUsers fills in Discord IDs for rows that only give a username, by exact
+// (case-insensitive) match on username, global name or server nickname.
+func resolveImportUsers(s *discordgo.Session, guildID string, rows []importRow) {
+	for n := range rows {
+		r := &rows[n]
+		if r.member.DiscordID != "" || r.username == "" {
+			continue
+		}
+		members, err := s.GuildMembersSearch(guildID, r.username, 10)
+		if err != nil {
+			r.errs = append(r.errs, "could not look up username: "+err.Error())
+			continue
+		}
+		var match []*discordgo.Member
+		for _, m := range members {
+			if strings.EqualFold(m.User.Username, r.username) || strings.EqualFold(m.User.GlobalName, r.username) || strings.EqualFold(m.Nick, r.username) {
+				match = append(match, m)
+			}
+		}
+		switch len(match) {
+		case 0:
+			r.errs = append(r.errs, "no server member named "+strconv.Quote(r.username))
+		case 1:
+			r.member.DiscordID = match[0].User.ID
+		default:
+			r.errs = append(r.errs, "username "+strconv.Quote(r.username)+" matches several members; use discord_id")
+		}
+	}
+	seen := make(map[string]int)
+	for n := range rows {
+		id := rows[n].member.DiscordID
+		if id == "" {
+			continue
+		}
+		if first, ok := seen[id]; ok {
+			rows[n].errs = append(rows[n].errs, "same member as line "+strconv.Itoa(first))
+			continue
+		}
+		seen[id] = rows[n].line
+	}
+}
+
+// checkImportBounds flags resource amounts outside the bounds of their resource type.
+func checkImportBounds(types []storage.ResourceType, rows []importRow) {
+	for n := range rows {
+		r := &rows[n]
+		for _, t := range types {
+			if v, ok := r.member.Resources[t.Key]; ok && !t.Contains(v) {
+				r.errs = append(r.errs, t.Name+" must be between "+formatNumber(t.Min)+" and "+formatNumber(t.Max))
+			}
+		}
+	}
+}
+
+// fetchAttachment downloads a slash-command attachment, refusing files over maxImportBytes.
+func fetchAttachment(s *discordgo.Session, att *discordgo.MessageAttachment) ([]byte, error) {
+	if att.Size > maxImportBytes {
+		return nil, fmt.Errorf("file is too large (max %d KB)", maxImportBytes>>10)
+	}
+	resp, err := s.Client.Get(att.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportBytes+1))
+}
+
+// /roster import file (officer or above): parse, validate and show a dry-run preview.
+func handleRosterImport(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	data := i.ApplicationCommandData()
+	var att *discordgo.MessageAttachment
+	if len(sub.Options) > 0 && data.Resolved != nil {
+		if id, ok := sub.Options[0].Value.(string); ok {
+			att = data.Resolved.Attachments[id]
+		}
+	}
+	if att == nil {
+		ephemeralErrorRespond(s, i, "Attach a CSV or JSON file to import.")
+		return
+	}
+	// Downloading and resolving usernames can exceed the 3s response window
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral},
+	})
+	editContent := func(msg string) {
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg})
+	}
+	raw, err := fetchAttachment(s, att)
+	if err != nil {
+		editContent("Import failed: " + err.Error())
+		return
+	}
+	if len(raw) > maxImportBytes {
+		editContent(fmt.Sprintf("Import failed: file is too large (max %d KB)", maxImportBytes>>10))
+		return
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	types, err := b.resourceTypes(c, i.GuildID)
+	cancel()
+	if err != nil {
+		editContent("Import failed: " + err.Error())
+		return
+	}
+	rows, err := parseImportFile(att.Filename, raw, types)
+	if err != nil {
+		editContent("Import failed: " + err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		editContent("Import failed: the file has no rows.")
+		return
+	}
+	if len(rows) > maxImportRows {
+		editContent("Import failed: at most " + strconv.Itoa(maxImportRows) + " rows per import.")
+		return
+	}
+	resolveImportUsers(s, i.GuildID, rows)
+	checkImportBounds(types, rows)
+	slots, err := b.availabilitySlots(c, i.GuildID)
+	if err != nil {
+		editContent("Import failed: " + err.Error())
+		return
+	}
+	checkImportAvailability(slots, rows)
+
+	// Username lookups go to Discord and can use up a storage timeout, so start a fresh one
+	c, cancel = storage.WithTimeout(ctx)
+	defer cancel()
+	existing := make(map[string]bool)
+	if members, err := b.DB.GetAllMembers(c, i.GuildID); err == nil {
+		for _, m := range members {
+			existing[m.DiscordID] = true
+		}
+	}
+
+	var lines []string
+	var valid []storage.ImportMember
+	added, updated, failed := 0, 0, 0
+	for _, r := range rows {
+		if len(r.errs) > 0 {
+			failed++
+			lines = append(lines, "❌ line "+strconv.Itoa(r.line)+": "+strings.Join(r.errs, "; "))
+			continue
+		}
+		verb := "new"
+		if existing[r.member.DiscordID] {
+			verb = "update"
+			updated++
+		} else {
+			added++
+		}
+		line := "✅ <@" + r.member.DiscordID + "> → " + r.member.InGameName + " (" + verb + ")"
+		for _, t := range types {
+			if v, ok := r.member.Resources[t.Key]; ok {
+				line += ", " + strings.ToLower(t.Name) + " " + formatNumber(v)
+			}
+		}
+		if r.member.Availability != nil {
+			line += ", " + r.member.Availability.String()
+		}
+		lines = append(lines, line)
+		valid = append(valid, r.member)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "Roster import preview (dry run)",
+		Description: joinLinesLimit(lines, embedDescriptionLimit),
+		Color:       0x00AAFF,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d new, %d updated, %d with errors", added, updated, failed)},
+	}
+	if failed > 0 {
+		embed.Color = 0xCC3333
+		msg := "Nothing was imported. Fix the rows marked ❌ and run /roster import again."
+		_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg, Embeds: &[]*discordgo.MessageEmbed{embed}})
+		return
+	}
+	token := b.imports.put(&pendingImport{guildID: i.GuildID, userID: i.Member.User.ID, rows: valid, expires: time.Now().Add(importTTL)})
+	msg := "Review the changes below. Nothing is saved until you confirm."
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Confirm import", Style: discordgo.SuccessButton, CustomID: importConfirmPrefix + token},
+			discordgo.Button{Label: "Cancel", Style: discordgo.SecondaryButton, CustomID: importCancelPrefix + token},
+		}},
+	}
+	_, _ = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &msg, Embeds: &[]*discordgo.MessageEmbed{embed}, Components: &components})
+}
+
+// handleImportButton applies or discards a pending import from its confirm/cancel button.
+func handleImportButton(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	update := func(msg string) {
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{Content: msg, Components: []discordgo.MessageComponent{}},
+		})
+	}
+	confirm := strings.HasPrefix(customID, importConfirmPrefix)
+	token := strings.TrimPrefix(strings.TrimPrefix(customID, importConfirmPrefix), importCancelPrefix)
+	// Check the tier before taking the import, so a refused click leaves it pending
+	if !requireTier(context.Background(), b, s, i, commandTiers["roster"], "/roster import") {
+		return
+	}
+	p, ok := b.imports.take(token, i.GuildID, i.Member.User.ID)
+	if !ok {
+		ephemeralErrorRespond(s, i, "Only the member who uploaded this import can confirm it.")
+		return
+	}
+	if p == nil {
+		update("This import preview has expired. Run /roster import again.")
+		return
+	}
+	if !confirm {
+		update("Import cancelled. Nothing was changed.")
+		return
+	}
+	ctx, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	if err := b.DB.ImportMembers(ctx, p.guildID, i.Member.User.ID, p.rows); err != nil {
+		update("Import failed and was rolled back: " + err.Error())
+		return
+	}
+	update("Imported " + strconv.Itoa(len(p.rows)) + " members.")
+}
@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := "\xef\xbb\xbfDiscord ID,IGN,Orders,Lumber,Availability,updated_at\n" +
+		"<@!101>,Alpha,\"1,200\",,18:00-20:00 GMT,2024-01-01T00:00:00Z\n" +
+		"102,,lots,3,Sometimes,\n" +
+		"abc,Gamma,,,,\n"
+	rows, err := parseImportFile("roster.csv", []byte(data), storage.DefaultResourceTypes("g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkImportAvailability(storage.DefaultAvailabilitySlots(), rows)
+	if len(rows) != 3 {
+		t.Fatalf("parsed %d rows, want 3", len(rows))
+	}
+	alpha := rows[0]
+	if len(alpha.errs) != 0 || alpha.line != 2 || alpha.member.DiscordID != "101" || alpha.member.InGameName != "Alpha" {
+		t.Fatalf("row 1 = %+v", alpha)
+	}
+	if _, ok := alpha.member.Resources[storage.ResourceLumber]; ok || alpha.member.Resources[storage.ResourceOrders] != 1200 {
+		t.Fatalf("row 1 amounts = %v, want 1200 orders and lumber left unchanged", alpha.member.Resources)
+	}
+	if got := alpha.member.Availability.String(); got != "Mon, Tue, Wed, Thu, Fri, Sat, Sun: 18:00-20:00 GMT" {
+		t.Fatalf("row 1 availability = %q, want the single slot on every day", got)
+	}
+	if got := strings.Join(rows[1].errs, "; "); got != `in_game_name is required; orders must be a whole number; unknown availability "Sometimes"` {
+		t.Fatalf("row 2 errors = %q", got)
+	}
+	if got := strings.Join(rows[2].errs, "; "); got != "discord_id must be numeric" {
+		t.Fatalf("row 3 errors = %q", got)
+	}
+
+	if _, err := parseImportFile("roster.csv", []byte("discord_id,rank\n1,2\n"), storage.DefaultResourceTypes("g")); err == nil || !strings.Contains(err.Error(), `unknown column "rank"`) {
+		t.Fatalf("unknown column = %v, want an error", err)
+	}
+}
+
+func TestParseImportJSON(t *testing.T) {
+	// No extension: the leading '[' picks JSON. war_orders is the column name from before
+	// resource types were configurable.
+	rows, err := parseImportFile("upload", []byte(`[{"username": "alpha", "name": "Alpha", "war_orders": 12, "lumber": null}]`), storage.DefaultResourceTypes("g"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0].errs) != 0 || rows[0].username != "alpha" || rows[0].line != 1 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if m := rows[0].member; len(m.Resources) != 1 || m.Resources[storage.ResourceOrders] != 12 {
+		t.Fatalf("amounts = %v, want only orders", m.Resources)
+	}
+	if _, err := parseImportFile("roster.json", []byte(`{"discord_id": "1"}`), nil); err == nil {
+		t.Fatal("a JSON object instead of an array was accepted")
+	}
+}
+
+// importClick builds the interaction Discord sends when userID presses an import button.
+func importClick(userID, customID string, roles ...string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      "interaction",
+		Token:   "token",
+		Type:    discordgo.InteractionMessageComponent,
+		GuildID: "g",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: userID}, Roles: roles},
+		Data:    discordgo.MessageComponentInteractionData{CustomID: customID},
+	}}
+}
+
+func TestImportButtons(t *testing.T) {
+	b, fake := newTestBot(t)
+	b.Config.Guilds = []GuildConfig{{GuildID: "g", LeaderRoleIDs: []string{"lead"}}}
+	b.seedPermissions()
+	pending := func() string {
+		return b.imports.put(&pendingImport{guildID: "g", userID: "u1", expires: time.Now().Add(importTTL),
+			rows: []storage.ImportMember{{DiscordID: "101", InGameName: "Alpha", Resources: map[string]int{storage.ResourceOrders: 40}}}})
+	}
+	click := func(i *discordgo.InteractionCreate) string {
+		handleComponentInteraction(b, b.Session, i)
+		return fake.lastResponse(t).Data.Content
+	}
+
+	token := pending()
+	// The uploader lost the officer tier: the import must stay pending for when it is restored
+	if got := click(importClick("u1", importConfirmPrefix+token)); got != "You need the officer tier or higher to use /roster import." {
+		t.Fatalf("confirm without the tier = %q", got)
+	}
+	if got := click(importClick("u2", importConfirmPrefix+token, "lead")); got != "Only the member who uploaded this import can confirm it." {
+		t.Fatalf("confirm by another officer = %q", got)
+	}
+	if got := click(importClick("u1", importConfirmPrefix+token, "lead")); got != "Imported 1 members." {
+		t.Fatalf("confirm = %q", got)
+	}
+	members, err := b.DB.GetAllMembers(t.Context(), "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].InGameName != "Alpha" || members[0].Resources[storage.ResourceOrders] != 40 {
+		t.Fatalf("members after import = %+v", members)
+	}
+	if got := click(importClick("u1", importConfirmPrefix+token, "lead")); got != "This import preview has expired. Run /roster import again." {
+		t.Fatalf("second confirm = %q", got)
+	}
+
+	token = pending()
+	if got := click(importClick("u1", importCancelPrefix+token, "lead")); got != "Import cancelled. Nothing was changed." {
+		t.Fatalf("cancel = %q", got)
+	}
+	if got := click(importClick("u1", importConfirmPrefix+token, "lead")); got != "This import preview has expired. Run /roster import again." {
+		t.Fatalf("confirm after cancel = %q", got)
+	}
+}
+
+func TestCheckImportBounds(t *testing.T) {
+	types := storage.DefaultResourceTypes("g")
+	types[0].Bounds = storage.Bounds{Min: -100, Max: 500}
+	rows, err := parseImportFile("roster.csv", []byte("discord_id,in_game_name,orders,lumber\n1,A,-100,0\n2,B,501,-1\n"), types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkImportBounds(types, rows)
+	if len(rows[0].errs) != 0 {
+		t.Fatalf("row at the bounds has errors %q", rows[0].errs)
+	}
+	if got := strings.Join(rows[1].errs, "; "); got != "War Orders must be between -100 and 500; Lumber must be between 0 and 1,000,000,000" {
+		t.Fatalf("row outside the bounds has errors %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// profileContextMenu is the user context-menu command that opens a member's profile card.
+const profileContextMenu = "War Profile"
+
+// /profile [user|member] and the War Profile context menu
+func handleProfile(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	data := i.ApplicationCommandData()
+	query := i.Member.User.ID
+	if data.TargetID != "" {
+		query = data.TargetID
+	}
+	for _, o := range data.Options {
+		switch o.Name {
+		case "user":
+			query = o.UserValue(s).ID
+		case "member":
+			query = o.StringValue()
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	m, err := resolveMember(c, b, i.GuildID, query)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+		return
+	}
+	if m == nil {
+		switch query {
+		case i.Member.User.ID:
+			ephemeralErrorRespond(s, i, "You are not registered yet. Use /register first.")
+		case data.TargetID:
+			ephemeralErrorRespond(s, i, "<@"+query+"> is not on the roster.")
+		default:
+			ephemeralErrorRespond(s, i, "No roster member matches "+query+".")
+		}
+		return
+	}
+	embed, err := profileEmbed(c, b, *m)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load profile: "+err.Error())
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// profileEmbed renders everything stored for a member: main character, alts, availability,
+// tracked role and when each value last changed.
+func profileEmbed(ctx context.Context, b *Bot, m storage.Member) (*discordgo.MessageEmbed, error) {
+	alts, err := b.DB.GetCharacters(ctx, m.GuildID, m.DiscordID)
+	if err != nil {
+		return nil, err
+	}
+	types, err := b.resourceTypes(ctx, m.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := b.availabilitySlots(ctx, m.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := b.DB.GetResourceHistory(ctx, m.GuildID, m.DiscordID, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	// history is newest first, so the first entry per character and resource is its last change
+	type key struct{ character, resource string }
+	lastChange := make(map[key]time.Time)
+	for _, e := range history {
+		k := key{e.Character, e.Resource}
+		if _, ok := lastChange[k]; !ok {
+			lastChange[k] = e.ChangedAt
+		}
+	}
+	resourceValue := func(character, resource string, amount int) string {
+		v := formatNumber(amount)
+		if t, ok := lastChange[key{character, resource}]; ok {
+			v += "\nupdated " + discordTimestamp(t)
+		}
+		return v
+	}
+
+	role := "None"
+	if m.GuildRoleID != "" {
+		role = "<@&" + m.GuildRoleID + ">"
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Discord", Value: "<@" + m.DiscordID + ">", Inline: true},
+		{Name: "Tracked role", Value: role, Inline: true},
+		{Name: "Time zone", Value: memberLocation(&m).String(), Inline: true},
+		{Name: "Availability", Value: strings.ReplaceAll(displaySchedule(m.Availability, memberLocation(&m), slots, time.Now()).String(), "; ", "\n")},
+	}
+	for _, t := range types {
+		if len(fields) >= 24 {
+			break
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: t.Label(), Value: resourceValue("", t.Key, m.Resources[t.Key]), Inline: true})
+	}
+	for _, a := range alts {
+		// Discord allows 25 fields per embed; keep room for the last-updated field
+		if len(fields) >= 24 {
+			break
+		}
+		var lines []string
+		for _, t := range types {
+			lines = append(lines, t.Name+": "+resourceValue(a.Name, t.Key, a.Resources[t.Key]))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  a.Name + " (alt)",
+			Value: strings.Join(lines, "\n"),
+		})
+	}
+	updated := "Never"
+	if !m.UpdatedAt.IsZero() {
+		updated = discordTimestamp(m.UpdatedAt)
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Name: "Last updated", Value: updated})
+	embed := &discordgo.MessageEmbed{
+		Title:  m.InGameName,
+		Color:  0x3399FF,
+		Fields: fields,
+	}
+	if len(alts) > 0 {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: strconv.Itoa(len(alts)+1) + " characters"}
+	}
+	return embed, nil
+}
+
+// discordTimestamp formats t as a Discord timestamp that renders in the viewer's local time.
+func discordTimestamp(t time.Time) string {
+	return discordTime(t, "f") + " (" + discordTime(t, "R") + ")"
+}
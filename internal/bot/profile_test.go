@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestProfile(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	if err := b.DB.UpsertMember(ctx, "g", "1", "Ragnar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DB.AddCharacter(ctx, "g", "1", "Scout"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DB.SetResource(ctx, "g", "1", "", "1", storage.ResourceOrders, 1500); err != nil {
+		t.Fatal(err)
+	}
+	run := func(i *discordgo.InteractionCreate) discordgo.InteractionResponse {
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t)
+	}
+
+	resp := run(slashCommand("g", "1", "profile"))
+	if len(resp.Data.Embeds) != 1 {
+		t.Fatalf("own profile = %+v, want a card", resp.Data)
+	}
+	embed := resp.Data.Embeds[0]
+	fields := make(map[string]string)
+	for _, f := range embed.Fields {
+		fields[f.Name] = f.Value
+	}
+	if embed.Title != "Ragnar" || embed.Footer == nil || embed.Footer.Text != "2 characters" {
+		t.Fatalf("card title %q, footer %+v", embed.Title, embed.Footer)
+	}
+	if !strings.HasPrefix(fields["📜 War Orders"], "1,500\nupdated <t:") || fields["🪵 Lumber"] != "0" {
+		t.Fatalf("resource fields = %q, %q; want orders with their last change and lumber without", fields["📜 War Orders"], fields["🪵 Lumber"])
+	}
+	if fields["Scout (alt)"] != "War Orders: 0\nLumber: 0" || fields["Tracked role"] != "None" {
+		t.Fatalf("fields = %v", fields)
+	}
+
+	// Another member looked up by a typed name, then by the context menu
+	if resp := run(slashCommand("g", "2", "profile", stringOption("member", "scout"))); len(resp.Data.Embeds) != 1 || resp.Data.Embeds[0].Title != "Ragnar" {
+		t.Fatalf("profile by alt name = %+v", resp.Data)
+	}
+	menu := slashCommand("g", "1", profileContextMenu)
+	menu.Data = discordgo.ApplicationCommandInteractionData{Name: profileContextMenu, TargetID: "2"}
+	if got := run(menu).Data.Content; got != "<@2> is not on the roster." {
+		t.Fatalf("context menu on an unregistered user = %q", got)
+	}
+	if got := run(slashCommand("g", "2", "profile")).Data.Content; got != "You are not registered yet. Use /register first." {
+		t.Fatalf("own profile unregistered = %q", got)
+	}
+	if got := run(slashCommand("g", "1", "profile", stringOption("member", "Floki"))).Data.Content; got != "No roster member matches Floki." {
+		t.Fatalf("unknown member = %q", got)
+	}
+}
@@ -0,0 +1,316 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	// reminderCheckInterval is how often the scheduler looks for guilds with reminders due.
+	reminderCheckInterval = 5 * time.Minute
+	reminderTimeout       = 2 * time.Minute
+	maxStaleAfterDays     = 90
+	// discordMessageLimit is the most characters a Discord message may hold.
+	discordMessageLimit = 2000
+)
+
+// reminderDue finds the scheduled reminder time nearest to now in direction step (-1 for the
+// latest at or before now, 1 for the first after it): ReminderAt UTC on one of the ReminderDays.
+func reminderDue(gs storage.GuildSettings, now time.Time, step int) time.Time {
+	days, ok := parseDays(gs.ReminderDays)
+	if !ok {
+		days = storage.Week
+	}
+	now = now.UTC()
+	t := time.Date(now.Year(), now.Month(), now.Day(), gs.ReminderAt/60, gs.ReminderAt%60, 0, 0, time.UTC)
+	for n := 0; n < 8; n++ {
+		if slices.Contains(days, t.Weekday()) && (step < 0 && !t.After(now) || step > 0 && t.After(now)) {
+			break
+		}
+		t = t.AddDate(0, 0, step)
+	}
+	return t
+}
+
+// reminderSchedule describes when reminders go out, e.g. "weekdays at 18:00 UTC".
+func reminderSchedule(gs storage.GuildSettings) string {
+	clock := fmt.Sprintf("%02d:%02d UTC", gs.ReminderAt/60, gs.ReminderAt%60)
+	return strings.TrimPrefix(describeDays(gs.ReminderDays), "on ") + " at " + clock
+}
+
+// staleData lists what a member has not changed since cutoff: the guild's resource types, then
+// availability. Data never set counts as stale.
+func staleData(a storage.MemberActivity, types []storage.ResourceType, cutoff time.Time) []string {
+	var stale []string
+	for _, t := range types {
+		if a.Resources[t.Key].Before(cutoff) {
+			stale = append(stale, t.Key)
+		}
+	}
+	if a.Availability.Before(cutoff) {
+		stale = append(stale, storage.ReminderAvailability)
+	}
+	return stale
+}
+
+// staleNames names stale data for messages, e.g. "War Orders, Lumber and availability".
+func staleNames(stale []string, types []storage.ResourceType) string {
+	names := make([]string, len(stale))
+	for n, key := range stale {
+		names[n] = key
+		if key != storage.ReminderAvailability {
+			names[n] = resourceLabel(types, key)
+		}
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// sendReminders reminds every member of a guild who has not opted out and has stale data, by DM
+// or with one ping per member in the guild's reminder channel, and logs the run. Pings that do not
+// fit one message go out in several; a reminder is delivered only if the message with it was sent.
+func (b *Bot) sendReminders(ctx context.Context, gs storage.GuildSettings, now time.Time) ([]storage.ReminderLog, error) {
+	activity, err := b.DB.ListMemberActivity(ctx, gs.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	types, err := b.resourceTypes(ctx, gs.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	server := "this server"
+	if g, err := b.Session.State.Guild(gs.GuildID); err == nil && g.Name != "" {
+		server = g.Name
+	}
+	// Stamp the run before sending, so a failure to log it below does not send it all again on the next check
+	if err := b.DB.RecordReminderRun(ctx, gs.GuildID, now, nil); err != nil {
+		return nil, err
+	}
+	cutoff := now.AddDate(0, 0, -gs.StaleAfterDays)
+	howLong := fmt.Sprintf("%d days", gs.StaleAfterDays)
+	if gs.StaleAfterDays == 1 {
+		howLong = "a day"
+	}
+	var sent []storage.ReminderLog
+	var pings []string
+	var pinged []int // index in sent of each ping's reminder
+	for _, a := range activity {
+		stale := staleData(a, types, cutoff)
+		if a.RemindersOff || len(stale) == 0 {
+			continue
+		}
+		r := storage.ReminderLog{GuildID: gs.GuildID, DiscordID: a.DiscordID, InGameName: a.InGameName, Stale: stale, SentAt: now}
+		if gs.ReminderChannelID != "" {
+			pings = append(pings, "<@"+a.DiscordID+"> "+staleNames(stale, types))
+			pinged = append(pinged, len(sent))
+		} else {
+			verb := " haven't"
+			if len(stale) == 1 {
+				verb = " hasn't"
+			}
+			msg := "Your " + staleNames(stale, types) + " in " + server + verb + " been updated in over " + howLong +
+				". Please check /order, /lumber, /resource or /availability there so officers can plan wars. Use /reminders off to stop these messages."
+			ch, err := b.Session.UserChannelCreate(a.DiscordID)
+			if err == nil {
+				_, err = b.Session.ChannelMessageSend(ch.ID, msg)
+			}
+			if err != nil {
+				log.Printf("WARN: remind %s in guild %s: %v", a.DiscordID, gs.GuildID, err)
+			}
+			r.Delivered = err == nil
+		}
+		sent = append(sent, r)
+	}
+	if len(pings) > 0 {
+		header := "Not updated in over " + howLong + ", please check with /order, /lumber, /resource or /availability (/reminders off to opt out):\n"
+		next := 0
+		for _, group := range splitLines(pings, discordMessageLimit-len(header)) {
+			_, err := b.Session.ChannelMessageSend(gs.ReminderChannelID, header+strings.Join(group, "\n"))
+			if err != nil {
+				log.Printf("WARN: reminders in channel %s of guild %s: %v", gs.ReminderChannelID, gs.GuildID, err)
+			}
+			for _, n := range pinged[next : next+len(group)] {
+				sent[n].Delivered = err == nil
+			}
+			next += len(group)
+		}
+	}
+	// The Discord calls above can use up ctx, so log the run with a fresh timeout
+	c, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	return sent, b.DB.RecordReminderRun(c, gs.GuildID, now, sent)
+}
+
+// backgroundReminders sends each guild's stale-data reminders when they are due. A run missed
+// while the bot was down is sent once it is back.
+func (b *Bot) backgroundReminders() {
+	ticker := time.NewTicker(reminderCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		b.remindDueGuilds(time.Now())
+	}
+}
+
+// remindDueGuilds sends the reminders of every guild with a run due at now, each guild with its
+// own timeout so a slow one does not starve the rest.
+func (b *Bot) remindDueGuilds(now time.Time) {
+	ctx, cancel := storage.WithTimeout(context.Background())
+	settings, err := b.DB.ListGuildSettings(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("ERROR: reminders: %v", err)
+		return
+	}
+	for _, gs := range settings {
+		if gs.StaleAfterDays <= 0 || !reminderDue(gs, now, -1).After(gs.LastReminderAt) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), reminderTimeout)
+		if sent, err := b.sendReminders(ctx, gs, now); err != nil {
+			log.Printf("ERROR: reminders for guild %s: %v", gs.GuildID, err)
+		} else {
+			log.Printf("Reminders sent in guild %s: %d members", gs.GuildID, len(sent))
+		}
+		cancel()
+	}
+}
+
+// handleReminderSettings answers /settings reminders set|off|status (leader only).
+func handleReminderSettings(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	gs, err := b.DB.GetGuildSettings(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load settings: "+err.Error())
+		return
+	}
+	switch sub.Name {
+	case "set":
+		gs.ReminderDays, gs.ReminderChannelID = "all", ""
+		okTime := false
+		for _, o := range sub.Options {
+			switch o.Name {
+			case "stale-days":
+				gs.StaleAfterDays = int(o.IntValue())
+			case "time":
+				gs.ReminderAt, okTime = parseClock(o.StringValue())
+			case "days":
+				gs.ReminderDays = o.StringValue()
+			case "channel":
+				gs.ReminderChannelID = o.ChannelValue(nil).ID
+			}
+		}
+		if gs.StaleAfterDays < 1 || gs.StaleAfterDays > maxStaleAfterDays {
+			ephemeralErrorRespond(s, i, fmt.Sprintf("stale-days must be between 1 and %d.", maxStaleAfterDays))
+			return
+		}
+		if !okTime {
+			ephemeralErrorRespond(s, i, "time must be UTC in 24-hour HH:MM form, e.g. 18:00.")
+			return
+		}
+		if _, ok := parseDays(gs.ReminderDays); !ok {
+			ephemeralErrorRespond(s, i, "Pick the days from the list.")
+			return
+		}
+		if err := b.DB.SaveGuildSettings(c, gs); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to save settings: "+err.Error())
+			return
+		}
+		// Count from now, so a run that was due earlier today is not sent right away
+		if err := b.DB.RecordReminderRun(c, i.GuildID, time.Now(), nil); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to save settings: "+err.Error())
+			return
+		}
+		where := "by DM"
+		if gs.ReminderChannelID != "" {
+			where = "in <#" + gs.ReminderChannelID + ">"
+		}
+		ephemeralOK(s, i, fmt.Sprintf("Members whose resources or availability haven't changed in %d days will be reminded %s, %s. The first run is %s.",
+			gs.StaleAfterDays, where, reminderSchedule(gs), discordTime(reminderDue(gs, time.Now(), 1), "f")))
+	case "off":
+		gs.StaleAfterDays = 0
+		if err := b.DB.SaveGuildSettings(c, gs); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to save settings: "+err.Error())
+			return
+		}
+		ephemeralOK(s, i, "Stale-data reminders are off.")
+	case "status":
+		types, _ := b.resourceTypes(c, i.GuildID)
+		embed := &discordgo.MessageEmbed{Title: "Stale-Data Reminders", Color: 0x00AAFF}
+		if gs.StaleAfterDays == 0 {
+			embed.Description = "Off. Turn them on with /settings reminders set."
+		} else {
+			where := "by DM"
+			if gs.ReminderChannelID != "" {
+				where = "in <#" + gs.ReminderChannelID + ">"
+			}
+			embed.Description = fmt.Sprintf("Members whose resources or availability haven't changed in %d days are reminded %s, %s. Next run %s.",
+				gs.StaleAfterDays, where, reminderSchedule(gs), discordTime(reminderDue(gs, time.Now(), 1), "f"))
+		}
+		activity, err := b.DB.ListMemberActivity(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load members: "+err.Error())
+			return
+		}
+		var optedOut []string
+		for _, a := range activity {
+			if a.RemindersOff {
+				optedOut = append(optedOut, a.InGameName)
+			}
+		}
+		if len(optedOut) > 0 {
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("Opted out (%d)", len(optedOut)), Value: joinNamesLimit(optedOut, 1024)})
+		}
+		last, err := b.DB.GetLastReminderRun(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load the last run: "+err.Error())
+			return
+		}
+		if !gs.LastReminderAt.IsZero() {
+			var lines []string
+			for _, r := range last {
+				line := "<@" + r.DiscordID + "> " + r.InGameName + " - " + staleNames(r.Stale, types)
+				if !r.Delivered {
+					line += " (not delivered)"
+				}
+				lines = append(lines, line)
+			}
+			value := "Nobody was reminded."
+			if len(lines) > 0 {
+				value = joinLinesLimit(lines, 1024)
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: fmt.Sprintf("Last check %s (%d reminded)", gs.LastReminderAt.UTC().Format("Mon 2 Jan 15:04 MST"), len(lines)), Value: value})
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral, AllowedMentions: &discordgo.MessageAllowedMentions{}},
+		})
+	}
+}
+
+// /reminders on|off
+func handleReminders(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	off := i.ApplicationCommandData().Options[0].Name == "off"
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	switch err := b.DB.SetRemindersOff(c, i.GuildID, i.Member.User.ID, off); {
+	case errors.Is(err, storage.ErrMemberNotRegistered):
+		ephemeralErrorRespond(s, i, "You must /register first.")
+	case err != nil:
+		ephemeralErrorRespond(s, i, "Failed to save: "+err.Error())
+	case off:
+		ephemeralOK(s, i, "You won't get reminders to update your resources or availability here. Turn them back on with /reminders on.")
+	default:
+		ephemeralOK(s, i, "You'll be reminded when your resources or availability go unchanged for a while, if the leaders have turned reminders on.")
+	}
+}
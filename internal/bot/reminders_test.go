@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestChannelRemindersSendEveryPing(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	for n := range 100 {
+		if err := b.DB.UpsertMember(ctx, "g", fmt.Sprintf("member-%03d", n), fmt.Sprintf("Member %03d", n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages := 0
+	fake.fail = func(r fakeRequest) bool {
+		if !strings.HasSuffix(r.Path, "/channels/chan/messages") {
+			return false
+		}
+		messages++
+		return messages == 2
+	}
+	gs := storage.GuildSettings{GuildID: "g", StaleAfterDays: 7, ReminderChannelID: "chan"}
+	sent, err := b.sendReminders(ctx, gs, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 100 {
+		t.Fatalf("reminded %d members, want 100", len(sent))
+	}
+
+	posts := fake.sent("/channels/chan/messages")
+	if len(posts) < 3 {
+		t.Fatalf("100 pings went out in %d messages, want them split across several", len(posts))
+	}
+	// Every member is pinged in exactly one message, and only those in the failed one are undelivered
+	var contents []string
+	for n, p := range posts {
+		var msg struct {
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(p.Body, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if len(msg.Content) > discordMessageLimit || strings.Contains(msg.Content, " more") {
+			t.Fatalf("message %d is %d characters: %q", n, len(msg.Content), msg.Content)
+		}
+		contents = append(contents, msg.Content)
+	}
+	for _, r := range sent {
+		pings, failed := 0, false
+		for n, c := range contents {
+			if k := strings.Count(c, "<@"+r.DiscordID+">"); k > 0 {
+				pings += k
+				failed = failed || n == 1
+			}
+		}
+		if pings != 1 {
+			t.Fatalf("%s was pinged %d times", r.DiscordID, pings)
+		}
+		if r.Delivered == failed {
+			t.Fatalf("%s delivered = %v, but its message failed = %v", r.DiscordID, r.Delivered, failed)
+		}
+	}
+	if strings.Count(contents[1], "<@") == 0 {
+		t.Fatal("no pings were in the failed message")
+	}
+}
+
+// unloggedReminders is a Store that fails to log any reminder run that reached someone.
+type unloggedReminders struct {
+	storage.Store
+}
+
+func (u unloggedReminders) RecordReminderRun(ctx context.Context, guildID string, at time.Time, sent []storage.ReminderLog) error {
+	if len(sent) > 0 {
+		return errors.New("disk full")
+	}
+	return u.Store.RecordReminderRun(ctx, guildID, at, sent)
+}
+
+func TestRemindersNotResentWhenLogFails(t *testing.T) {
+	b, fake := newTestBot(t)
+	b.DB = unloggedReminders{b.DB}
+	ctx := t.Context()
+	if err := b.DB.UpsertMember(ctx, "g", "1", "Ragnar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DB.SaveGuildSettings(ctx, storage.GuildSettings{GuildID: "g", StaleAfterDays: 7, ReminderDays: "all"}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	b.remindDueGuilds(now)
+	if n := len(fake.sent("/messages")); n != 1 {
+		t.Fatalf("first check sent %d DMs, want 1", n)
+	}
+	// Logging who was reached failed, but the run was stamped, so the next check sends nothing
+	b.remindDueGuilds(now.Add(reminderCheckInterval))
+	if n := len(fake.sent("/messages")); n != 1 {
+		t.Fatalf("%d DMs after the next check, want the failed run not sent again", n)
+	}
+}
@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// resourceKeyPattern limits resource keys to short identifiers that work as export columns.
+var resourceKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// Limits of the /resourcetype min and max options. Amounts are stored as 32-bit integers, so
+// bounds stay within ±storage.DefaultBounds.Max and updates can never overflow the column.
+var (
+	resourceLimitMin = -float64(storage.DefaultBounds.Max)
+	resourceLimitMax = float64(storage.DefaultBounds.Max)
+)
+
+// resourceTypes returns the resource types a guild tracks, in display order. Guilds that never
+// used /resourcetype get the types from config.json.
+func (b *Bot) resourceTypes(ctx context.Context, guildID string) ([]storage.ResourceType, error) {
+	types, err := b.DB.ListResourceTypes(ctx, guildID)
+	if err != nil || len(types) > 0 {
+		return types, err
+	}
+	return b.Config.ResourceTypesFor(guildID), nil
+}
+
+// findResourceType matches a type by key, or by display name when typed by hand.
+func findResourceType(types []storage.ResourceType, input string) (storage.ResourceType, bool) {
+	input = strings.TrimSpace(input)
+	for _, t := range types {
+		if t.Key == input {
+			return t, true
+		}
+	}
+	for _, t := range types {
+		if strings.EqualFold(t.Name, input) || strings.EqualFold(t.Key, input) {
+			return t, true
+		}
+	}
+	return storage.ResourceType{}, false
+}
+
+// resourceLabel names a resource key for display, falling back to the key for types that
+// have since been removed.
+func resourceLabel(types []storage.ResourceType, key string) string {
+	if t, ok := findResourceType(types, key); ok {
+		return t.Label()
+	}
+	return key
+}
+
+// formatResources renders amounts as "📜 War Orders: 10, 🪵 Lumber: 2,500" in type order.
+func formatResources(types []storage.ResourceType, amounts map[string]int) string {
+	parts := make([]string, len(types))
+	for n, t := range types {
+		parts[n] = t.Label() + ": " + formatNumber(amounts[t.Key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resourceTypeChoices suggests the guild's resource types for the type option of /resource,
+// /resourcetype remove and the resource option of /history.
+func resourceTypeChoices(ctx context.Context, b *Bot, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	types, err := b.resourceTypes(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, t := range types {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(t.Name), typed) || strings.Contains(t.Key, typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: t.Label(), Value: t.Key})
+		}
+	}
+	return choices
+}
+
+// /resource set|add|subtract type amount [character]
+func handleResource(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	var key string
+	for _, o := range i.ApplicationCommandData().Options[0].Options {
+		if o.Name == "type" {
+			key = o.StringValue()
+		}
+	}
+	handleResourceUpdate(b, s, i, ctx, key)
+}
+
+// /resourcetype add|remove|list (leader only)
+func handleResourceType(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	types, err := b.resourceTypes(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load resource types: "+err.Error())
+		return
+	}
+	switch sub.Name {
+	case "add":
+		var t storage.ResourceType
+		var floor, ceiling *int
+		for _, o := range sub.Options {
+			switch o.Name {
+			case "key":
+				t.Key = strings.ToLower(strings.TrimSpace(o.StringValue()))
+			case "name":
+				t.Name = strings.TrimSpace(o.StringValue())
+			case "emoji":
+				t.Emoji = strings.TrimSpace(o.StringValue())
+			case "min":
+				v := int(o.IntValue())
+				floor = &v
+			case "max":
+				v := int(o.IntValue())
+				ceiling = &v
+			}
+		}
+		if !resourceKeyPattern.MatchString(t.Key) {
+			ephemeralErrorRespond(s, i, "Keys are up to 32 lowercase letters, digits or underscores, starting with a letter.")
+			return
+		}
+		t.GuildID, t.Bounds = i.GuildID, storage.DefaultBounds
+		update := false
+		for _, e := range types {
+			if e.Key == t.Key {
+				// Options left blank keep their current values
+				update, t.Bounds = true, e.Bounds
+				if t.Emoji == "" {
+					t.Emoji = e.Emoji
+				}
+			}
+		}
+		if floor != nil {
+			t.Min = *floor
+		}
+		if ceiling != nil {
+			t.Max = *ceiling
+		}
+		if float64(t.Min) < resourceLimitMin || float64(t.Max) > resourceLimitMax {
+			ephemeralErrorRespond(s, i, "min and max must be between "+formatNumber(int(resourceLimitMin))+" and "+formatNumber(int(resourceLimitMax))+".")
+			return
+		}
+		if t.Min > t.Max {
+			ephemeralErrorRespond(s, i, "min must not be greater than max.")
+			return
+		}
+		if err := b.DB.SeedResourceTypes(c, i.GuildID, types); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to save resource type: "+err.Error())
+			return
+		}
+		if err := b.DB.UpsertResourceType(c, t); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to save resource type: "+err.Error())
+			return
+		}
+		verb := "now tracked"
+		if update {
+			verb = "updated"
+		}
+		ephemeralOK(s, i, t.Label()+" ("+t.Key+") is "+verb+", allowed range "+formatNumber(t.Min)+"–"+formatNumber(t.Max)+".")
+	case "remove":
+		key := sub.Options[0].StringValue()
+		t, ok := findResourceType(types, key)
+		if !ok {
+			ephemeralErrorRespond(s, i, "This server doesn't track a resource called "+key+".")
+			return
+		}
+		if len(types) == 1 {
+			ephemeralErrorRespond(s, i, "A server must track at least one resource. Add another before removing "+t.Label()+".")
+			return
+		}
+		if err := b.DB.SeedResourceTypes(c, i.GuildID, types); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to remove resource type: "+err.Error())
+			return
+		}
+		switch err := b.DB.DeleteResourceType(c, i.GuildID, t.Key); {
+		case errors.Is(err, storage.ErrResourceTypeNotFound):
+			ephemeralErrorRespond(s, i, "This server doesn't track a resource called "+key+".")
+		case err != nil:
+			ephemeralErrorRespond(s, i, "Failed to remove resource type: "+err.Error())
+		default:
+			ephemeralOK(s, i, t.Label()+" is no longer tracked and its stored amounts were deleted. /history still shows past changes.")
+		}
+	case "list":
+		var lines []string
+		for _, t := range types {
+			lines = append(lines, t.Label()+" (`"+t.Key+"`) - "+formatNumber(t.Min)+"–"+formatNumber(t.Max))
+		}
+		desc := "(no resource types; add one with /resourcetype add)"
+		if len(lines) > 0 {
+			desc = joinLinesLimit(lines, embedDescriptionLimit)
+		}
+		embed := &discordgo.MessageEmbed{Title: "Tracked Resources", Description: desc, Color: 0x00CC66}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestResourceTypeCommands(t *testing.T) {
+	b, fake := newTestBot(t)
+	run := func(userID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		i := slashCommand("g", userID, name, options...)
+		if userID == "leader" {
+			i.Member.Permissions = discordgo.PermissionAdministrator
+		}
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t).Data.Content
+	}
+	addGold := func(options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		return run("leader", "resourcetype", subcommand("add", append([]*discordgo.ApplicationCommandInteractionDataOption{
+			stringOption("key", "Gold"), stringOption("name", "Gold"), stringOption("emoji", "🪙"),
+		}, options...)...))
+	}
+
+	if got := addGold(intOption("max", 2_000_000_000)); got != "min and max must be between -1,000,000,000 and 1,000,000,000." {
+		t.Fatalf("max past the storage limit = %q", got)
+	}
+	if got := addGold(intOption("min", 10), intOption("max", 5)); got != "min must not be greater than max." {
+		t.Fatalf("min above max = %q", got)
+	}
+	if got := addGold(intOption("min", -50), intOption("max", 500)); got != "🪙 Gold (gold) is now tracked, allowed range -50–500." {
+		t.Fatalf("/resourcetype add = %q", got)
+	}
+
+	run("u1", "register", stringOption("in-game-name", "Alpha"))
+	if got := run("u1", "resource", subcommand("subtract", stringOption("type", "gold"), intOption("amount", 40))); got != "Your Gold changed by -40 to -40." {
+		t.Fatalf("/resource subtract = %q", got)
+	}
+	if got := run("u1", "resource", subcommand("set", stringOption("type", "gold"), intOption("amount", 501))); got != "Gold must be between -50 and 500." {
+		t.Fatalf("/resource set past the max = %q", got)
+	}
+	members, err := b.DB.GetAllMembers(t.Context(), "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Resources["gold"] != -40 {
+		t.Fatalf("members = %+v", members)
+	}
+
+	if got := run("leader", "resourcetype", subcommand("remove", stringOption("key", "gold"))); got != "🪙 Gold is no longer tracked and its stored amounts were deleted. /history still shows past changes." {
+		t.Fatalf("/resourcetype remove = %q", got)
+	}
+	if got := run("u1", "resourcetype", subcommand("remove", stringOption("key", "orders"))); got != "You need the leader tier or higher to use /resourcetype." {
+		t.Fatalf("/resourcetype as a member = %q", got)
+	}
+}
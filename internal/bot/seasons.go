@@ -0,0 +1,389 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	maxScoreLength      = 30
+	maxWarNotesLength   = 200
+	maxSeasonNameLength = 60
+	// maxSeasonFields is Discord's limit on fields per embed, so /season list shows the latest 25.
+	maxSeasonFields = 25
+)
+
+// resultLabels names war results in messages.
+var resultLabels = map[string]string{
+	storage.ResultWin:  "🏆 Win",
+	storage.ResultLoss: "💀 Loss",
+	storage.ResultDraw: "🤝 Draw",
+}
+
+// warResultLine describes a completed war's outcome, e.g. "🏆 Win 3-1".
+func warResultLine(w storage.War) string {
+	line := resultLabels[w.Result]
+	if w.Score != "" {
+		line += " " + w.Score
+	}
+	return line
+}
+
+// warRecord tallies completed wars.
+type warRecord struct {
+	Wins, Losses, Draws int
+}
+
+func (r *warRecord) add(result string) {
+	switch result {
+	case storage.ResultWin:
+		r.Wins++
+	case storage.ResultLoss:
+		r.Losses++
+	case storage.ResultDraw:
+		r.Draws++
+	}
+}
+
+func (r warRecord) Played() int { return r.Wins + r.Losses + r.Draws }
+
+// String formats the record as wins-losses-draws with the win rate, e.g. "3W 1L 0D (75%)".
+func (r warRecord) String() string {
+	s := fmt.Sprintf("%dW %dL %dD", r.Wins, r.Losses, r.Draws)
+	if r.Played() > 0 {
+		s += fmt.Sprintf(" (%.0f%%)", float64(r.Wins)/float64(r.Played())*100)
+	}
+	return s
+}
+
+// opponentName is how history groups wars by opponent; wars without one share a bucket.
+func opponentName(w storage.War) string {
+	if w.Opponent == "" {
+		return "(no opponent)"
+	}
+	return w.Opponent
+}
+
+// opponentChoices suggests opponents from earlier wars, most recent first. What was typed comes
+// first when it is a new name, since the opponent option accepts any text.
+func opponentChoices(ctx context.Context, b *Bot, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	wars, err := b.DB.ListWars(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	typed = strings.TrimSpace(typed)
+	seen := make(map[string]bool)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, w := range wars {
+		key := strings.ToLower(w.Opponent)
+		if w.Opponent == "" || seen[key] || !strings.Contains(key, strings.ToLower(typed)) {
+			continue
+		}
+		seen[key] = true
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: w.Opponent, Value: w.Opponent})
+	}
+	if typed != "" && !seen[strings.ToLower(typed)] {
+		choices = append([]*discordgo.ApplicationCommandOptionChoice{{Name: typed, Value: typed}}, choices...)
+	}
+	return choices[:min(len(choices), maxAutocompleteChoices)]
+}
+
+// seasonChoices suggests a guild's seasons, latest first.
+func seasonChoices(ctx context.Context, b *Bot, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	seasons, err := b.DB.ListSeasons(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, se := range seasons {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		name := se.Name
+		if se.Active() {
+			name += " (active)"
+		}
+		if strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: strconv.FormatInt(se.ID, 10)})
+		}
+	}
+	return choices
+}
+
+// resolveSeason finds the season picked in a season option, by ID or name.
+func resolveSeason(seasons []storage.Season, opt string) (storage.Season, bool) {
+	opt = strings.TrimSpace(opt)
+	for _, se := range seasons {
+		if strconv.FormatInt(se.ID, 10) == opt || strings.EqualFold(se.Name, opt) {
+			return se, true
+		}
+	}
+	return storage.Season{}, false
+}
+
+// seasonPeriod formats when a season ran as Discord dates: "<start> - <end>", or "since <start>"
+// while it is active.
+func seasonPeriod(se storage.Season) string {
+	if se.Active() {
+		return "since " + discordTime(se.StartedAt, "D")
+	}
+	return discordTime(se.StartedAt, "D") + " - " + discordTime(se.EndedAt, "D")
+}
+
+// seasonRecord tallies the completed wars that started during se.
+func seasonRecord(se storage.Season, wars []storage.War) warRecord {
+	var r warRecord
+	for _, w := range wars {
+		if w.Result != "" && se.Contains(w.StartsAt) {
+			r.add(w.Result)
+		}
+	}
+	return r
+}
+
+// archiveTotals sums a season's archived resources over all members and characters, in
+// resource type order.
+func archiveTotals(archive []storage.ArchivedResource, types []storage.ResourceType) string {
+	totals := make(map[string]int)
+	for _, a := range archive {
+		totals[a.Resource] += a.Amount
+	}
+	var parts []string
+	for _, t := range types {
+		if n, ok := totals[t.Key]; ok {
+			parts = append(parts, t.Name+" "+formatNumber(n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// setWarResult answers /war result: it records the outcome, completes the war and updates its message.
+func setWarResult(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, w storage.War, opts map[string]string) {
+	if !requireTier(ctx, b, s, i, storage.TierOfficer, "/war result") {
+		return
+	}
+	result := opts["result"]
+	if _, ok := resultLabels[result]; !ok {
+		ephemeralErrorRespond(s, i, "Result must be win, loss or draw.")
+		return
+	}
+	if w.StartsAt.After(time.Now()) {
+		ephemeralErrorRespond(s, i, warTitle(w)+" has not started yet. It starts "+discordTimestamp(w.StartsAt)+".")
+		return
+	}
+	score, notes := strings.TrimSpace(opts["score"]), strings.TrimSpace(opts["notes"])
+	if len([]rune(score)) > maxScoreLength || len([]rune(notes)) > maxWarNotesLength {
+		ephemeralErrorRespond(s, i, fmt.Sprintf("Keep the score within %d characters and notes within %d.", maxScoreLength, maxWarNotesLength))
+		return
+	}
+	if err := b.DB.SetWarResult(ctx, i.GuildID, w.ID, result, score, notes); err != nil {
+		ephemeralErrorRespond(s, i, "Failed to record result: "+err.Error())
+		return
+	}
+	w.Result, w.Score, w.Notes, w.Status = result, score, notes, storage.WarComplete
+	msg := "Recorded " + warResultLine(w) + " for " + warTitle(w) + "."
+	if err := refreshWarMessage(ctx, b, s, w); err != nil {
+		msg += " The signup message could not be updated: " + err.Error()
+	}
+	if summary, err := settleWarOrders(ctx, b, w, i.Member.User.ID); err != nil {
+		msg += " Failed to deduct War Orders: " + err.Error()
+	} else if summary != "" {
+		msg += " " + summary
+	}
+	ephemeralOK(s, i, msg)
+}
+
+// warHistoryRespond answers /war history [opponent] [season] with the guild's record and its
+// completed wars, latest first.
+func warHistoryRespond(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, opts map[string]string) {
+	wars, err := b.DB.ListWars(ctx, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load wars: "+err.Error())
+		return
+	}
+	title := "War History"
+	opponent := strings.TrimSpace(opts["opponent"])
+	if opponent != "" {
+		title += " vs " + opponent
+	}
+	var season *storage.Season
+	if opts["season"] != "" {
+		seasons, err := b.DB.ListSeasons(ctx, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load seasons: "+err.Error())
+			return
+		}
+		se, ok := resolveSeason(seasons, opts["season"])
+		if !ok {
+			ephemeralErrorRespond(s, i, "Season not found. See /season list.")
+			return
+		}
+		season = &se
+		title += " - " + se.Name
+	}
+	var total warRecord
+	records := make(map[string]*warRecord)
+	var order, lines []string
+	for _, w := range wars {
+		if w.Result == "" || (opponent != "" && !strings.EqualFold(w.Opponent, opponent)) || (season != nil && !season.Contains(w.StartsAt)) {
+			continue
+		}
+		total.add(w.Result)
+		name := opponentName(w)
+		key := strings.ToLower(name)
+		if records[key] == nil {
+			records[key] = &warRecord{}
+			order = append(order, name)
+		}
+		records[key].add(w.Result)
+		line := "**" + warTitle(w) + "** " + discordTime(w.StartsAt, "d") + " - " + warResultLine(w)
+		if w.Notes != "" {
+			line += " - " + w.Notes
+		}
+		lines = append(lines, line)
+	}
+	if total.Played() == 0 {
+		ephemeralErrorRespond(s, i, "No war results match. Record them with /war result.")
+		return
+	}
+	desc := "Record: **" + total.String() + "**\n\n"
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: desc + joinLinesLimit(lines, embedDescriptionLimit-len(desc)),
+		Color:       0xE67E22,
+	}
+	if opponent == "" {
+		sort.SliceStable(order, func(a, b int) bool {
+			return records[strings.ToLower(order[a])].Played() > records[strings.ToLower(order[b])].Played()
+		})
+		var opponents []string
+		for _, name := range order {
+			opponents = append(opponents, name+": "+records[strings.ToLower(name)].String())
+		}
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "Opponents", Value: joinLinesLimit(opponents, 1024)}}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
+	})
+}
+
+// /season start|end (leader) and /season list (officer or above)
+func handleSeason(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	switch sub.Name {
+	case "start":
+		if !requireTier(c, b, s, i, storage.TierLeader, "/season start") {
+			return
+		}
+		var name string
+		for _, o := range sub.Options {
+			if o.Name == "name" {
+				name = strings.TrimSpace(o.StringValue())
+			}
+		}
+		if name == "" || len([]rune(name)) > maxSeasonNameLength {
+			ephemeralErrorRespond(s, i, fmt.Sprintf("Season names must be 1-%d characters.", maxSeasonNameLength))
+			return
+		}
+		se, err := b.DB.StartSeason(c, i.GuildID, name)
+		if errors.Is(err, storage.ErrSeasonActive) {
+			ephemeralErrorRespond(s, i, "A season is already running. End it with /season end first.")
+			return
+		}
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to start season: "+err.Error())
+			return
+		}
+		ephemeralOK(s, i, "Started season **"+se.Name+"**. Wars from now on count towards it until /season end.")
+	case "end":
+		if !requireTier(c, b, s, i, storage.TierLeader, "/season end") {
+			return
+		}
+		se, err := b.DB.EndSeason(c, i.GuildID)
+		if errors.Is(err, storage.ErrNoActiveSeason) {
+			ephemeralErrorRespond(s, i, "No season is running. Start one with /season start.")
+			return
+		}
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to end season: "+err.Error())
+			return
+		}
+		wars, err := b.DB.ListWars(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Ended the season but failed to load its wars: "+err.Error())
+			return
+		}
+		archive, err := b.DB.GetSeasonArchive(c, i.GuildID, se.ID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Ended the season but failed to load its archive: "+err.Error())
+			return
+		}
+		types, _ := b.resourceTypes(c, i.GuildID)
+		embed := &discordgo.MessageEmbed{
+			Title:       "Season ended: " + se.Name,
+			Description: seasonPeriod(se) + "\nRecord: **" + seasonRecord(se, wars).String() + "**",
+			Color:       0xE67E22,
+			Footer:      &discordgo.MessageEmbedFooter{Text: "Every member's resources were archived. Compare seasons with /season list."},
+		}
+		if totals := archiveTotals(archive, types); totals != "" {
+			embed.Description += "\nResources: " + totals
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
+		})
+	case "list":
+		seasons, err := b.DB.ListSeasons(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load seasons: "+err.Error())
+			return
+		}
+		if len(seasons) == 0 {
+			ephemeralErrorRespond(s, i, "No seasons yet. A leader can start one with /season start.")
+			return
+		}
+		wars, err := b.DB.ListWars(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load wars: "+err.Error())
+			return
+		}
+		types, _ := b.resourceTypes(c, i.GuildID)
+		embed := &discordgo.MessageEmbed{
+			Title:  "Seasons",
+			Color:  0xE67E22,
+			Footer: &discordgo.MessageEmbedFooter{Text: "Resources are the guild's totals archived when each season ended."},
+		}
+		for _, se := range seasons[:min(len(seasons), maxSeasonFields)] {
+			name, value := se.Name, seasonPeriod(se)+"\nRecord: "+seasonRecord(se, wars).String()
+			if se.Active() {
+				name += " (active)"
+			} else {
+				archive, err := b.DB.GetSeasonArchive(c, i.GuildID, se.ID)
+				if err != nil {
+					ephemeralErrorRespond(s, i, "Failed to load the archive of "+se.Name+": "+err.Error())
+					return
+				}
+				if totals := archiveTotals(archive, types); totals != "" {
+					value += "\nResources: " + totals
+				}
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: name, Value: value})
+		}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}},
+		})
+	}
+}
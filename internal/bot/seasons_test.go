@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestSeasonRecord(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	se := storage.Season{ID: 1, Name: "Spring", StartedAt: start, EndedAt: start.AddDate(0, 3, 0)}
+	wars := []storage.War{
+		{StartsAt: start, Result: storage.ResultWin},
+		{StartsAt: start.AddDate(0, 1, 0), Result: storage.ResultWin},
+		{StartsAt: start.AddDate(0, 2, 0), Result: storage.ResultLoss},
+		{StartsAt: start.AddDate(0, 2, 1)}, // no result yet
+		{StartsAt: start.Add(-time.Hour), Result: storage.ResultLoss},
+		{StartsAt: se.EndedAt, Result: storage.ResultDraw}, // ended exactly then
+	}
+	if got := seasonRecord(se, wars).String(); got != "2W 1L 0D (67%)" {
+		t.Fatalf("season record = %q", got)
+	}
+	se.EndedAt = time.Time{}
+	if got := seasonRecord(se, wars).String(); got != "2W 1L 1D (50%)" {
+		t.Fatalf("active season record = %q", got)
+	}
+	if got := (warRecord{}).String(); got != "0W 0L 0D" {
+		t.Fatalf("empty record = %q", got)
+	}
+}
+
+func TestResolveSeasonAndArchiveTotals(t *testing.T) {
+	seasons := []storage.Season{{ID: 2, Name: "Summer"}, {ID: 1, Name: "Spring"}}
+	for opt, want := range map[string]int64{"1": 1, " summer ": 2, "Autumn": 0} {
+		se, ok := resolveSeason(seasons, opt)
+		if ok != (want != 0) || se.ID != want {
+			t.Errorf("resolveSeason(%q) = %d, %v, want %d", opt, se.ID, ok, want)
+		}
+	}
+
+	archive := []storage.ArchivedResource{
+		{Resource: storage.ResourceLumber, Amount: 5},
+		{Resource: storage.ResourceOrders, Amount: 1000},
+		{Resource: storage.ResourceOrders, Amount: 500},
+		{Resource: "removed", Amount: 9},
+	}
+	if got := archiveTotals(archive, storage.DefaultResourceTypes("g")); got != "War Orders 1,500, Lumber 5" {
+		t.Fatalf("archive totals = %q", got)
+	}
+}
+
+func TestSeasonCommands(t *testing.T) {
+	b, fake := newTestBot(t)
+	run := func(options ...*discordgo.ApplicationCommandInteractionDataOption) discordgo.InteractionResponse {
+		i := slashCommand("g", "leader", "season", options...)
+		i.Member.Permissions = discordgo.PermissionAdministrator
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t)
+	}
+	if got := run(subcommand("end")).Data.Content; got != "No season is running. Start one with /season start." {
+		t.Fatalf("/season end without a season = %q", got)
+	}
+	if got := run(subcommand("start", stringOption("name", " Spring "))).Data.Content; got != "Started season **Spring**. Wars from now on count towards it until /season end." {
+		t.Fatalf("/season start = %q", got)
+	}
+	if got := run(subcommand("start", stringOption("name", "Summer"))).Data.Content; !strings.HasPrefix(got, "A season is already running.") {
+		t.Fatalf("second /season start = %q", got)
+	}
+
+	handleSlashCommand(b, b.Session, slashCommand("g", "u1", "register", stringOption("in-game-name", "Alpha")))
+	if err := b.DB.SetResource(t.Context(), "g", "u1", "", "u1", storage.ResourceOrders, 1200); err != nil {
+		t.Fatal(err)
+	}
+	ended := run(subcommand("end"))
+	if len(ended.Data.Embeds) != 1 || ended.Data.Embeds[0].Title != "Season ended: Spring" || !strings.HasSuffix(ended.Data.Embeds[0].Description, "\nResources: War Orders 1,200") {
+		t.Fatalf("/season end = %+v", ended.Data.Embeds[0])
+	}
+	list := run(subcommand("list"))
+	if fields := list.Data.Embeds[0].Fields; len(fields) != 1 || fields[0].Name != "Spring" || !strings.Contains(fields[0].Value, "Record: 0W 0L 0D") {
+		t.Fatalf("/season list fields = %+v", fields)
+	}
+}
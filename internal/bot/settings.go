@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+const (
+	// maxAvailabilitySlots leaves room for Not Available in the 25-option /availability menu.
+	maxAvailabilitySlots = 24
+	// maxSlotLabelLength keeps labels, with local times appended, under Discord's 100-character option limit.
+	maxSlotLabelLength = 60
+)
+
+// parseClock reads a UTC time of day such as "18:00" or "6:30" as minutes after midnight.
+// "24:00" is accepted as midnight, for slots ending at the end of the day.
+func parseClock(v string) (int, bool) {
+	v = strings.TrimSpace(v)
+	if v == "24:00" {
+		return 0, true
+	}
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// availabilitySlotChoices suggests the guild's slots for /settings availability remove and /war create.
+func availabilitySlotChoices(ctx context.Context, b *Bot, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	slots, err := b.availabilitySlots(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, sl := range slots {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(sl.Label), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: sl.Label, Value: sl.Label})
+		}
+	}
+	return choices
+}
+
+// /settings availability add|remove|list, /settings wars orders and /settings reminders set|off|status (leader only)
+func handleSettings(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	group := i.ApplicationCommandData().Options[0]
+	switch group.Name {
+	case "availability":
+		handleAvailabilitySettings(b, s, i, ctx, group.Options[0])
+	case "wars":
+		handleWarSettings(b, s, i, ctx, group.Options[0])
+	case "reminders":
+		handleReminderSettings(b, s, i, ctx, group.Options[0])
+	}
+}
+
+// handleWarSettings shows or changes the War Orders deducted when a war is completed.
+func handleWarSettings(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	if sub.Name != "orders" {
+		return
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	settings, err := b.DB.GetGuildSettings(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load settings: "+err.Error())
+		return
+	}
+	types, _ := b.resourceTypes(c, i.GuildID)
+	orders, tracked := findResourceType(types, storage.ResourceOrders)
+	if len(sub.Options) == 0 {
+		switch {
+		case settings.WarOrdersCost == 0:
+			ephemeralOK(s, i, "Completing a war does not deduct any orders. Set an amount with /settings wars orders amount.")
+		case !tracked:
+			ephemeralOK(s, i, fmt.Sprintf("Completing a war deducts %d War Orders per member present, but this server no longer tracks War Orders, so nothing is deducted.", settings.WarOrdersCost))
+		default:
+			ephemeralOK(s, i, fmt.Sprintf("Completing a war deducts %s %s from the main character of each member marked present.", formatNumber(settings.WarOrdersCost), orders.Label()))
+		}
+		return
+	}
+	amount := int(sub.Options[0].IntValue())
+	if amount > 0 && !tracked {
+		ephemeralErrorRespond(s, i, "This server does not track War Orders. Add a resource type with the key orders first.")
+		return
+	}
+	if amount < 0 || (tracked && amount > orders.Max) {
+		ephemeralErrorRespond(s, i, "The amount must be between 0 and "+formatNumber(orders.Max)+".")
+		return
+	}
+	settings.WarOrdersCost = amount
+	if err := b.DB.SaveGuildSettings(c, settings); err != nil {
+		ephemeralErrorRespond(s, i, "Failed to save settings: "+err.Error())
+		return
+	}
+	if amount == 0 {
+		ephemeralOK(s, i, "Completing a war no longer deducts orders. Deductions already made stay in /history.")
+		return
+	}
+	ephemeralOK(s, i, fmt.Sprintf("From now on, /war result deducts %s %s from the main character of each member marked present in /war attendance. Deductions show in /history, and are returned if a member is later marked absent or excused.", formatNumber(amount), orders.Label()))
+}
+
+func handleAvailabilitySettings(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, sub *discordgo.ApplicationCommandInteractionDataOption) {
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	slots, err := b.availabilitySlots(c, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+		return
+	}
+	switch sub.Name {
+	case "add":
+		var sl storage.AvailabilitySlot
+		var okStart, okEnd bool
+		for _, o := range sub.Options {
+			switch o.Name {
+			case "start":
+				sl.Start, okStart = parseClock(o.StringValue())
+			case "end":
+				sl.End, okEnd = parseClock(o.StringValue())
+			case "label":
+				sl.Label = strings.Join(strings.Fields(o.StringValue()), " ")
+			}
+		}
+		if !okStart || !okEnd {
+			ephemeralErrorRespond(s, i, "Times must be UTC in 24-hour HH:MM form, e.g. 18:00 or 24:00.")
+			return
+		}
+		if sl.Start == sl.End {
+			ephemeralErrorRespond(s, i, "start and end must differ.")
+			return
+		}
+		if sl.Label == "" {
+			sl.Label = slotTimes(sl)
+		}
+		switch {
+		case len(sl.Label) > maxSlotLabelLength:
+			ephemeralErrorRespond(s, i, "Labels are at most 60 characters.")
+			return
+		case strings.ContainsAny(sl.Label, ",;"):
+			ephemeralErrorRespond(s, i, "Labels cannot contain commas or semicolons; exports use them to separate slots and days.")
+			return
+		case strings.EqualFold(sl.Label, notAvailableSlot) || strings.EqualFold(sl.Label, "Not Set"):
+			ephemeralErrorRespond(s, i, sl.Label+" is reserved.")
+			return
+		case len(slots) >= maxAvailabilitySlots:
+			ephemeralErrorRespond(s, i, "A server can offer at most 24 slots. Remove one first.")
+			return
+		}
+		if err := b.DB.SeedAvailabilitySlots(c, i.GuildID, slots); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to add slot: "+err.Error())
+			return
+		}
+		switch err := b.DB.AddAvailabilitySlot(c, i.GuildID, sl); {
+		case errors.Is(err, storage.ErrAvailabilitySlotExists):
+			ephemeralErrorRespond(s, i, "There is already a slot called "+sl.Label+". Remove it first to change its times.")
+		case err != nil:
+			ephemeralErrorRespond(s, i, "Failed to add slot: "+err.Error())
+		default:
+			ephemeralOK(s, i, "Members can now pick "+sl.Label+" ("+slotTimes(sl)+") in /availability.")
+		}
+	case "remove":
+		label := sub.Options[0].StringValue()
+		if _, ok := findSlot(slots, label); !ok {
+			ephemeralErrorRespond(s, i, "This server has no slot called "+label+".")
+			return
+		}
+		if len(slots) == 1 {
+			ephemeralErrorRespond(s, i, "A server must offer at least one slot. Add another before removing "+label+".")
+			return
+		}
+		if err := b.DB.SeedAvailabilitySlots(c, i.GuildID, slots); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to remove slot: "+err.Error())
+			return
+		}
+		affected, err := b.DB.DeleteAvailabilitySlot(c, i.GuildID, label)
+		switch {
+		case errors.Is(err, storage.ErrAvailabilitySlotNotFound):
+			ephemeralErrorRespond(s, i, "This server has no slot called "+label+".")
+			return
+		case err != nil:
+			ephemeralErrorRespond(s, i, "Failed to remove slot: "+err.Error())
+			return
+		}
+		if len(affected) == 0 {
+			ephemeralOK(s, i, label+" was removed. No member had picked it.")
+			return
+		}
+		ephemeralOK(s, i, label+" was removed from "+formatNumber(len(affected))+" member schedules; days left empty are now Not Set. Notifying them by DM.")
+		notifyRemovedSlot(s, i.GuildID, label, affected)
+	case "list":
+		lines := make([]string, len(slots))
+		for n, sl := range slots {
+			lines[n] = sl.Label
+			if sl.Label != slotTimes(sl) {
+				lines[n] += " - " + slotTimes(sl)
+			}
+		}
+		embed := &discordgo.MessageEmbed{Title: "Availability Slots", Description: joinLinesLimit(lines, embedDescriptionLimit), Color: 0x00CC66}
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral},
+		})
+	}
+}
+
+// notifyRemovedSlot DMs members whose schedule lost a removed slot. Members with DMs closed are
+// logged and skipped.
+func notifyRemovedSlot(s *discordgo.Session, guildID, label string, discordIDs []string) {
+	server := "this server"
+	if g, err := s.State.Guild(guildID); err == nil && g.Name != "" {
+		server = g.Name
+	}
+	msg := "The availability slot " + label + " was removed in " + server + ", so it is no longer part of your schedule. Days that had no other slot are now Not Set. Use /availability there to pick new slots."
+	for _, id := range discordIDs {
+		ch, err := s.UserChannelCreate(id)
+		if err == nil {
+			_, err = s.ChannelMessageSend(ch.ID, msg)
+		}
+		if err != nil {
+			log.Printf("WARN: notify %s of removed availability slot: %v", id, err)
+		}
+	}
+}
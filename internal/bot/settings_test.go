@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestParseClock(t *testing.T) {
+	for in, want := range map[string]int{"18:00": 1080, " 6:30 ": 390, "00:00": 0, "24:00": 0} {
+		if got, ok := parseClock(in); !ok || got != want {
+			t.Errorf("parseClock(%q) = %d, %v, want %d", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"25:00", "6pm", "18:60", ""} {
+		if _, ok := parseClock(in); ok {
+			t.Errorf("parseClock(%q) accepted", in)
+		}
+	}
+}
+
+func TestAvailabilitySettings(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	run := func(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		i := slashCommand("g", "leader", "settings", subcommand("availability", subcommand(name, options...)))
+		i.Member.Permissions = discordgo.PermissionAdministrator
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t).Data.Content
+	}
+
+	if got := run("add", stringOption("start", "6pm"), stringOption("end", "20:00")); !strings.HasPrefix(got, "Times must be UTC") {
+		t.Fatalf("add with a 12-hour time = %q", got)
+	}
+	if got := run("add", stringOption("start", "12:00"), stringOption("end", "14:00"), stringOption("label", "Lunch, late")); !strings.HasPrefix(got, "Labels cannot contain commas") {
+		t.Fatalf("add with a comma = %q", got)
+	}
+	if got := run("add", stringOption("start", "12:00"), stringOption("end", "14:00"), stringOption("label", "  Raid   prime ")); got != "Members can now pick Raid prime (12:00-14:00 GMT) in /availability." {
+		t.Fatalf("add = %q", got)
+	}
+	// The first change stores the defaults alongside the new slot
+	slots, err := b.DB.ListAvailabilitySlots(ctx, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != len(storage.DefaultAvailabilitySlots())+1 || slots[0].Label != "Raid prime" {
+		t.Fatalf("slots = %+v", slots)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		if err := b.DB.UpsertMember(ctx, "g", id, "member-"+id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.DB.SetAvailability(ctx, "g", "1", storage.Schedule{time.Monday: {"Raid prime"}, time.Tuesday: {"Raid prime", "18:00-20:00 GMT"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := run("remove", stringOption("label", "Nope")); got != "This server has no slot called Nope." {
+		t.Fatalf("remove unknown = %q", got)
+	}
+	if got := run("remove", stringOption("label", "Raid prime")); !strings.HasPrefix(got, "Raid prime was removed from 1 member schedules") {
+		t.Fatalf("remove = %q", got)
+	}
+	if dms := fake.sent("/channels/1/messages"); len(dms) != 1 || !strings.Contains(string(dms[0].Body), "The availability slot Raid prime was removed") {
+		t.Fatalf("DMs = %d, want one to the affected member", len(dms))
+	}
+	members, err := b.DB.GetAllMembers(ctx, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := members[0].Availability.String(); got != "Tue: 18:00-20:00 GMT" {
+		t.Fatalf("schedule after removing the slot = %q", got)
+	}
+	if got := run("remove", stringOption("label", "18:00-20:00 GMT")); got != "18:00-20:00 GMT was removed from 1 member schedules; days left empty are now Not Set. Notifying them by DM." {
+		t.Fatalf("second remove = %q", got)
+	}
+}
+
+func TestWarOrdersSettlement(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	run := func(options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		i := slashCommand("g", "leader", "settings", subcommand("wars", subcommand("orders", options...)))
+		i.Member.Permissions = discordgo.PermissionAdministrator
+		handleSlashCommand(b, b.Session, i)
+		return fake.lastResponse(t).Data.Content
+	}
+	if got := run(); !strings.HasPrefix(got, "Completing a war does not deduct any orders.") {
+		t.Fatalf("/settings wars orders before setting it = %q", got)
+	}
+	if got := run(intOption("amount", -1)); !strings.HasPrefix(got, "The amount must be between 0 and ") {
+		t.Fatalf("negative amount = %q", got)
+	}
+	if got := run(intOption("amount", 5)); !strings.HasPrefix(got, "From now on, /war result deducts 5 ") {
+		t.Fatalf("/settings wars orders amount:5 = %q", got)
+	}
+
+	for id, orders := range map[string]int{"1": 12, "2": 3, "3": 0} {
+		if err := b.DB.UpsertMember(ctx, "g", id, "member-"+id); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.DB.SetResource(ctx, "g", id, "", id, storage.ResourceOrders, orders); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := storage.War{GuildID: "g", StartsAt: time.Now().Add(-time.Hour), Status: storage.WarOpen}
+	var err error
+	if w.ID, err = b.DB.CreateWar(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.DB.SetAttendance(ctx, "g", w.ID, "officer", map[string]string{"1": storage.AttendancePresent, "2": storage.AttendancePresent, "3": storage.AttendancePresent}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is settled before the war has a result
+	if got, err := settleWarOrders(ctx, b, w, "officer"); err != nil || got != "" {
+		t.Fatalf("settling an open war = %q, %v", got, err)
+	}
+	if err := b.DB.SetWarResult(ctx, "g", w.ID, storage.ResultWin, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Member 2 only has 3 to lose and member 3 is already at the minimum
+	got, err := settleWarOrders(ctx, b, w, "officer")
+	if err != nil || !strings.HasPrefix(got, "Deducted 8 ") || !strings.Contains(got, " from 2 present.") {
+		t.Fatalf("settle = %q, %v", got, err)
+	}
+	if err := b.DB.SetAttendance(ctx, "g", w.ID, "officer", map[string]string{"2": storage.AttendanceExcused}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := settleWarOrders(ctx, b, w, "officer"); err != nil || !strings.Contains(got, " to 1 no longer present.") {
+		t.Fatalf("settle after excusing member 2 = %q, %v", got, err)
+	}
+	members, err := b.DB.GetAllMembers(ctx, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range members {
+		if want := map[string]int{"1": 7, "2": 3, "3": 0}[m.DiscordID]; m.Resources[storage.ResourceOrders] != want {
+			t.Errorf("member %s has %d orders, want %d", m.DiscordID, m.Resources[storage.ResourceOrders], want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // zone data for hosts without a zoneinfo database
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// commonTimeZones seed /timezone autocomplete; any IANA zone name is accepted.
+var commonTimeZones = []string{
+	"UTC",
+	"Europe/London", "Europe/Lisbon", "Europe/Paris", "Europe/Berlin", "Europe/Madrid", "Europe/Rome",
+	"Europe/Amsterdam", "Europe/Warsaw", "Europe/Athens", "Europe/Helsinki", "Europe/Istanbul", "Europe/Moscow",
+	"America/New_York", "America/Chicago", "America/Denver", "America/Phoenix", "America/Los_Angeles",
+	"America/Anchorage", "America/Toronto", "America/Vancouver", "America/Mexico_City", "America/Bogota",
+	"America/Lima", "America/Santiago", "America/Sao_Paulo", "America/Argentina/Buenos_Aires",
+	"Africa/Lagos", "Africa/Cairo", "Africa/Johannesburg", "Africa/Nairobi",
+	"Asia/Dubai", "Asia/Karachi", "Asia/Kolkata", "Asia/Dhaka", "Asia/Bangkok", "Asia/Jakarta",
+	"Asia/Singapore", "Asia/Manila", "Asia/Shanghai", "Asia/Hong_Kong", "Asia/Taipei", "Asia/Seoul",
+	"Asia/Tokyo", "Australia/Perth", "Australia/Adelaide", "Australia/Brisbane", "Australia/Sydney",
+	"Pacific/Auckland", "Pacific/Honolulu",
+}
+
+// loadZone looks up an IANA zone name. time.LoadLocation reads $ZONEINFO or the host's zoneinfo
+// database first and only falls back to the embedded tzdata when neither has the zone.
+func loadZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, errors.New("not an IANA time zone")
+	}
+	return time.LoadLocation(name)
+}
+
+// memberLocation returns the member's time zone, or UTC if unset or no longer valid.
+func memberLocation(m *storage.Member) *time.Location {
+	if m == nil || m.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := loadZone(m.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// timeZoneChoices suggests zones containing typed; a valid zone typed in full is offered first.
+func timeZoneChoices(typed string) []*discordgo.ApplicationCommandOptionChoice {
+	typed = strings.TrimSpace(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if _, err := loadZone(typed); err == nil && !slices.Contains(commonTimeZones, typed) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: typed, Value: typed})
+	}
+	query := strings.ToLower(strings.ReplaceAll(typed, " ", "_"))
+	for _, z := range commonTimeZones {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		if strings.Contains(strings.ToLower(z), query) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: z, Value: z})
+		}
+	}
+	return choices
+}
+
+// /timezone [zone] shows or sets the caller's time zone
+func handleTimeZone(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	var zone string
+	for _, o := range i.ApplicationCommandData().Options {
+		if o.Name == "zone" {
+			zone = strings.TrimSpace(o.StringValue())
+		}
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	mem, err := findMember(c, b, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+		return
+	}
+	if mem == nil {
+		ephemeralErrorRespond(s, i, "You must /register first.")
+		return
+	}
+	if zone == "" {
+		current := mem.TimeZone
+		if current == "" {
+			current = "UTC (not set)"
+		}
+		ephemeralOK(s, i, "Your time zone is "+current+". Set it with /timezone zone:Europe/Berlin (any IANA zone name).")
+		return
+	}
+	loc, err := loadZone(zone)
+	if err != nil {
+		ephemeralErrorRespond(s, i, zone+" is not a known time zone. Use an IANA name such as America/New_York or Asia/Kolkata.")
+		return
+	}
+	if err := b.DB.SetTimeZone(c, i.GuildID, i.Member.User.ID, loc.String()); err != nil {
+		ephemeralErrorRespond(s, i, "Failed to set time zone: "+err.Error())
+		return
+	}
+	ephemeralOK(s, i, "Your time zone is now "+loc.String()+" (local time "+time.Now().In(loc).Format("Mon 15:04 MST")+"). /availability now shows slots in this zone.")
+}
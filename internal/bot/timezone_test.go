@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestLoadZone(t *testing.T) {
+	if loc, err := loadZone("Asia/Kolkata"); err != nil || loc.String() != "Asia/Kolkata" {
+		t.Fatalf("loadZone(Asia/Kolkata) = %v, %v", loc, err)
+	}
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons"} {
+		if _, err := loadZone(name); err == nil {
+			t.Errorf("loadZone(%q) accepted", name)
+		}
+	}
+	if loc := memberLocation(&storage.Member{TimeZone: "Mars/Olympus_Mons"}); loc != time.UTC {
+		t.Errorf("a stored zone that no longer loads = %v, want UTC", loc)
+	}
+}
+
+func TestTimeZoneChoices(t *testing.T) {
+	choices := timeZoneChoices("new york")
+	if len(choices) != 1 || choices[0].Value != "America/New_York" {
+		t.Fatalf("choices for \"new york\" = %+v", choices)
+	}
+	// A valid zone missing from the common list is offered as typed
+	if choices := timeZoneChoices("Asia/Kathmandu"); len(choices) == 0 || choices[0].Value != "Asia/Kathmandu" {
+		t.Fatalf("choices for a full zone name = %+v", choices)
+	}
+}
+
+func TestScheduleByUTCWeekday(t *testing.T) {
+	kolkata, err := loadZone("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slots := storage.DefaultAvailabilitySlots()
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	// In UTC+5:30, the 22:00 GMT slot starts at 03:30 local, so Monday's picks fall on Sunday in UTC
+	local := storage.Schedule{time.Monday: {"16:00-18:00 GMT", "22:00-00:00 GMT"}, time.Friday: {notAvailableSlot}}
+	stored := utcSchedule(local, kolkata, slots, now)
+	if got := stored.String(); got != "Mon: 16:00-18:00 GMT; Fri: Not Available; Sun: 22:00-00:00 GMT" {
+		t.Fatalf("stored schedule = %q", got)
+	}
+	if got := localSchedule(stored, kolkata, slots, now); !sameSlots(got[time.Monday], local[time.Monday]) || len(got[time.Sunday]) != 0 {
+		t.Fatalf("local schedule = %v, want %v back", got, local)
+	}
+	if got := displaySchedule(stored, kolkata, slots, now).String(); got != "Mon: 03:30-05:30 IST, 21:30-23:30 IST; Fri: Not Available" {
+		t.Fatalf("displayed schedule = %q", got)
+	}
+	// Members in UTC see the stored labels
+	if got := displaySchedule(stored, time.UTC, slots, now).String(); got != "Mon: 16:00-18:00 GMT; Fri: Not Available; Sun: 22:00-00:00 GMT" {
+		t.Fatalf("schedule in UTC = %q", got)
+	}
+
+	monday := nextDay(now, kolkata, time.Monday)
+	lines := availabilityDayLines([]storage.Member{{InGameName: "Alpha", Availability: stored}}, monday, slots)
+	start := time.Date(2024, 1, 14, 22, 0, 0, 0, time.UTC)
+	if want := "Alpha - " + discordTime(start, "t") + "-" + discordTime(start.Add(2*time.Hour), "t") + ", "; len(lines) != 1 || !strings.HasPrefix(lines[0], want) {
+		t.Fatalf("day lines = %q, want them to start with %q", lines, want)
+	}
+}
+
+func TestTimeZoneCommand(t *testing.T) {
+	b, fake := newTestBot(t)
+	run := func(options ...*discordgo.ApplicationCommandInteractionDataOption) string {
+		handleSlashCommand(b, b.Session, slashCommand("g", "u1", "timezone", options...))
+		return fake.lastResponse(t).Data.Content
+	}
+	if got := run(); got != "You must /register first." {
+		t.Fatalf("/timezone before registering = %q", got)
+	}
+	handleSlashCommand(b, b.Session, slashCommand("g", "u1", "register", stringOption("in-game-name", "Alpha")))
+	if got := run(stringOption("zone", "Mars/Olympus_Mons")); !strings.HasPrefix(got, "Mars/Olympus_Mons is not a known time zone.") {
+		t.Fatalf("/timezone with an unknown zone = %q", got)
+	}
+	if got := run(stringOption("zone", " Asia/Kolkata ")); !strings.HasPrefix(got, "Your time zone is now Asia/Kolkata (local time ") {
+		t.Fatalf("/timezone zone:Asia/Kolkata = %q", got)
+	}
+	if got := run(); !strings.HasPrefix(got, "Your time zone is Asia/Kolkata.") {
+		t.Fatalf("/timezone = %q", got)
+	}
+}
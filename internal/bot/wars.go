@@ -0,0 +1,446 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+// warSignupPrefix starts the custom id of a war message button: war_signup:<war id>:<response>.
+const warSignupPrefix = "war_signup:"
+
+// signupLabels names the responses in war messages, in display order.
+var signupLabels = []struct{ response, label string }{
+	{storage.SignupJoin, "✅ Joining"},
+	{storage.SignupMaybe, "❔ Maybe"},
+	{storage.SignupDecline, "❌ Declined"},
+}
+
+// parseWarTime reads the time option of /war create in the creator's zone: "2026-10-24 20:00",
+// "sat 20:00", "20:00" (the next time that clock time comes round), or a date or weekday alone
+// when slot is set, meaning the slot's start on that day.
+func parseWarTime(v string, loc *time.Location, now time.Time, slot *storage.AvailabilitySlot) (time.Time, error) {
+	fields := strings.Fields(v)
+	if len(fields) == 0 || len(fields) > 2 {
+		return time.Time{}, errors.New("use a date or weekday and a time, e.g. 2026-10-24 20:00 or sat 20:00")
+	}
+	if len(fields) == 1 {
+		if clock, ok := parseClock(fields[0]); ok {
+			t := atClock(nextDay(now, loc, now.In(loc).Weekday()), clock)
+			if !t.After(now) {
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+	}
+	date, dateErr := time.ParseInLocation("2006-01-02", fields[0], loc)
+	day, dayOK := storage.ParseWeekday(fields[0])
+	if dateErr != nil && !dayOK {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYY-MM-DD) or weekday", fields[0])
+	}
+	if len(fields) == 1 {
+		if slot == nil {
+			return time.Time{}, errors.New("add a time, e.g. " + fields[0] + " 20:00, or pick a slot")
+		}
+		if dateErr == nil {
+			utc := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+			return utc.Add(time.Duration(slot.Start) * time.Minute), nil
+		}
+		return nextSlotStart(now, day, *slot), nil
+	}
+	clock, ok := parseClock(fields[1])
+	if !ok {
+		return time.Time{}, fmt.Errorf("%q is not a 24-hour HH:MM time", fields[1])
+	}
+	if dateErr == nil {
+		return atClock(date, clock), nil
+	}
+	t := atClock(nextDay(now, loc, day), clock)
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+	return t, nil
+}
+
+// atClock returns the given minutes after midnight on day's date, in day's location. Unlike
+// adding a duration to midnight it keeps the wall clock time across daylight saving changes.
+func atClock(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location())
+}
+
+// slotAt finds the availability slot starting at t, so wars created for a slot's start time
+// count the members available then.
+func slotAt(slots []storage.AvailabilitySlot, t time.Time) (storage.AvailabilitySlot, bool) {
+	u := t.UTC()
+	for _, sl := range slots {
+		if sl.Start == u.Hour()*60+u.Minute() {
+			return sl, true
+		}
+	}
+	return storage.AvailabilitySlot{}, false
+}
+
+// warTitle names a war, e.g. "War #3 vs Iron Wolves".
+func warTitle(w storage.War) string {
+	title := "War #" + strconv.FormatInt(w.ID, 10)
+	if w.Opponent != "" {
+		title += " vs " + w.Opponent
+	}
+	return title
+}
+
+// warEmbed renders the public signup message of a war.
+func warEmbed(w storage.War, signups []storage.WarSignup) *discordgo.MessageEmbed {
+	desc := "Starts " + discordTimestamp(w.StartsAt)
+	if w.Slot != "" {
+		desc += "\nSlot: " + storage.DayName(w.StartsAt.UTC().Weekday()) + " " + w.Slot
+	}
+	footer := "Sign up with the buttons below. Your choice can be changed until the lineup is locked."
+	color := 0xE67E22
+	switch w.Status {
+	case storage.WarLocked:
+		footer, color = "The lineup is locked.", 0x992D22
+	case storage.WarComplete:
+		footer, color = "This war is over.", 0x607D8B
+		desc += "\nResult: **" + warResultLine(w) + "**"
+		if w.Notes != "" {
+			desc += "\n" + w.Notes
+		}
+	}
+	embed := &discordgo.MessageEmbed{Title: warTitle(w), Description: desc, Color: color, Footer: &discordgo.MessageEmbedFooter{Text: footer}}
+	for _, sl := range signupLabels {
+		var mentions []string
+		for _, s := range signups {
+			if s.Response == sl.response {
+				mentions = append(mentions, "<@"+s.DiscordID+">")
+			}
+		}
+		value := "-"
+		if len(mentions) > 0 {
+			value = joinNamesLimit(mentions, 1024)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: sl.label + " (" + strconv.Itoa(len(mentions)) + ")", Value: value, Inline: true})
+	}
+	return embed
+}
+
+// warComponents returns the signup buttons of a war message, disabled once the lineup is locked.
+func warComponents(w storage.War) []discordgo.MessageComponent {
+	locked := w.Status != storage.WarOpen
+	id := warSignupPrefix + strconv.FormatInt(w.ID, 10) + ":"
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{Label: "Join", Style: discordgo.SuccessButton, CustomID: id + storage.SignupJoin, Disabled: locked},
+			discordgo.Button{Label: "Maybe", Style: discordgo.SecondaryButton, CustomID: id + storage.SignupMaybe, Disabled: locked},
+			discordgo.Button{Label: "Decline", Style: discordgo.DangerButton, CustomID: id + storage.SignupDecline, Disabled: locked},
+		}},
+	}
+}
+
+// warChoices suggests a guild's wars, latest first, for the war option of /war.
+func warChoices(ctx context.Context, b *Bot, guildID, typed string) []*discordgo.ApplicationCommandOptionChoice {
+	wars, err := b.DB.ListWars(ctx, guildID)
+	if err != nil {
+		return nil
+	}
+	typed = strings.ToLower(typed)
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, w := range wars {
+		if len(choices) >= maxAutocompleteChoices {
+			break
+		}
+		name := warTitle(w) + " - " + w.StartsAt.UTC().Format("Mon 2 Jan 15:04 MST") + " (" + w.Status + ")"
+		if strings.Contains(strings.ToLower(name), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: strconv.FormatInt(w.ID, 10)})
+		}
+	}
+	return choices
+}
+
+// resolveWar loads the war picked in a war option, defaulting to the next war to start, or the
+// latest one if none is upcoming.
+func resolveWar(ctx context.Context, b *Bot, guildID, opt string) (storage.War, error) {
+	if opt != "" {
+		id, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(opt), "#"), 10, 64)
+		if err != nil {
+			return storage.War{}, storage.ErrWarNotFound
+		}
+		return b.DB.GetWar(ctx, guildID, id)
+	}
+	wars, err := b.DB.ListWars(ctx, guildID)
+	if err != nil {
+		return storage.War{}, err
+	}
+	if len(wars) == 0 {
+		return storage.War{}, storage.ErrWarNotFound
+	}
+	now, next := time.Now(), wars[0]
+	for _, w := range wars {
+		if w.StartsAt.After(now) {
+			next = w
+		}
+	}
+	return next, nil
+}
+
+// refreshWarMessage re-renders a war's public message after its status changed.
+func refreshWarMessage(ctx context.Context, b *Bot, s *discordgo.Session, w storage.War) error {
+	if w.ChannelID == "" || w.MessageID == "" {
+		return nil
+	}
+	signups, err := b.DB.GetSignups(ctx, w.GuildID, w.ID)
+	if err != nil {
+		return err
+	}
+	components := warComponents(w)
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    w.ChannelID,
+		ID:         w.MessageID,
+		Embeds:     &[]*discordgo.MessageEmbed{warEmbed(w, signups)},
+		Components: &components,
+	})
+	return err
+}
+
+// /war create|lineup|lock|attendance|result|history; all but lineup and history need officer or above
+func handleWar(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context) {
+	sub := i.ApplicationCommandData().Options[0]
+	opts := make(map[string]string)
+	for _, o := range sub.Options {
+		opts[o.Name] = o.StringValue()
+	}
+	c, cancel := storage.WithTimeout(ctx)
+	defer cancel()
+	switch sub.Name {
+	case "create":
+		if !requireTier(c, b, s, i, storage.TierOfficer, "/war create") {
+			return
+		}
+		mem, err := findMember(c, b, i.GuildID, i.Member.User.ID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+			return
+		}
+		slots, err := b.availabilitySlots(c, i.GuildID)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load availability slots: "+err.Error())
+			return
+		}
+		var slot *storage.AvailabilitySlot
+		if label := opts["slot"]; label != "" {
+			sl, ok := findSlot(slots, label)
+			if !ok {
+				ephemeralErrorRespond(s, i, "This server has no slot called "+label+". See /settings availability list.")
+				return
+			}
+			slot = &sl
+		}
+		loc, now := memberLocation(mem), time.Now()
+		start, err := parseWarTime(opts["time"], loc, now, slot)
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Invalid time: "+err.Error()+". Times are in your time zone, "+loc.String()+".")
+			return
+		}
+		if !start.After(now) {
+			ephemeralErrorRespond(s, i, "That time has already passed: "+discordTimestamp(start)+".")
+			return
+		}
+		w := storage.War{GuildID: i.GuildID, StartsAt: start, Opponent: strings.TrimSpace(opts["opponent"]), Status: storage.WarOpen, CreatedBy: i.Member.User.ID}
+		if slot != nil {
+			w.Slot = slot.Label
+		} else if sl, ok := slotAt(slots, start); ok {
+			w.Slot = sl.Label
+		}
+		if w.ID, err = b.DB.CreateWar(c, w); err != nil {
+			ephemeralErrorRespond(s, i, "Failed to create war: "+err.Error())
+			return
+		}
+		w.StartsAt = w.StartsAt.Truncate(time.Second)
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{warEmbed(w, nil)}, Components: warComponents(w)},
+		}); err != nil {
+			return
+		}
+		msg, err := s.InteractionResponse(i.Interaction)
+		if err != nil {
+			log.Printf("WARN: look up message of war %d: %v", w.ID, err)
+			return
+		}
+		// The Discord calls above can use up the storage timeout, so start a fresh one
+		c, cancel = storage.WithTimeout(ctx)
+		defer cancel()
+		if err := b.DB.SetWarMessage(c, i.GuildID, w.ID, msg.ChannelID, msg.ID); err != nil {
+			log.Printf("WARN: store message of war %d: %v", w.ID, err)
+		}
+	case "history":
+		warHistoryRespond(b, s, i, c, opts)
+	case "lineup", "lock", "attendance", "result":
+		w, err := resolveWar(c, b, i.GuildID, opts["war"])
+		if errors.Is(err, storage.ErrWarNotFound) {
+			ephemeralErrorRespond(s, i, "War not found. Create one with /war create.")
+			return
+		}
+		if err != nil {
+			ephemeralErrorRespond(s, i, "Failed to load war: "+err.Error())
+			return
+		}
+		switch sub.Name {
+		case "lock":
+			lockWar(b, s, i, c, w)
+		case "attendance":
+			warAttendanceRespond(b, s, i, c, w)
+		case "result":
+			setWarResult(b, s, i, c, w, opts)
+		default:
+			warLineupRespond(b, s, i, c, w)
+		}
+	}
+}
+
+// lockWar freezes a war's signups and disables the buttons on its message.
+func lockWar(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, w storage.War) {
+	if !requireTier(ctx, b, s, i, storage.TierOfficer, "/war lock") {
+		return
+	}
+	if w.Status != storage.WarOpen {
+		ephemeralErrorRespond(s, i, "The lineup for "+warTitle(w)+" is already locked.")
+		return
+	}
+	if err := b.DB.SetWarStatus(ctx, i.GuildID, w.ID, storage.WarLocked); err != nil {
+		ephemeralErrorRespond(s, i, "Failed to lock war: "+err.Error())
+		return
+	}
+	w.Status = storage.WarLocked
+	msg := "Locked the lineup for " + warTitle(w) + ". /war lineup shows who is in."
+	if err := refreshWarMessage(ctx, b, s, w); err != nil {
+		msg += " The signup message could not be updated: " + err.Error()
+	}
+	ephemeralOK(s, i, msg)
+}
+
+// warLineupRespond lists who joined a war with their War Orders across main and alts.
+func warLineupRespond(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, ctx context.Context, w storage.War) {
+	signups, err := b.DB.GetSignups(ctx, i.GuildID, w.ID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load signups: "+err.Error())
+		return
+	}
+	members, err := b.DB.GetAllMembers(ctx, i.GuildID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load roster: "+err.Error())
+		return
+	}
+	chars, err := b.DB.GetCharacters(ctx, i.GuildID, "")
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load characters: "+err.Error())
+		return
+	}
+	types, _ := b.resourceTypes(ctx, i.GuildID)
+	byID := make(map[string]storage.Member, len(members))
+	for _, m := range members {
+		byID[m.DiscordID] = m
+	}
+	orders := make(map[string]int)
+	for _, ch := range chars {
+		orders[ch.DiscordID] += ch.Resources[storage.ResourceOrders]
+	}
+	var lines, maybe []string
+	total := 0
+	for _, su := range signups {
+		m, ok := byID[su.DiscordID]
+		name := "<@" + su.DiscordID + ">"
+		switch su.Response {
+		case storage.SignupJoin:
+			if !ok {
+				lines = append(lines, strconv.Itoa(len(lines)+1)+". "+name+" (not on the roster)")
+				continue
+			}
+			n := m.Resources[storage.ResourceOrders] + orders[su.DiscordID]
+			total += n
+			lines = append(lines, strconv.Itoa(len(lines)+1)+". "+name+" "+m.InGameName+" - "+formatNumber(n))
+		case storage.SignupMaybe:
+			if ok {
+				name += " " + m.InGameName
+			}
+			maybe = append(maybe, name)
+		}
+	}
+	desc := "Starts " + discordTimestamp(w.StartsAt) + "\n\n"
+	if len(lines) == 0 {
+		lines = []string{"(nobody has joined yet)"}
+	}
+	desc += joinLinesLimit(lines, embedDescriptionLimit-len(desc))
+	status := "Signups open"
+	if w.Status != storage.WarOpen {
+		status = "Lineup locked"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "Lineup: " + warTitle(w),
+		Description: desc,
+		Color:       0xE67E22,
+		Footer:      &discordgo.MessageEmbedFooter{Text: status + " - total " + resourceLabel(types, storage.ResourceOrders) + ": " + formatNumber(total)},
+	}
+	if len(maybe) > 0 {
+		embed.Fields = []*discordgo.MessageEmbedField{{Name: "❔ Maybe (" + strconv.Itoa(len(maybe)) + ")", Value: joinNamesLimit(maybe, 1024)}}
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{embed}, Flags: discordgo.MessageFlagsEphemeral},
+	})
+}
+
+// handleWarSignup stores a Join/Maybe/Decline click and refreshes the war message.
+func handleWarSignup(b *Bot, s *discordgo.Session, i *discordgo.InteractionCreate, customID string) {
+	idPart, response, _ := strings.Cut(strings.TrimPrefix(customID, warSignupPrefix), ":")
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || (response != storage.SignupJoin && response != storage.SignupMaybe && response != storage.SignupDecline) {
+		return
+	}
+	ctx, cancel := storage.WithTimeout(context.Background())
+	defer cancel()
+	if !requireTier(ctx, b, s, i, storage.TierMember, "war signups") {
+		return
+	}
+	mem, err := findMember(ctx, b, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to look up member: "+err.Error())
+		return
+	}
+	if mem == nil {
+		ephemeralErrorRespond(s, i, "You must /register before signing up for wars.")
+		return
+	}
+	switch err := b.DB.SetSignup(ctx, i.GuildID, id, i.Member.User.ID, response); {
+	case errors.Is(err, storage.ErrWarLocked):
+		ephemeralErrorRespond(s, i, "The lineup for this war is locked. Ask an officer if you need to change it.")
+		return
+	case errors.Is(err, storage.ErrWarNotFound):
+		ephemeralErrorRespond(s, i, "This war no longer exists.")
+		return
+	case err != nil:
+		ephemeralErrorRespond(s, i, "Failed to sign up: "+err.Error())
+		return
+	}
+	w, err := b.DB.GetWar(ctx, i.GuildID, id)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load war: "+err.Error())
+		return
+	}
+	signups, err := b.DB.GetSignups(ctx, i.GuildID, id)
+	if err != nil {
+		ephemeralErrorRespond(s, i, "Failed to load signups: "+err.Error())
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{Embeds: []*discordgo.MessageEmbed{warEmbed(w, signups)}, Components: warComponents(w)},
+	})
+}
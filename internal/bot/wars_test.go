@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/divijg19/Wartracker/internal/storage"
+)
+
+func TestParseWarTime(t *testing.T) {
+	berlin, err := loadZone("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) // Wednesday, 14:00 in Berlin
+	slot := &storage.AvailabilitySlot{Label: "18:00-20:00 GMT", Start: 18 * 60, End: 20 * 60}
+	utc := func(day, hour int) time.Time { return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC) }
+	for _, c := range []struct {
+		in   string
+		slot *storage.AvailabilitySlot
+		want time.Time
+	}{
+		{"2026-10-24 20:00", nil, utc(24, 18)},
+		{"2026-10-25 20:00", nil, utc(25, 19)}, // daylight saving time has ended
+		{"sat 20:00", nil, utc(17, 18)},
+		{"Wednesday 13:00", nil, utc(21, 11)}, // passed today, so next week
+		{"15:00", nil, utc(14, 13)},
+		{"13:00", nil, utc(15, 11)}, // passed today, so tomorrow
+		{"2026-10-24", slot, utc(24, 18)},
+		{"fri", slot, utc(16, 18)},
+	} {
+		got, err := parseWarTime(c.in, berlin, now, c.slot)
+		if err != nil || !got.Equal(c.want) {
+			t.Errorf("parseWarTime(%q) = %v, %v, want %v", c.in, got.UTC(), err, c.want)
+		}
+	}
+	for _, in := range []string{"", "sat 20:00 CET", "someday 20:00", "sat 8pm", "sat"} {
+		if _, err := parseWarTime(in, berlin, now, nil); err == nil {
+			t.Errorf("parseWarTime(%q) accepted", in)
+		}
+	}
+}
+
+func TestJoinNamesLimit(t *testing.T) {
+	if got := joinNamesLimit([]string{"a", "b"}, 1024); got != "a, b" {
+		t.Fatalf("short list = %q", got)
+	}
+	var mentions []string
+	for n := range 100 {
+		mentions = append(mentions, "<@"+strconv.Itoa(100000000000000000+n)+">")
+	}
+	got := joinNamesLimit(mentions, 1024)
+	shown := strings.Count(got, "<@")
+	if len(got) > 1024 || shown < 40 || !strings.HasSuffix(got, ", … and "+strconv.Itoa(100-shown)+" more") {
+		t.Fatalf("long list shows %d names in %d bytes: %q", shown, len(got), got)
+	}
+}
+
+// signupClick builds the interaction Discord sends when userID presses a war signup button.
+func signupClick(userID, warID, response string) *discordgo.InteractionCreate {
+	return importClick(userID, warSignupPrefix+warID+":"+response)
+}
+
+func TestWarCreateAndSignup(t *testing.T) {
+	b, fake := newTestBot(t)
+	ctx := t.Context()
+	create := slashCommand("g", "officer", "war", subcommand("create", stringOption("time", "2099-01-02 20:00"), stringOption("opponent", " Rivals ")))
+	create.Member.Permissions = discordgo.PermissionAdministrator
+	handleSlashCommand(b, b.Session, create)
+	wars, err := b.DB.ListWars(ctx, "g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wars) != 1 || wars[0].Opponent != "Rivals" || wars[0].Slot != "20:00-22:00 GMT" || wars[0].MessageID != "1" {
+		t.Fatalf("wars = %+v, want the war in the 20:00 slot with its message stored", wars)
+	}
+	id := strconv.FormatInt(wars[0].ID, 10)
+
+	for n := range 60 {
+		userID := strconv.Itoa(100000000000000000 + n)
+		if err := b.DB.UpsertMember(ctx, "g", userID, "member-"+userID); err != nil {
+			t.Fatal(err)
+		}
+		handleComponentInteraction(b, b.Session, signupClick(userID, id, storage.SignupJoin))
+	}
+	signups, err := b.DB.GetSignups(ctx, "g", wars[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signups) != 60 {
+		t.Fatalf("%d signups, want 60", len(signups))
+	}
+	sent := fake.sent("/callback")
+	var resp struct {
+		Data struct {
+			Embeds []discordgo.MessageEmbed `json:"embeds"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(sent[len(sent)-1].Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	joining := resp.Data.Embeds[0].Fields[0]
+	if joining.Name != "✅ Joining (60)" || len(joining.Value) > 1024 || !strings.HasSuffix(joining.Value, " more") || strings.Count(joining.Value, "<@") < 40 {
+		t.Fatalf("Joining field = %q: %q", joining.Name, joining.Value)
+	}
+
+	if err := b.DB.SetWarStatus(ctx, "g", wars[0].ID, storage.WarLocked); err != nil {
+		t.Fatal(err)
+	}
+	handleComponentInteraction(b, b.Session, signupClick("100000000000000000", id, storage.SignupDecline))
+	if got := fake.lastResponse(t).Data.Content; !strings.HasPrefix(got, "The lineup for this war is locked.") {
+		t.Fatalf("signup after locking = %q", got)
+	}
+}
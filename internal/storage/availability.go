@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Week lists the weekdays in display order, Monday first.
+var Week = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// DayName returns the three-letter name of a weekday, e.g. "Mon".
+func DayName(d time.Weekday) string { return d.String()[:3] }
+
+// ParseWeekday accepts a full or three-letter weekday name in any case.
+func ParseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, d := range Week {
+		if s == strings.ToLower(d.String()) || s == strings.ToLower(DayName(d)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// String renders the schedule as "Mon, Tue: 18:00-20:00 GMT; Sat: 16:00-18:00 GMT, 20:00-22:00 GMT",
+// grouping days with the same slots. ParseSchedule reads it back. An empty schedule is "Not Set".
+func (s Schedule) String() string {
+	var groups []string
+	done := make(map[time.Weekday]bool)
+	for _, d := range Week {
+		if done[d] || len(s[d]) == 0 {
+			continue
+		}
+		days := []string{DayName(d)}
+		for _, o := range Week {
+			if o != d && !done[o] && slices.Equal(s[o], s[d]) {
+				days = append(days, DayName(o))
+				done[o] = true
+			}
+		}
+		groups = append(groups, strings.Join(days, ", ")+": "+strings.Join(s[d], ", "))
+	}
+	if len(groups) == 0 {
+		return "Not Set"
+	}
+	return strings.Join(groups, "; ")
+}
+
+// ParseSchedule reads the format written by Schedule.String. A value without day names, such as
+// a single slot from an export made before availability was per weekday, applies to every day.
+// "Not Set" or an empty value is an empty schedule. Slot names are not validated.
+func ParseSchedule(v string) (Schedule, error) {
+	s := make(Schedule)
+	v = strings.TrimSpace(v)
+	if v == "" || v == "Not Set" {
+		return s, nil
+	}
+	for _, group := range strings.Split(v, ";") {
+		days, slots := Week, group
+		if prefix, rest, ok := strings.Cut(group, ": "); ok {
+			var parsed []time.Weekday
+			for _, name := range strings.Split(prefix, ",") {
+				d, ok := ParseWeekday(name)
+				if !ok {
+					parsed = nil
+					break
+				}
+				parsed = append(parsed, d)
+			}
+			if parsed != nil {
+				days, slots = parsed, rest
+			}
+		}
+		var list []string
+		for _, slot := range strings.Split(slots, ",") {
+			if slot = strings.TrimSpace(slot); slot != "" {
+				list = append(list, slot)
+			}
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("no slots in %q", strings.TrimSpace(group))
+		}
+		for _, d := range days {
+			s[d] = list
+		}
+	}
+	return s, nil
+}
+
+// loadAvailability reads the weekly schedules of every member in a guild, keyed by Discord ID.
+// Callers hold d.mu.
+func (d *DB) loadAvailability(ctx context.Context, guildID string) (map[string]Schedule, error) {
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT discord_id, weekday, slot FROM member_availability WHERE guild_id=? ORDER BY discord_id, weekday, slot`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]Schedule)
+	for rows.Next() {
+		var discordID, slot string
+		var day int
+		if err := rows.Scan(&discordID, &day, &slot); err != nil {
+			return nil, err
+		}
+		if out[discordID] == nil {
+			out[discordID] = make(Schedule)
+		}
+		out[discordID][time.Weekday(day)] = append(out[discordID][time.Weekday(day)], slot)
+	}
+	return out, rows.Err()
+}
+
+// SetAvailability replaces the member's whole weekly schedule. Days missing from sched are cleared.
+func (d *DB) SetAvailability(ctx context.Context, guildID, discordID string, sched Schedule) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	now := time.Now().Unix()
+	res, err := tx.ExecContext(ctx, d.q(`UPDATE members SET updated_at=?, availability_updated_at=? WHERE guild_id=? AND discord_id=?`), now, now, guildID, discordID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMemberNotRegistered
+	}
+	for _, day := range Week {
+		if err := d.setAvailabilityTx(ctx, tx, guildID, discordID, day, sched[day]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetTimeZone stores the member's IANA time zone name; callers validate it.
+func (d *DB) SetTimeZone(ctx context.Context, guildID, discordID, zone string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.conn.ExecContext(ctx, d.q(`UPDATE members SET time_zone=? WHERE guild_id=? AND discord_id=?`), zone, guildID, discordID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMemberNotRegistered
+	}
+	return nil
+}
+
+// setAvailabilityTx replaces one day's slots inside tx.
+func (d *DB) setAvailabilityTx(ctx context.Context, tx *sql.Tx, guildID, discordID string, day time.Weekday, slots []string) error {
+	if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM member_availability WHERE guild_id=? AND discord_id=? AND weekday=?`), guildID, discordID, int(day)); err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO member_availability(guild_id, discord_id, weekday, slot) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`),
+			guildID, discordID, int(day), slot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListAvailabilitySlots returns a guild's stored slots ordered by start time. It is empty for
+// guilds that never changed their slots.
+func (d *DB) ListAvailabilitySlots(ctx context.Context, guildID string) ([]AvailabilitySlot, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT label, start_minute, end_minute FROM availability_slots
+		WHERE guild_id=? ORDER BY start_minute, label`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []AvailabilitySlot
+	for rows.Next() {
+		var sl AvailabilitySlot
+		if err := rows.Scan(&sl.Label, &sl.Start, &sl.End); err != nil {
+			return nil, err
+		}
+		list = append(list, sl)
+	}
+	return list, rows.Err()
+}
+
+// AddAvailabilitySlot stores a new slot for a guild.
+func (d *DB) AddAvailabilitySlot(ctx context.Context, guildID string, slot AvailabilitySlot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO availability_slots(guild_id, label, start_minute, end_minute) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`),
+		guildID, slot.Label, slot.Start, slot.End)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAvailabilitySlotExists
+	}
+	return nil
+}
+
+// DeleteAvailabilitySlot removes a slot and takes it out of every member's schedule. It returns
+// the Discord IDs of the members who had picked it; days left without slots become Not Set.
+func (d *DB) DeleteAvailabilitySlot(ctx context.Context, guildID, label string) ([]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	res, err := tx.ExecContext(ctx, d.q(`DELETE FROM availability_slots WHERE guild_id=? AND label=?`), guildID, label)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrAvailabilitySlotNotFound
+	}
+	rows, err := tx.QueryContext(ctx, d.q(`SELECT DISTINCT discord_id FROM member_availability WHERE guild_id=? AND slot=? ORDER BY discord_id`), guildID, label)
+	if err != nil {
+		return nil, err
+	}
+	var affected []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		affected = append(affected, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM member_availability WHERE guild_id=? AND slot=?`), guildID, label); err != nil {
+		return nil, err
+	}
+	return affected, tx.Commit()
+}
+
+// SeedAvailabilitySlots stores slots for a guild that has none yet.
+func (d *DB) SeedAvailabilitySlots(ctx context.Context, guildID string, slots []AvailabilitySlot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM availability_slots WHERE guild_id=?`), guildID).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	for _, sl := range slots {
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO availability_slots(guild_id, label, start_minute, end_minute) VALUES(?,?,?,?)`),
+			guildID, sl.Label, sl.Start, sl.End); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
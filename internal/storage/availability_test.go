@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleStringRoundTrip(t *testing.T) {
+	s := Schedule{
+		time.Monday:   {"18:00-20:00 GMT"},
+		time.Tuesday:  {"18:00-20:00 GMT"},
+		time.Saturday: {"16:00-18:00 GMT", "20:00-22:00 GMT"},
+		time.Sunday:   {"18:00-20:00 GMT"},
+	}
+	want := "Mon, Tue, Sun: 18:00-20:00 GMT; Sat: 16:00-18:00 GMT, 20:00-22:00 GMT"
+	if got := s.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+	back, err := ParseSchedule(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := back.String(); got != want {
+		t.Fatalf("ParseSchedule(String()) = %q", got)
+	}
+	if got := (Schedule{}).String(); got != "Not Set" {
+		t.Fatalf("empty schedule = %q", got)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, c := range []struct{ in, want string }{
+		{"", "Not Set"},
+		{"Not Set", "Not Set"},
+		// A single slot from before availability was per weekday applies to every day
+		{"18:00-20:00 GMT", "Mon, Tue, Wed, Thu, Fri, Sat, Sun: 18:00-20:00 GMT"},
+		{"monday: a; SAT, sunday: b, c", "Mon: a; Sat, Sun: b, c"},
+		// A prefix that is not a day list is part of the slot name
+		{"late: night", "Mon, Tue, Wed, Thu, Fri, Sat, Sun: late: night"},
+	} {
+		s, err := ParseSchedule(c.in)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", c.in, err)
+			continue
+		}
+		if got := s.String(); got != c.want {
+			t.Errorf("ParseSchedule(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	if _, err := ParseSchedule("Mon: ,"); err == nil {
+		t.Error("a day with no slots was accepted")
+	}
+}
+
+func TestParseWeekday(t *testing.T) {
+	for in, want := range map[string]time.Weekday{"mon": time.Monday, " Sunday ": time.Sunday, "THU": time.Thursday} {
+		if d, ok := ParseWeekday(in); !ok || d != want {
+			t.Errorf("ParseWeekday(%q) = %v, %v, want %v", in, d, ok, want)
+		}
+	}
+	if _, ok := ParseWeekday("funday"); ok {
+		t.Error("ParseWeekday accepted funday")
+	}
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backuper is implemented by stores that can take consistent online copies of themselves.
+type Backuper interface {
+	BackupTo(ctx context.Context, destPath string) error
+}
+
+// ErrBackupUnsupported is returned when the backend has no built-in backup (e.g. PostgreSQL; use pg_dump).
+var ErrBackupUnsupported = errors.New("built-in backups are only supported for SQLite")
+
+var _ Backuper = (*DB)(nil)
+
+// backupNamePattern matches files written by BackupFileName and by the old deploy/backup_runner.sh.
+var backupNamePattern = regexp.MustCompile(`^guild_data\.(\d{8}T\d{6}Z)\.db$`)
+
+const backupTimeLayout = "20060102T150405Z"
+
+// BackupFileName returns the file name for a backup taken at t.
+func BackupFileName(t time.Time) string {
+	return "guild_data." + t.UTC().Format(backupTimeLayout) + ".db"
+}
+
+// BackupTo copies the live database to destPath with the SQLite online backup API, then verifies
+// the copy with PRAGMA integrity_check. The copy is written to a temp file and only renamed into
+// place once verified, so destPath never holds a partial backup.
+func (d *DB) BackupTo(ctx context.Context, destPath string) error {
+	if d.dialect != dialectSQLite {
+		return ErrBackupUnsupported
+	}
+	tmp := destPath + ".tmp"
+	_ = os.Remove(tmp)
+	if err := d.backupSQLite(ctx, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := VerifyBackup(ctx, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, destPath)
+}
+
+func (d *DB) backupSQLite(ctx context.Context, destPath string) error {
+	// Every write method takes d.mu exclusively, so the read lock holds off this process's writes
+	// until the copy is done. Other processes are not held off; the backup API copies everything
+	// in one step, which gives a consistent snapshot either way. Readers wait for the single
+	// pooled connection, which the backup holds.
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return copySQLite(ctx, d.conn, destPath, true)
+}
+
+// copySQLite runs the online backup API between live and the database file at path.
+// toFile copies live into path; otherwise path is copied into live.
+func copySQLite(ctx context.Context, live *sql.DB, path string, toFile bool) error {
+	file, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fileConn, err := file.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer fileConn.Close()
+	liveConn, err := live.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer liveConn.Close()
+
+	src, dst := liveConn, fileConn
+	if !toFile {
+		src, dst = fileConn, liveConn
+	}
+	return dst.Raw(func(dc any) error {
+		return src.Raw(func(sc any) error {
+			dconn, ok1 := dc.(*sqlite3.SQLiteConn)
+			sconn, ok2 := sc.(*sqlite3.SQLiteConn)
+			if !ok1 || !ok2 {
+				return errors.New("backup: unexpected driver connection type")
+			}
+			b, err := dconn.Backup("main", sconn, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := b.Step(-1); err != nil {
+				_ = b.Finish()
+				return err
+			}
+			return b.Finish()
+		})
+	})
+}
+
+// RestoreFrom replaces the live database contents with a verified backup using the online
+// backup API in reverse, then migrates the restored schema up to this binary's version.
+// Callers must make sure no other instance is serving from this database.
+func (d *DB) RestoreFrom(ctx context.Context, srcPath string) error {
+	if d.dialect != dialectSQLite {
+		return ErrBackupUnsupported
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return err
+	}
+	if err := VerifyBackup(ctx, srcPath); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err := copySQLite(ctx, d.conn, srcPath, false); err != nil {
+		return err
+	}
+	return migrate(d.conn, sqliteMigrationSet, d.env)
+}
+
+// VerifyBackup runs PRAGMA integrity_check against a backup file.
+func VerifyBackup(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite3", path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// PruneBackups deletes backups in dir beyond the retention policy: the newest backup of each of the
+// last keepDaily days and of each of the last keepWeekly ISO weeks are kept. It returns removed paths.
+func PruneBackups(dir string, keepDaily, keepWeekly int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type backupFile struct {
+		path string
+		at   time.Time
+	}
+	var files []backupFile
+	for _, e := range entries {
+		m := backupNamePattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		at, err := time.Parse(backupTimeLayout, m[1])
+		if err != nil {
+			continue
+		}
+		files = append(files, backupFile{path: filepath.Join(dir, e.Name()), at: at})
+	}
+	sort.Slice(files, func(a, b int) bool { return files[a].at.After(files[b].at) })
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	var removed []string
+	for _, f := range files {
+		keep := false
+		day := f.at.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep = true
+		}
+		y, w := f.at.ISOWeek()
+		week := fmt.Sprintf("%d-W%02d", y, w)
+		if !weeks[week] && len(weeks) < keepWeekly {
+			weeks[week] = true
+			keep = true
+		}
+		if keep {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, f.path)
+	}
+	return removed, nil
+}
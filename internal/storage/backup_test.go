@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBackupTo(t *testing.T) {
+	ctx := t.Context()
+	db := openTestSQLite(t).(*DB)
+	defer db.Close()
+	must(t, db.UpsertMember(ctx, "g1", "1", "alpha"))
+
+	dest := filepath.Join(t.TempDir(), BackupFileName(time.Now()))
+	must(t, db.BackupTo(ctx, dest))
+	if _, err := os.Stat(dest + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temp file left behind: %v", err)
+	}
+	must(t, VerifyBackup(ctx, dest))
+	copied, err := NewConnection(dest, "")
+	must(t, err)
+	defer copied.Close()
+	if m, ok := findMember(t, copied, "g1", "1"); !ok || m.InGameName != "alpha" {
+		t.Fatalf("member in backup = %+v, %v", m, ok)
+	}
+}
+
+func TestVerifyBackupRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "garbage.db")
+	must(t, os.WriteFile(path, []byte("not a database, just some bytes that fill a page"), 0o644))
+	if err := VerifyBackup(t.Context(), path); err == nil {
+		t.Fatal("VerifyBackup accepted a file that is not a database")
+	}
+}
+
+func TestPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"guild_data.20231228T120000Z.db", // ISO week 2023-W52
+		"guild_data.20240101T100000Z.db", // 2024-W01
+		"guild_data.20240101T120000Z.db",
+		"guild_data.20240102T120000Z.db",
+		"guild_data.20240103T120000Z.db",
+		"guild_data.20240110T120000Z.db", // 2024-W02
+		"notes.txt",
+	}
+	for _, n := range names {
+		must(t, os.WriteFile(filepath.Join(dir, n), nil, 0o644))
+	}
+	// Two days (10th, 3rd) and three weeks (W02, W01 via the 3rd, 2023-W52) are kept
+	removed, err := PruneBackups(dir, 2, 3)
+	must(t, err)
+	var got []string
+	for _, p := range removed {
+		got = append(got, filepath.Base(p))
+	}
+	slices.Sort(got)
+	want := []string{
+		"guild_data.20240101T100000Z.db",
+		"guild_data.20240101T120000Z.db",
+		"guild_data.20240102T120000Z.db",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("removed %v, want %v", got, want)
+	}
+	left, err := os.ReadDir(dir)
+	must(t, err)
+	if len(left) != len(names)-len(want) {
+		t.Fatalf("%d files left, want %d", len(left), len(names)-len(want))
+	}
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// AddCharacter registers an alt for a member. Names are unique per member, ignoring case,
+// across the main character and all alts.
+func (d *DB) AddCharacter(ctx context.Context, guildID, discordID, name string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := d.checkCharacterNameTx(ctx, tx, guildID, discordID, name, ""); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO characters(guild_id, discord_id, name, updated_at) VALUES(?,?,?,?)`),
+		guildID, discordID, name, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RenameCharacter renames one of the member's alts. Resource amounts and ledger rows follow the new name.
+func (d *DB) RenameCharacter(ctx context.Context, guildID, discordID, oldName, newName string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := d.checkCharacterNameTx(ctx, tx, guildID, discordID, newName, oldName); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	res, err := tx.ExecContext(ctx, d.q(`UPDATE characters SET name=?, updated_at=? WHERE guild_id=? AND discord_id=? AND name=?`), newName, now, guildID, discordID, oldName)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCharacterNotFound
+	}
+	for _, table := range []string{"member_resources", "resource_history"} {
+		if _, err := tx.ExecContext(ctx, d.q(`UPDATE `+table+` SET character_name=? WHERE guild_id=? AND discord_id=? AND character_name=?`), newName, guildID, discordID, oldName); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// checkCharacterNameTx verifies the member exists and that name is not taken by their main
+// character or another alt. except is the alt being renamed, which may keep its own name.
+func (d *DB) checkCharacterNameTx(ctx context.Context, tx *sql.Tx, guildID, discordID, name, except string) error {
+	var main string
+	err := tx.QueryRowContext(ctx, d.q(`SELECT in_game_name FROM members WHERE guild_id=? AND discord_id=?`), guildID, discordID).Scan(&main)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMemberNotRegistered
+	}
+	if err != nil {
+		return err
+	}
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM characters WHERE guild_id=? AND discord_id=? AND LOWER(name)=LOWER(?) AND name<>?`),
+		guildID, discordID, name, except).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 || strings.EqualFold(main, name) {
+		return ErrCharacterExists
+	}
+	return nil
+}
+
+// GetCharacters returns alts ordered by owner and name. Empty discordID returns every alt in the guild.
+func (d *DB) GetCharacters(ctx context.Context, guildID, discordID string) ([]Character, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT guild_id, discord_id, name, updated_at FROM characters
+		WHERE guild_id=? AND (CAST(? AS TEXT)='' OR discord_id=?)
+		ORDER BY discord_id, LOWER(name)`), guildID, discordID, discordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Character
+	for rows.Next() {
+		var c Character
+		var updated int64
+		if err := rows.Scan(&c.GuildID, &c.DiscordID, &c.Name, &updated); err != nil {
+			return nil, err
+		}
+		c.UpdatedAt = unixTime(updated)
+		list = append(list, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	amounts, err := d.loadResources(ctx, guildID, discordID)
+	if err != nil {
+		return nil, err
+	}
+	for n := range list {
+		list[n].Resources = amounts[resourceOwner{list[n].DiscordID, list[n].Name}]
+	}
+	return list, nil
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// dialect identifies the SQL database behind a DB. Queries are written once with ? placeholders
+// and portable syntax; bind adapts them for drivers that need numbered placeholders.
+type dialect int
+
+const (
+	dialectSQLite dialect = iota
+	dialectPostgres
+)
+
+func (d dialect) String() string {
+	if d == dialectPostgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
+
+// bind rewrites ? placeholders to $1, $2, … for PostgreSQL. Queries must not contain a literal '?'.
+func (d dialect) bind(query string) string {
+	if d != dialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// q adapts a query to the connection's placeholder style.
+func (d *DB) q(query string) string { return d.dialect.bind(query) }
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Export formats accepted by ExportMembers.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatXLSX = "xlsx"
+)
+
+// Column sets accepted by ExportMembers.
+const (
+	IncludeResources    = "resources"
+	IncludeAvailability = "availability"
+	IncludeAll          = "all"
+)
+
+// exportColumn is one exported field. Values are string or int so XLSX can type cells.
+type exportColumn struct {
+	name  string
+	value func(m Member) any
+}
+
+var (
+	colDiscordID    = exportColumn{"discord_id", func(m Member) any { return m.DiscordID }}
+	colInGameName   = exportColumn{"in_game_name", func(m Member) any { return m.InGameName }}
+	colAvailability = exportColumn{"availability", func(m Member) any { return m.Availability.String() }}
+	colRole         = exportColumn{"guild_role_id", func(m Member) any { return m.GuildRoleID }}
+	colUpdatedAt    = exportColumn{"updated_at", func(m Member) any { return formatExportTime(m.UpdatedAt) }}
+)
+
+// resourceColumns exports one column per resource type, named by its key.
+func resourceColumns(types []ResourceType) []exportColumn {
+	cols := make([]exportColumn, len(types))
+	for n, t := range types {
+		key := t.Key
+		cols[n] = exportColumn{key, func(m Member) any { return m.Resources[key] }}
+	}
+	return cols
+}
+
+func exportColumns(include string, types []ResourceType) ([]exportColumn, error) {
+	cols := []exportColumn{colDiscordID, colInGameName}
+	switch include {
+	case IncludeResources:
+		cols = append(cols, resourceColumns(types)...)
+	case IncludeAvailability:
+		cols = append(cols, colAvailability)
+	case IncludeAll, "":
+		cols = append(append(cols, resourceColumns(types)...), colAvailability)
+	default:
+		return nil, fmt.Errorf("unknown export columns %q (want resources, availability or all)", include)
+	}
+	return append(cols, colRole, colUpdatedAt), nil
+}
+
+func formatExportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ExportMembers writes a roster as CSV (with header), a JSON array of objects, or an XLSX workbook.
+// include selects the column set, with one column per resource type; the same column names are
+// used as CSV/XLSX headers and JSON keys.
+func ExportMembers(w io.Writer, format, include string, types []ResourceType, members []Member) error {
+	cols, err := exportColumns(include, types)
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(cols))
+	for n, c := range cols {
+		header[n] = c.name
+	}
+	rows := make([][]any, 0, len(members))
+	for _, m := range members {
+		row := make([]any, len(cols))
+		for n, c := range cols {
+			row[n] = c.value(m)
+		}
+		rows = append(rows, row)
+	}
+
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			rec := make([]string, len(row))
+			for n, v := range row {
+				rec[n] = fmt.Sprint(v)
+			}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		// Encode objects with keys in column order
+		objs := make([]orderedRow, 0, len(rows))
+		for _, row := range rows {
+			objs = append(objs, orderedRow{keys: header, values: row})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(objs)
+	case FormatXLSX:
+		return writeXLSX(w, "Roster", header, rows)
+	}
+	return fmt.Errorf("unknown export format %q (want csv, json or xlsx)", format)
+}
+
+// orderedRow marshals as a JSON object preserving column order.
+type orderedRow struct {
+	keys   []string
+	values []any
+}
+
+func (r orderedRow) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for n, k := range r.keys {
+		if n > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendQuote(b, k)
+		b = append(b, ':')
+		var v bytes.Buffer
+		enc := json.NewEncoder(&v)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(r.values[n]); err != nil {
+			return nil, err
+		}
+		b = append(b, bytes.TrimSpace(v.Bytes())...)
+	}
+	return append(b, '}'), nil
+}
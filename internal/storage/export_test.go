@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var exportTestMembers = []Member{
+	{DiscordID: "1", InGameName: "alpha, the <first>", Resources: map[string]int{ResourceOrders: 12, ResourceLumber: 3}, Availability: Schedule{time.Saturday: {"18:00-20:00 GMT"}}, GuildRoleID: "r1",
+		UpdatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	{DiscordID: "2", InGameName: "bravo"},
+}
+
+func TestExportMembersCSV(t *testing.T) {
+	var buf bytes.Buffer
+	must(t, ExportMembers(&buf, FormatCSV, IncludeResources, DefaultResourceTypes("g"), exportTestMembers))
+	want := "discord_id,in_game_name,orders,lumber,guild_role_id,updated_at\n" +
+		"1,\"alpha, the <first>\",12,3,r1,2024-05-01T12:00:00Z\n" +
+		"2,bravo,0,0,,\n"
+	if buf.String() != want {
+		t.Fatalf("csv =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExportMembersJSON(t *testing.T) {
+	var buf bytes.Buffer
+	must(t, ExportMembers(&buf, FormatJSON, IncludeAvailability, nil, exportTestMembers[:1]))
+	// Keys follow column order and names are not HTML-escaped
+	want := `[
+  {
+    "discord_id": "1",
+    "in_game_name": "alpha, the <first>",
+    "availability": "Sat: 18:00-20:00 GMT",
+    "guild_role_id": "r1",
+    "updated_at": "2024-05-01T12:00:00Z"
+  }
+]
+`
+	if buf.String() != want {
+		t.Fatalf("json =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestExportMembersXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	must(t, ExportMembers(&buf, FormatXLSX, IncludeAll, DefaultResourceTypes("g"), exportTestMembers))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	must(t, err)
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		must(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		must(t, err)
+		sheet = string(b)
+	}
+	for _, want := range []string{
+		`<c r="G1" t="inlineStr"><is><t xml:space="preserve">updated_at</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">alpha, the &lt;first&gt;</t></is></c>`,
+		`<c r="C2"><v>12</v></c>`,
+		`<row r="3">`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Fatalf("sheet is missing %s:\n%s", want, sheet)
+		}
+	}
+}
+
+func TestExportMembersRejectsUnknownOptions(t *testing.T) {
+	if err := ExportMembers(io.Discard, "pdf", IncludeAll, nil, nil); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+	if err := ExportMembers(io.Discard, FormatCSV, "secrets", nil, nil); err == nil {
+		t.Fatal("unknown column set accepted")
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for n, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(n); got != want {
+			t.Errorf("xlsxColumn(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,1004 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type memberKey struct{ guildID, discordID string }
+
+type charKey struct{ guildID, discordID, name string }
+
+type resKey struct{ guildID, discordID, character, resource string }
+
+type permKey struct{ guildID, subjectType, subjectID string }
+
+type signupKey struct {
+	warID     int64
+	discordID string
+}
+
+// MemoryStore is a thread-safe in-memory Store for tests and throwaway runs. Nothing is persisted.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	members     map[memberKey]Member
+	characters  map[charKey]Character
+	resources   map[resKey]int
+	resourceAt  map[resKey]time.Time
+	types       map[string][]ResourceType
+	slots       map[string][]AvailabilitySlot
+	history     []HistoryEntry
+	perms       map[permKey]Tier
+	wars        map[int64]War
+	lastWarID   int64
+	signups     map[signupKey]WarSignup
+	attendance  map[signupKey]Attendance
+	charged     map[signupKey]int // War Orders deducted per attendance record; absent means unsettled
+	settings    map[string]GuildSettings
+	scheduleAt  map[memberKey]time.Time // last availability change
+	optedOut    map[memberKey]bool
+	reminders   []ReminderLog
+	seasons     []Season
+	archive     []ArchivedResource
+	leaderOwner string
+	leaderAt    time.Time
+}
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		members:    make(map[memberKey]Member),
+		characters: make(map[charKey]Character),
+		resources:  make(map[resKey]int),
+		resourceAt: make(map[resKey]time.Time),
+		types:      make(map[string][]ResourceType),
+		slots:      make(map[string][]AvailabilitySlot),
+		perms:      make(map[permKey]Tier),
+		wars:       make(map[int64]War),
+		signups:    make(map[signupKey]WarSignup),
+		attendance: make(map[signupKey]Attendance),
+		charged:    make(map[signupKey]int),
+		settings:   make(map[string]GuildSettings),
+		scheduleAt: make(map[memberKey]time.Time),
+		optedOut:   make(map[memberKey]bool),
+	}
+}
+
+func (m *MemoryStore) UpsertMember(_ context.Context, guildID, discordID, inGameName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memberKey{guildID, discordID}
+	mem, ok := m.members[k]
+	if !ok {
+		mem = newMember(guildID, discordID)
+	}
+	mem.InGameName = inGameName
+	mem.UpdatedAt = time.Now().Truncate(time.Second).UTC()
+	m.members[k] = mem
+	return nil
+}
+
+func (m *MemoryStore) InsertMemberIfMissing(_ context.Context, guildID, discordID, inGameName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memberKey{guildID, discordID}
+	if _, ok := m.members[k]; !ok {
+		mem := newMember(guildID, discordID)
+		mem.InGameName = inGameName
+		mem.UpdatedAt = time.Now().Truncate(time.Second).UTC()
+		m.members[k] = mem
+	}
+	return nil
+}
+
+func (m *MemoryStore) EnsureMemberExists(_ context.Context, guildID, discordID string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.members[memberKey{guildID, discordID}]
+	return ok, nil
+}
+
+func (m *MemoryStore) DeleteMember(_ context.Context, guildID, discordID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.members, memberKey{guildID, discordID})
+	delete(m.scheduleAt, memberKey{guildID, discordID})
+	delete(m.optedOut, memberKey{guildID, discordID})
+	for k := range m.characters {
+		if k.guildID == guildID && k.discordID == discordID {
+			delete(m.characters, k)
+		}
+	}
+	for k := range m.resources {
+		if k.guildID == guildID && k.discordID == discordID {
+			delete(m.resources, k)
+			delete(m.resourceAt, k)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetAllMembers(_ context.Context, guildID string) ([]Member, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []Member
+	for k, mem := range m.members {
+		if k.guildID == guildID {
+			mem.Resources = m.amounts(guildID, k.discordID, "")
+			list = append(list, mem)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		x, y := strings.ToLower(list[a].InGameName), strings.ToLower(list[b].InGameName)
+		if x != y {
+			return x < y
+		}
+		return list[a].DiscordID < list[b].DiscordID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) SetResource(_ context.Context, guildID, discordID, character, actorID, resource string, amount int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkOwner(guildID, discordID, character); err != nil {
+		return err
+	}
+	m.setResource(guildID, discordID, character, actorID, resource, amount)
+	return nil
+}
+
+func (m *MemoryStore) AdjustResource(_ context.Context, guildID, discordID, character, actorID, resource string, delta int, bounds Bounds) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkOwner(guildID, discordID, character); err != nil {
+		return 0, err
+	}
+	current := m.resources[resKey{guildID, discordID, character, resource}]
+	if !bounds.Contains(current + delta) {
+		return current, ErrOutOfBounds
+	}
+	m.setResource(guildID, discordID, character, actorID, resource, current+delta)
+	return current + delta, nil
+}
+
+// checkOwner mirrors DB.checkOwnerTx; callers hold the lock.
+func (m *MemoryStore) checkOwner(guildID, discordID, character string) error {
+	if _, ok := m.members[memberKey{guildID, discordID}]; !ok {
+		return ErrMemberNotRegistered
+	}
+	if _, ok := m.characters[charKey{guildID, discordID, character}]; character != "" && !ok {
+		return ErrCharacterNotFound
+	}
+	return nil
+}
+
+// setResource stores an amount, records it and touches the owner's UpdatedAt; callers hold the write lock.
+func (m *MemoryStore) setResource(guildID, discordID, character, actorID, resource string, amount int) {
+	k := resKey{guildID, discordID, character, resource}
+	m.record(guildID, discordID, character, resource, m.resources[k], amount, actorID)
+	m.resources[k] = amount
+	now := time.Now().Truncate(time.Second).UTC()
+	m.resourceAt[k] = now
+	if mem, ok := m.members[memberKey{guildID, discordID}]; ok {
+		mem.UpdatedAt = now
+		m.members[memberKey{guildID, discordID}] = mem
+	}
+	if c, ok := m.characters[charKey{guildID, discordID, character}]; ok {
+		c.UpdatedAt = now
+		m.characters[charKey{guildID, discordID, character}] = c
+	}
+}
+
+// amounts copies one character's resource amounts; callers hold the lock.
+func (m *MemoryStore) amounts(guildID, discordID, character string) map[string]int {
+	var out map[string]int
+	for k, v := range m.resources {
+		if k.guildID == guildID && k.discordID == discordID && k.character == character {
+			if out == nil {
+				out = make(map[string]int)
+			}
+			out[k.resource] = v
+		}
+	}
+	return out
+}
+
+func (m *MemoryStore) SetAvailability(_ context.Context, guildID, discordID string, sched Schedule) error {
+	return m.updateMember(guildID, discordID, func(mem *Member) {
+		mem.Availability = cloneSchedule(sched)
+		mem.UpdatedAt = time.Now().Truncate(time.Second).UTC()
+		m.scheduleAt[memberKey{guildID, discordID}] = mem.UpdatedAt
+	})
+}
+
+func (m *MemoryStore) SetTimeZone(_ context.Context, guildID, discordID, zone string) error {
+	return m.updateMember(guildID, discordID, func(mem *Member) { mem.TimeZone = zone })
+}
+
+// cloneSchedule copies sched with each day's slots sorted and deduplicated, as the SQL store returns them.
+func cloneSchedule(sched Schedule) Schedule {
+	out := make(Schedule)
+	for d, v := range sched {
+		if len(v) > 0 {
+			out[d] = slices.Compact(slices.Sorted(slices.Values(v)))
+		}
+	}
+	return out
+}
+
+func (m *MemoryStore) UpdateMemberRole(_ context.Context, guildID, discordID, roleID string) error {
+	return m.updateMember(guildID, discordID, func(mem *Member) { mem.GuildRoleID = roleID })
+}
+
+// ImportMembers applies all rows under one lock, so readers see either none or all of them.
+func (m *MemoryStore) ImportMembers(_ context.Context, guildID, actorID string, rows []ImportMember) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now().Truncate(time.Second).UTC()
+	for _, r := range rows {
+		k := memberKey{guildID, r.DiscordID}
+		mem, ok := m.members[k]
+		if !ok {
+			mem = newMember(guildID, r.DiscordID)
+		}
+		mem.InGameName = r.InGameName
+		if r.Availability != nil {
+			mem.Availability = cloneSchedule(r.Availability)
+			m.scheduleAt[k] = now
+		}
+		mem.UpdatedAt = now
+		m.members[k] = mem
+		for _, key := range sortedKeys(r.Resources) {
+			m.setResource(guildID, r.DiscordID, "", actorID, key, r.Resources[key])
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) AddCharacter(_ context.Context, guildID, discordID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkCharacterName(guildID, discordID, name, ""); err != nil {
+		return err
+	}
+	m.characters[charKey{guildID, discordID, name}] = Character{GuildID: guildID, DiscordID: discordID, Name: name, UpdatedAt: time.Now().Truncate(time.Second).UTC()}
+	return nil
+}
+
+func (m *MemoryStore) RenameCharacter(_ context.Context, guildID, discordID, oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkCharacterName(guildID, discordID, newName, oldName); err != nil {
+		return err
+	}
+	k := charKey{guildID, discordID, oldName}
+	c, ok := m.characters[k]
+	if !ok {
+		return ErrCharacterNotFound
+	}
+	delete(m.characters, k)
+	c.Name = newName
+	c.UpdatedAt = time.Now().Truncate(time.Second).UTC()
+	m.characters[charKey{guildID, discordID, newName}] = c
+	for k, v := range m.resources {
+		if k.guildID == guildID && k.discordID == discordID && k.character == oldName {
+			at := m.resourceAt[k]
+			delete(m.resources, k)
+			delete(m.resourceAt, k)
+			k.character = newName
+			m.resources[k], m.resourceAt[k] = v, at
+		}
+	}
+	for n := range m.history {
+		if e := &m.history[n]; e.GuildID == guildID && e.DiscordID == discordID && e.Character == oldName {
+			e.Character = newName
+		}
+	}
+	return nil
+}
+
+// checkCharacterName mirrors DB.checkCharacterNameTx; callers hold the write lock.
+func (m *MemoryStore) checkCharacterName(guildID, discordID, name, except string) error {
+	mem, ok := m.members[memberKey{guildID, discordID}]
+	if !ok {
+		return ErrMemberNotRegistered
+	}
+	if strings.EqualFold(mem.InGameName, name) {
+		return ErrCharacterExists
+	}
+	for k := range m.characters {
+		if k.guildID == guildID && k.discordID == discordID && k.name != except && strings.EqualFold(k.name, name) {
+			return ErrCharacterExists
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetCharacters(_ context.Context, guildID, discordID string) ([]Character, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []Character
+	for k, c := range m.characters {
+		if k.guildID == guildID && (discordID == "" || k.discordID == discordID) {
+			c.Resources = m.amounts(guildID, k.discordID, k.name)
+			list = append(list, c)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].DiscordID != list[b].DiscordID {
+			return list[a].DiscordID < list[b].DiscordID
+		}
+		return strings.ToLower(list[a].Name) < strings.ToLower(list[b].Name)
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) ListResourceTypes(_ context.Context, guildID string) ([]ResourceType, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]ResourceType(nil), m.types[guildID]...), nil
+}
+
+func (m *MemoryStore) UpsertResourceType(_ context.Context, t ResourceType) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	types := m.types[t.GuildID]
+	for n := range types {
+		if types[n].Key == t.Key {
+			t.Position = types[n].Position
+			types[n] = t
+			return nil
+		}
+	}
+	t.Position = 0
+	if len(types) > 0 {
+		t.Position = types[len(types)-1].Position + 1
+	}
+	m.types[t.GuildID] = append(types, t)
+	return nil
+}
+
+func (m *MemoryStore) DeleteResourceType(_ context.Context, guildID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	types := m.types[guildID]
+	for n := range types {
+		if types[n].Key == key {
+			m.types[guildID] = append(types[:n:n], types[n+1:]...)
+			for k := range m.resources {
+				if k.guildID == guildID && k.resource == key {
+					delete(m.resources, k)
+					delete(m.resourceAt, k)
+				}
+			}
+			return nil
+		}
+	}
+	return ErrResourceTypeNotFound
+}
+
+func (m *MemoryStore) SeedResourceTypes(_ context.Context, guildID string, types []ResourceType) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.types[guildID]) > 0 {
+		return nil
+	}
+	seeded := make([]ResourceType, len(types))
+	for n, t := range types {
+		t.GuildID, t.Position = guildID, n
+		seeded[n] = t
+	}
+	m.types[guildID] = seeded
+	return nil
+}
+
+func (m *MemoryStore) ListAvailabilitySlots(_ context.Context, guildID string) ([]AvailabilitySlot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]AvailabilitySlot(nil), m.slots[guildID]...), nil
+}
+
+func (m *MemoryStore) AddAvailabilitySlot(_ context.Context, guildID string, slot AvailabilitySlot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sl := range m.slots[guildID] {
+		if sl.Label == slot.Label {
+			return ErrAvailabilitySlotExists
+		}
+	}
+	m.slots[guildID] = sortSlots(append(m.slots[guildID], slot))
+	return nil
+}
+
+func (m *MemoryStore) DeleteAvailabilitySlot(_ context.Context, guildID, label string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	slots := m.slots[guildID]
+	n := slices.IndexFunc(slots, func(sl AvailabilitySlot) bool { return sl.Label == label })
+	if n < 0 {
+		return nil, ErrAvailabilitySlotNotFound
+	}
+	m.slots[guildID] = append(slots[:n:n], slots[n+1:]...)
+	var affected []string
+	for k, mem := range m.members {
+		if k.guildID != guildID {
+			continue
+		}
+		had := false
+		sched := make(Schedule)
+		for d, v := range mem.Availability {
+			if slices.Contains(v, label) {
+				had = true
+				v = slices.DeleteFunc(slices.Clone(v), func(s string) bool { return s == label })
+			}
+			if len(v) > 0 {
+				sched[d] = v
+			}
+		}
+		if had {
+			mem.Availability = sched
+			m.members[k] = mem
+			affected = append(affected, k.discordID)
+		}
+	}
+	sort.Strings(affected)
+	return affected, nil
+}
+
+func (m *MemoryStore) SeedAvailabilitySlots(_ context.Context, guildID string, slots []AvailabilitySlot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.slots[guildID]) == 0 {
+		m.slots[guildID] = sortSlots(slices.Clone(slots))
+	}
+	return nil
+}
+
+// sortSlots orders slots like ListAvailabilitySlots does: by start time, then label.
+func sortSlots(slots []AvailabilitySlot) []AvailabilitySlot {
+	sort.Slice(slots, func(a, b int) bool {
+		if slots[a].Start != slots[b].Start {
+			return slots[a].Start < slots[b].Start
+		}
+		return slots[a].Label < slots[b].Label
+	})
+	return slots
+}
+
+func (m *MemoryStore) CreateWar(_ context.Context, w War) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastWarID++
+	w.ID, w.StartsAt, w.CreatedAt = m.lastWarID, w.StartsAt.Truncate(time.Second).UTC(), time.Now().Truncate(time.Second).UTC()
+	if w.Status == "" {
+		w.Status = WarOpen
+	}
+	m.wars[w.ID] = w
+	return w.ID, nil
+}
+
+func (m *MemoryStore) GetWar(_ context.Context, guildID string, id int64) (War, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	w, ok := m.wars[id]
+	if !ok || w.GuildID != guildID {
+		return War{}, ErrWarNotFound
+	}
+	return w, nil
+}
+
+func (m *MemoryStore) ListWars(_ context.Context, guildID string) ([]War, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []War
+	for _, w := range m.wars {
+		if w.GuildID == guildID {
+			list = append(list, w)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].StartsAt.Equal(list[b].StartsAt) {
+			return list[a].StartsAt.After(list[b].StartsAt)
+		}
+		return list[a].ID > list[b].ID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) SetWarMessage(_ context.Context, guildID string, id int64, channelID, messageID string) error {
+	return m.updateWar(guildID, id, func(w *War) { w.ChannelID, w.MessageID = channelID, messageID })
+}
+
+func (m *MemoryStore) SetWarStatus(_ context.Context, guildID string, id int64, status string) error {
+	return m.updateWar(guildID, id, func(w *War) { w.Status = status })
+}
+
+// updateWar applies fn to a stored war under the write lock.
+func (m *MemoryStore) updateWar(guildID string, id int64, fn func(*War)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.wars[id]
+	if !ok || w.GuildID != guildID {
+		return ErrWarNotFound
+	}
+	fn(&w)
+	m.wars[id] = w
+	return nil
+}
+
+func (m *MemoryStore) SetSignup(_ context.Context, guildID string, warID int64, discordID, response string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.wars[warID]
+	if !ok || w.GuildID != guildID {
+		return ErrWarNotFound
+	}
+	if w.Status != WarOpen {
+		return ErrWarLocked
+	}
+	m.signups[signupKey{warID, discordID}] = WarSignup{WarID: warID, DiscordID: discordID, Response: response, UpdatedAt: time.Now().Truncate(time.Second).UTC()}
+	return nil
+}
+
+func (m *MemoryStore) GetSignups(_ context.Context, guildID string, warID int64) ([]WarSignup, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if w, ok := m.wars[warID]; !ok || w.GuildID != guildID {
+		return nil, nil
+	}
+	var list []WarSignup
+	for k, s := range m.signups {
+		if k.warID == warID {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].UpdatedAt.Equal(list[b].UpdatedAt) {
+			return list[a].UpdatedAt.Before(list[b].UpdatedAt)
+		}
+		return list[a].DiscordID < list[b].DiscordID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) SetAttendance(_ context.Context, guildID string, warID int64, actorID string, statuses map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.wars[warID]; !ok || w.GuildID != guildID {
+		return ErrWarNotFound
+	}
+	now := time.Now().Truncate(time.Second).UTC()
+	for id, status := range statuses {
+		m.attendance[signupKey{warID, id}] = Attendance{WarID: warID, DiscordID: id, Status: status, RecordedBy: actorID, RecordedAt: now}
+	}
+	return nil
+}
+
+func (m *MemoryStore) GetAttendance(_ context.Context, guildID string, warID int64) ([]Attendance, error) {
+	list := m.attendanceWhere(guildID, func(w War) bool { return w.ID == warID })
+	sort.Slice(list, func(a, b int) bool { return list[a].InGameName < list[b].InGameName })
+	return list, nil
+}
+
+func (m *MemoryStore) ListAttendance(_ context.Context, guildID string, since time.Time) ([]Attendance, error) {
+	list := m.attendanceWhere(guildID, func(w War) bool { return !w.StartsAt.Before(since.Truncate(time.Second)) })
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].WarStartsAt.Equal(list[b].WarStartsAt) {
+			return list[a].WarStartsAt.Before(list[b].WarStartsAt)
+		}
+		if list[a].WarID != list[b].WarID {
+			return list[a].WarID < list[b].WarID
+		}
+		return list[a].DiscordID < list[b].DiscordID
+	})
+	return list, nil
+}
+
+// attendanceWhere joins attendance records like the SQL stores: with their war (filtered by
+// keep), the member's roster row and their signup.
+func (m *MemoryStore) attendanceWhere(guildID string, keep func(War) bool) []Attendance {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []Attendance
+	for k, a := range m.attendance {
+		w, ok := m.wars[k.warID]
+		if !ok || w.GuildID != guildID || !keep(w) {
+			continue
+		}
+		mem, ok := m.members[memberKey{guildID, k.discordID}]
+		if !ok {
+			continue
+		}
+		a.WarStartsAt, a.InGameName, a.Signup = w.StartsAt, mem.InGameName, m.signups[k].Response
+		list = append(list, a)
+	}
+	return list
+}
+
+func (m *MemoryStore) SetWarResult(_ context.Context, guildID string, id int64, result, score, notes string) error {
+	return m.updateWar(guildID, id, func(w *War) { w.Result, w.Score, w.Notes, w.Status = result, score, notes, WarComplete })
+}
+
+func (m *MemoryStore) SettleWarOrders(_ context.Context, guildID string, warID int64, actorID string, cost int, bounds Bounds) ([]HistoryEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.wars[warID]
+	if !ok || w.GuildID != guildID {
+		return nil, ErrWarNotFound
+	}
+	if w.Status != WarComplete {
+		return nil, nil
+	}
+	var keys []signupKey
+	for k := range m.attendance {
+		if _, ok := m.members[memberKey{guildID, k.discordID}]; ok && k.warID == warID {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		x, y := m.members[memberKey{guildID, keys[a].discordID}], m.members[memberKey{guildID, keys[b].discordID}]
+		if x.InGameName != y.InGameName {
+			return x.InGameName < y.InGameName
+		}
+		return x.DiscordID < y.DiscordID
+	})
+	var changes []HistoryEntry
+	for _, k := range keys {
+		old := m.resources[resKey{guildID, k.discordID, "", ResourceOrders}]
+		charged, settled := m.charged[k]
+		present := m.attendance[k].Status == AttendancePresent
+		amount := old
+		switch {
+		case present && !settled && cost > 0:
+			if amount > bounds.Min {
+				amount = max(amount-cost, bounds.Min)
+			}
+			m.charged[k] = old - amount
+		case !present && settled:
+			amount = min(old+charged, max(bounds.Max, old))
+			delete(m.charged, k)
+		default:
+			continue
+		}
+		if amount == old {
+			continue
+		}
+		m.setResource(guildID, k.discordID, "", actorID, ResourceOrders, amount)
+		changes = append(changes, HistoryEntry{GuildID: guildID, DiscordID: k.discordID, InGameName: m.members[memberKey{guildID, k.discordID}].InGameName, Resource: ResourceOrders, OldValue: old, NewValue: amount, ActorID: actorID})
+	}
+	return changes, nil
+}
+
+func (m *MemoryStore) GetGuildSettings(_ context.Context, guildID string) (GuildSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if s, ok := m.settings[guildID]; ok {
+		return s, nil
+	}
+	return GuildSettings{GuildID: guildID, ReminderDays: "all"}, nil
+}
+
+func (m *MemoryStore) SaveGuildSettings(_ context.Context, s GuildSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.LastReminderAt = m.settings[s.GuildID].LastReminderAt
+	m.settings[s.GuildID] = s
+	return nil
+}
+
+func (m *MemoryStore) ListGuildSettings(_ context.Context) ([]GuildSettings, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []GuildSettings
+	for _, s := range m.settings {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].GuildID < list[b].GuildID })
+	return list, nil
+}
+
+func (m *MemoryStore) ListMemberActivity(_ context.Context, guildID string) ([]MemberActivity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	index := make(map[string]int)
+	var list []MemberActivity
+	for k, mem := range m.members {
+		if k.guildID != guildID {
+			continue
+		}
+		index[k.discordID] = len(list)
+		list = append(list, MemberActivity{DiscordID: k.discordID, InGameName: mem.InGameName, Resources: make(map[string]time.Time), Availability: m.scheduleAt[k], RemindersOff: m.optedOut[k]})
+	}
+	for k := range m.resources {
+		n, ok := index[k.discordID]
+		if at := m.resourceAt[k]; ok && k.guildID == guildID && at.After(list[n].Resources[k.resource]) {
+			list[n].Resources[k.resource] = at
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		x, y := strings.ToLower(list[a].InGameName), strings.ToLower(list[b].InGameName)
+		if x != y {
+			return x < y
+		}
+		return list[a].DiscordID < list[b].DiscordID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) SetRemindersOff(_ context.Context, guildID, discordID string, off bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memberKey{guildID, discordID}
+	if _, ok := m.members[k]; !ok {
+		return ErrMemberNotRegistered
+	}
+	m.optedOut[k] = off
+	return nil
+}
+
+func (m *MemoryStore) RecordReminderRun(_ context.Context, guildID string, at time.Time, sent []ReminderLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	at = at.Truncate(time.Second).UTC()
+	for _, r := range sent {
+		r.GuildID, r.SentAt = guildID, at
+		m.reminders = append(m.reminders, r)
+	}
+	s, ok := m.settings[guildID]
+	if !ok {
+		s = GuildSettings{GuildID: guildID, ReminderDays: "all"}
+	}
+	s.LastReminderAt = at
+	m.settings[guildID] = s
+	m.reminders = slices.DeleteFunc(m.reminders, func(r ReminderLog) bool {
+		return r.GuildID == guildID && r.SentAt.Before(at.Add(-reminderLogRetention))
+	})
+	return nil
+}
+
+func (m *MemoryStore) GetLastReminderRun(_ context.Context, guildID string) ([]ReminderLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	last := m.settings[guildID].LastReminderAt
+	var list []ReminderLog
+	for _, r := range m.reminders {
+		if r.GuildID == guildID && r.SentAt.Equal(last) {
+			list = append(list, r)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		x, y := strings.ToLower(list[a].InGameName), strings.ToLower(list[b].InGameName)
+		if x != y {
+			return x < y
+		}
+		return list[a].DiscordID < list[b].DiscordID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) StartSeason(_ context.Context, guildID, name string) (Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.seasons {
+		if s.GuildID == guildID && s.Active() {
+			return Season{}, ErrSeasonActive
+		}
+	}
+	s := Season{ID: int64(len(m.seasons) + 1), GuildID: guildID, Name: name, StartedAt: time.Now().Truncate(time.Second).UTC()}
+	m.seasons = append(m.seasons, s)
+	return s, nil
+}
+
+func (m *MemoryStore) EndSeason(_ context.Context, guildID string) (Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for n, s := range m.seasons {
+		if s.GuildID != guildID || !s.Active() {
+			continue
+		}
+		s.EndedAt = time.Now().Truncate(time.Second).UTC()
+		m.seasons[n] = s
+		for k, v := range m.resources {
+			mem, ok := m.members[memberKey{guildID, k.discordID}]
+			if k.guildID != guildID || !ok {
+				continue
+			}
+			m.archive = append(m.archive, ArchivedResource{SeasonID: s.ID, DiscordID: k.discordID, InGameName: mem.InGameName, Character: k.character, Resource: k.resource, Amount: v})
+		}
+		return s, nil
+	}
+	return Season{}, ErrNoActiveSeason
+}
+
+func (m *MemoryStore) ListSeasons(_ context.Context, guildID string) ([]Season, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []Season
+	for _, s := range m.seasons {
+		if s.GuildID == guildID {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if !list[a].StartedAt.Equal(list[b].StartedAt) {
+			return list[a].StartedAt.After(list[b].StartedAt)
+		}
+		return list[a].ID > list[b].ID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) GetSeasonArchive(_ context.Context, guildID string, seasonID int64) ([]ArchivedResource, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if seasonID < 1 || seasonID > int64(len(m.seasons)) || m.seasons[seasonID-1].GuildID != guildID {
+		return nil, nil
+	}
+	var list []ArchivedResource
+	for _, a := range m.archive {
+		if a.SeasonID == seasonID {
+			list = append(list, a)
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		x, y := list[a], list[b]
+		if x.InGameName != y.InGameName {
+			return x.InGameName < y.InGameName
+		}
+		if x.DiscordID != y.DiscordID {
+			return x.DiscordID < y.DiscordID
+		}
+		if x.Character != y.Character {
+			return x.Character < y.Character
+		}
+		return x.Resource < y.Resource
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) GetResourceHistory(_ context.Context, guildID, discordID, resource string, since time.Time) ([]HistoryEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []HistoryEntry
+	// history is append-only, so walking it backwards yields newest first
+	for n := len(m.history) - 1; n >= 0; n-- {
+		e := m.history[n]
+		if e.GuildID != guildID || (discordID != "" && e.DiscordID != discordID) || (resource != "" && e.Resource != resource) {
+			continue
+		}
+		if e.ChangedAt.Before(since.Truncate(time.Second)) {
+			continue
+		}
+		e.InGameName = m.members[memberKey{e.GuildID, e.DiscordID}].InGameName
+		list = append(list, e)
+	}
+	return list, nil
+}
+
+func (m *MemoryStore) GrantPermission(_ context.Context, guildID, subjectType, subjectID string, tier Tier) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.perms[permKey{guildID, subjectType, subjectID}] = tier
+	return nil
+}
+
+func (m *MemoryStore) RevokePermission(_ context.Context, guildID, subjectType, subjectID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := permKey{guildID, subjectType, subjectID}
+	if _, ok := m.perms[k]; !ok {
+		return ErrNoPermissionGrant
+	}
+	delete(m.perms, k)
+	return nil
+}
+
+func (m *MemoryStore) ListPermissions(_ context.Context, guildID string) ([]PermissionGrant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var list []PermissionGrant
+	for k, t := range m.perms {
+		if k.guildID == guildID {
+			list = append(list, PermissionGrant{GuildID: k.guildID, SubjectType: k.subjectType, SubjectID: k.subjectID, Tier: t})
+		}
+	}
+	sort.Slice(list, func(a, b int) bool {
+		if list[a].Tier != list[b].Tier {
+			return list[a].Tier > list[b].Tier
+		}
+		if list[a].SubjectType != list[b].SubjectType {
+			return list[a].SubjectType < list[b].SubjectType
+		}
+		return list[a].SubjectID < list[b].SubjectID
+	})
+	return list, nil
+}
+
+func (m *MemoryStore) SeedPermissions(_ context.Context, guildID string, grants []PermissionGrant) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.perms {
+		if k.guildID == guildID {
+			return nil
+		}
+	}
+	for _, g := range grants {
+		k := permKey{guildID, g.SubjectType, g.SubjectID}
+		if _, ok := m.perms[k]; !ok {
+			m.perms[k] = g.Tier
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) TryAcquireLeader(_ context.Context, instanceID string, lease time.Duration, takeoverIfStale bool) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	stale := !m.leaderAt.After(now.Add(-lease))
+	if m.leaderOwner == "" || m.leaderOwner == instanceID || (stale && takeoverIfStale) {
+		m.leaderOwner = instanceID
+		m.leaderAt = now
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *MemoryStore) RenewLeader(_ context.Context, instanceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leaderOwner == instanceID {
+		m.leaderAt = time.Now()
+	}
+	return nil
+}
+
+func (m *MemoryStore) ReleaseLeader(_ context.Context, instanceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.leaderOwner == instanceID {
+		m.leaderOwner = ""
+		m.leaderAt = time.Time{}
+	}
+	return nil
+}
+
+func (m *MemoryStore) Close() error { return nil }
+
+// updateMember applies fn to an existing member under the write lock.
+func (m *MemoryStore) updateMember(guildID, discordID string, fn func(mem *Member)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := memberKey{guildID, discordID}
+	mem, ok := m.members[k]
+	if !ok {
+		return ErrMemberNotRegistered
+	}
+	fn(&mem)
+	m.members[k] = mem
+	return nil
+}
+
+// record appends a ledger entry; callers hold the write lock.
+func (m *MemoryStore) record(guildID, discordID, character, resource string, old, amount int, actorID string) {
+	m.history = append(m.history, HistoryEntry{
+		GuildID:   guildID,
+		DiscordID: discordID,
+		Character: character,
+		Resource:  resource,
+		OldValue:  old,
+		NewValue:  amount,
+		ActorID:   actorID,
+		ChangedAt: time.Now().Truncate(time.Second).UTC(),
+	})
+}
+
+// newMember returns a member with the same defaults as the SQL schema.
+func newMember(guildID, discordID string) Member {
+	return Member{GuildID: guildID, DiscordID: discordID}
+}
@@ -0,0 +1,451 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// migration is a single numbered schema change. Migrations run in order, each in its own transaction.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx, env migrationEnv) error
+}
+
+// migrationEnv carries deployment settings some migrations need to rewrite existing data.
+type migrationEnv struct {
+	legacyGuildID string
+}
+
+// migrationSet is a dialect's ordered migrations plus how to detect databases that predate them.
+type migrationSet struct {
+	migrations []migration
+	dialect    dialect
+	// stampLegacy, if set, detects an unversioned database and stamps the version it already matches.
+	stampLegacy func(db *sql.DB, set migrationSet) (int, error)
+}
+
+func (s migrationSet) latest() int { return s.migrations[len(s.migrations)-1].version }
+
+var sqliteMigrationSet = migrationSet{migrations: sqliteMigrations, dialect: dialectSQLite, stampLegacy: stampLegacySQLite}
+
+// sqliteMigrations is the ordered list of SQLite up-migrations. Append only; never renumber or edit a released entry.
+var sqliteMigrations = []migration{
+	{version: 1, name: "initial members and leader tables", up: migrateInitial},
+	{version: 2, name: "members guild_role_id column", up: migrateGuildRoleColumn},
+	{version: 3, name: "scope members per guild", up: migrateGuildScopedMembers},
+	{version: 4, name: "resource history ledger", up: migrateResourceHistory},
+	{version: 5, name: "permission tiers", up: migratePermissions},
+	{version: 6, name: "members updated_at", up: migrateMemberUpdatedAt},
+	{version: 7, name: "characters", up: migrateCharacters},
+	{version: 8, name: "per-guild resource types", up: migrateResourceTypes},
+	{version: 9, name: "weekly availability", up: migrateWeeklyAvailability},
+	{version: 10, name: "members time_zone", up: migrateMemberTimeZone},
+	{version: 11, name: "per-guild availability slots", up: migrateAvailabilitySlots},
+	{version: 12, name: "wars and signups", up: migrateWars},
+	{version: 13, name: "war attendance", up: migrateWarAttendance},
+	{version: 14, name: "war results and seasons", up: migrateWarResults},
+	{version: 15, name: "guild settings", up: migrateGuildSettings},
+	{version: 16, name: "reminders", up: migrateReminders},
+}
+
+func migrateInitial(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE members (
+		discord_id TEXT PRIMARY KEY,
+		in_game_name TEXT NOT NULL,
+		war_orders INTEGER DEFAULT 0,
+		lumber INTEGER DEFAULT 0,
+		availability TEXT DEFAULT 'Not Set'
+	);
+	CREATE TABLE leader (
+		id INTEGER PRIMARY KEY CHECK (id=1),
+		owner TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`)
+	return err
+}
+
+func migrateGuildRoleColumn(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE members ADD COLUMN guild_role_id TEXT DEFAULT ''`)
+	return err
+}
+
+// migrateGuildScopedMembers rebuilds the members table (keyed only on discord_id) with a
+// (guild_id, discord_id) key, assigning existing rows to the legacy guild. It refuses to run on
+// a populated table without one, rather than filing the roster under a guild that does not exist.
+func migrateGuildScopedMembers(tx *sql.Tx, env migrationEnv) error {
+	if env.legacyGuildID == "" {
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM members`).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("the existing roster has %d members but no guild is configured to assign them to; set Guilds or GUILD_ID", n)
+		}
+	}
+	if _, err := tx.Exec(`CREATE TABLE members_new (
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		in_game_name TEXT NOT NULL,
+		war_orders INTEGER DEFAULT 0,
+		lumber INTEGER DEFAULT 0,
+		availability TEXT DEFAULT 'Not Set',
+		guild_role_id TEXT DEFAULT '',
+		PRIMARY KEY (guild_id, discord_id)
+	)`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO members_new(guild_id, discord_id, in_game_name, war_orders, lumber, availability, guild_role_id)
+		SELECT ?, discord_id, in_game_name, war_orders, lumber, availability, guild_role_id FROM members`, env.legacyGuildID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DROP TABLE members; ALTER TABLE members_new RENAME TO members;`)
+	return err
+}
+
+func migrateResourceHistory(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE resource_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		resource TEXT NOT NULL,
+		old_value INTEGER NOT NULL,
+		new_value INTEGER NOT NULL,
+		actor_id TEXT NOT NULL,
+		changed_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_resource_history_member ON resource_history(guild_id, discord_id, changed_at);`)
+	return err
+}
+
+func migratePermissions(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE permissions (
+		guild_id TEXT NOT NULL,
+		subject_type TEXT NOT NULL CHECK (subject_type IN ('user', 'role')),
+		subject_id TEXT NOT NULL,
+		tier TEXT NOT NULL,
+		PRIMARY KEY (guild_id, subject_type, subject_id)
+	)`)
+	return err
+}
+
+func migrateMemberUpdatedAt(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE members ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0`)
+	return err
+}
+
+// migrateCharacters adds the alts table and tags ledger rows with the character they belong to.
+// The SQL is portable, so PostgreSQL uses it too.
+func migrateCharacters(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE characters (
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		war_orders INTEGER NOT NULL DEFAULT 0,
+		lumber INTEGER NOT NULL DEFAULT 0,
+		updated_at BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (guild_id, discord_id, name)
+	);
+	ALTER TABLE resource_history ADD COLUMN character_name TEXT NOT NULL DEFAULT '';`)
+	return err
+}
+
+// migrateResourceTypes moves the fixed war_orders/lumber columns into the normalized
+// member_resources table keyed by resource type. Guilds keep tracking War Orders and Lumber
+// through DefaultResourceTypes until they define their own types. Shared with PostgreSQL.
+func migrateResourceTypes(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE resource_types (
+		guild_id TEXT NOT NULL,
+		resource_key TEXT NOT NULL,
+		name TEXT NOT NULL,
+		emoji TEXT NOT NULL DEFAULT '',
+		min_value INTEGER NOT NULL,
+		max_value INTEGER NOT NULL,
+		sort_order INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (guild_id, resource_key)
+	);
+	CREATE TABLE member_resources (
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		character_name TEXT NOT NULL DEFAULT '',
+		resource_key TEXT NOT NULL,
+		amount INTEGER NOT NULL DEFAULT 0,
+		updated_at BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (guild_id, discord_id, character_name, resource_key)
+	);
+	INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key, amount, updated_at)
+		SELECT guild_id, discord_id, '', 'orders', COALESCE(war_orders, 0), updated_at FROM members;
+	INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key, amount, updated_at)
+		SELECT guild_id, discord_id, '', 'lumber', COALESCE(lumber, 0), updated_at FROM members;
+	INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key, amount, updated_at)
+		SELECT guild_id, discord_id, name, 'orders', war_orders, updated_at FROM characters;
+	INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key, amount, updated_at)
+		SELECT guild_id, discord_id, name, 'lumber', lumber, updated_at FROM characters;
+	ALTER TABLE members DROP COLUMN war_orders;
+	ALTER TABLE members DROP COLUMN lumber;
+	ALTER TABLE characters DROP COLUMN war_orders;
+	ALTER TABLE characters DROP COLUMN lumber;`)
+	return err
+}
+
+// migrateWeeklyAvailability replaces the single members.availability value with per-weekday
+// slots in member_availability. A member's existing slot is copied to every day of the week.
+// Weekdays are numbered like time.Weekday (Sunday = 0). Shared with PostgreSQL.
+func migrateWeeklyAvailability(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE member_availability (
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		weekday INTEGER NOT NULL,
+		slot TEXT NOT NULL,
+		PRIMARY KEY (guild_id, discord_id, weekday, slot)
+	);
+	INSERT INTO member_availability(guild_id, discord_id, weekday, slot)
+		SELECT m.guild_id, m.discord_id, d.weekday, m.availability FROM members m
+		CROSS JOIN (SELECT 0 AS weekday UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+			UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6) d
+		WHERE m.availability IS NOT NULL AND m.availability NOT IN ('', 'Not Set');
+	ALTER TABLE members DROP COLUMN availability;`)
+	return err
+}
+
+// migrateMemberTimeZone adds the member's IANA time zone; an empty zone means UTC. Shared with PostgreSQL.
+func migrateMemberTimeZone(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE members ADD COLUMN time_zone TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// migrateAvailabilitySlots adds the per-guild availability slot set. Guilds without rows offer
+// DefaultAvailabilitySlots. Shared with PostgreSQL.
+func migrateAvailabilitySlots(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE availability_slots (
+		guild_id TEXT NOT NULL,
+		label TEXT NOT NULL,
+		start_minute INTEGER NOT NULL,
+		end_minute INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, label)
+	)`)
+	return err
+}
+
+func migrateWars(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE wars (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		starts_at INTEGER NOT NULL,
+		slot TEXT NOT NULL DEFAULT '',
+		opponent TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		channel_id TEXT NOT NULL DEFAULT '',
+		message_id TEXT NOT NULL DEFAULT '',
+		created_by TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX idx_wars_guild ON wars(guild_id, starts_at);
+	CREATE TABLE war_signups (
+		war_id INTEGER NOT NULL,
+		discord_id TEXT NOT NULL,
+		response TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (war_id, discord_id)
+	);`)
+	return err
+}
+
+// migrateWarAttendance adds per-war attendance. Shared with PostgreSQL.
+func migrateWarAttendance(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE war_attendance (
+		war_id BIGINT NOT NULL,
+		discord_id TEXT NOT NULL,
+		status TEXT NOT NULL CHECK (status IN ('present', 'absent', 'excused')),
+		recorded_by TEXT NOT NULL,
+		recorded_at BIGINT NOT NULL,
+		PRIMARY KEY (war_id, discord_id)
+	)`)
+	return err
+}
+
+func migrateWarResults(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE wars ADD COLUMN result TEXT NOT NULL DEFAULT '';
+	ALTER TABLE wars ADD COLUMN score TEXT NOT NULL DEFAULT '';
+	ALTER TABLE wars ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+	CREATE TABLE seasons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_seasons_guild ON seasons(guild_id, started_at);
+	CREATE TABLE season_archive (
+		season_id INTEGER NOT NULL,
+		discord_id TEXT NOT NULL,
+		in_game_name TEXT NOT NULL,
+		character_name TEXT NOT NULL DEFAULT '',
+		resource_key TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		PRIMARY KEY (season_id, discord_id, character_name, resource_key)
+	);`)
+	return err
+}
+
+// migrateGuildSettings adds per-guild settings and tracks the War Orders deducted for each
+// attendance record; NULL means nothing was settled. Shared with PostgreSQL.
+func migrateGuildSettings(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE guild_settings (
+		guild_id TEXT PRIMARY KEY,
+		war_orders_cost INTEGER NOT NULL DEFAULT 0
+	);
+	ALTER TABLE war_attendance ADD COLUMN orders_charged INTEGER;`)
+	return err
+}
+
+// migrateReminders adds the reminder settings, per-member opt-out and a log of who was reminded.
+// Members who already set their availability count it as changed at their last update. Shared
+// with PostgreSQL.
+func migrateReminders(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE guild_settings ADD COLUMN stale_after_days INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE guild_settings ADD COLUMN reminder_days TEXT NOT NULL DEFAULT 'all';
+	ALTER TABLE guild_settings ADD COLUMN reminder_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE guild_settings ADD COLUMN reminder_channel_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE guild_settings ADD COLUMN last_reminder_at BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE members ADD COLUMN availability_updated_at BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE members ADD COLUMN reminders_off INTEGER NOT NULL DEFAULT 0;
+	UPDATE members SET availability_updated_at=updated_at WHERE EXISTS (
+		SELECT 1 FROM member_availability a WHERE a.guild_id=members.guild_id AND a.discord_id=members.discord_id);
+	CREATE TABLE reminder_log (
+		guild_id TEXT NOT NULL,
+		sent_at BIGINT NOT NULL,
+		discord_id TEXT NOT NULL,
+		in_game_name TEXT NOT NULL,
+		stale TEXT NOT NULL,
+		delivered INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, sent_at, discord_id)
+	);`)
+	return err
+}
+
+// migrate brings the database up to the set's latest version. It refuses to run against a
+// database stamped with a newer version than this binary knows about.
+func migrate(db *sql.DB, set migrationSet, env migrationEnv) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at BIGINT NOT NULL
+	)`); err != nil {
+		return err
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current == 0 && set.stampLegacy != nil {
+		if current, err = set.stampLegacy(db, set); err != nil {
+			return fmt.Errorf("detect legacy schema: %w", err)
+		}
+	}
+	if latest := set.latest(); current > latest {
+		return fmt.Errorf("database schema version %d is newer than this binary supports (%d); upgrade wartracker", current, latest)
+	}
+	for _, m := range set.migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, set, m, env); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, set migrationSet, m migration, env migrationEnv) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := m.up(tx, env); err != nil {
+		return err
+	}
+	if err := stampVersion(tx, set.dialect, m); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// stampVersion records m as applied.
+func stampVersion(e execer, dia dialect, m migration) error {
+	_, err := e.Exec(dia.bind(`INSERT INTO schema_migrations(version, name, applied_at) VALUES(?,?,?)`), m.version, m.name, time.Now().Unix())
+	return err
+}
+
+func schemaVersion(db *sql.DB) (int, error) {
+	var v sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&v); err != nil {
+		return 0, err
+	}
+	return int(v.Int64), nil
+}
+
+// stampLegacySQLite detects SQLite databases created before schema_migrations existed and records
+// the version their tables already match, so only the missing migrations run.
+func stampLegacySQLite(db *sql.DB, set migrationSet) (int, error) {
+	var name string
+	err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type='table' AND name='members'`).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	cols, err := memberColumns(db)
+	if err != nil {
+		return 0, err
+	}
+	version := 1
+	switch {
+	case cols["guild_id"]:
+		version = 3
+	case cols["guild_role_id"]:
+		version = 2
+	}
+	// Older builds created leader alongside members; make sure it is there before stamping.
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS leader (
+		id INTEGER PRIMARY KEY CHECK (id=1),
+		owner TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`); err != nil {
+		return 0, err
+	}
+	for _, m := range set.migrations {
+		if m.version > version {
+			break
+		}
+		if err := stampVersion(db, set.dialect, m); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+// memberColumns returns the set of column names currently on the members table.
+func memberColumns(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(`PRAGMA table_info(members);`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		cols[name] = true
+	}
+	return cols, rows.Err()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// migrationRows returns the versions recorded in schema_migrations, in order.
+func migrationRows(t *testing.T, db *DB) []int {
+	t.Helper()
+	rows, err := db.conn.Query(`SELECT version FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fresh.db")
+	db, err := NewConnection(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := migrationRows(t, db)
+	if len(versions) != sqliteMigrationSet.latest() || versions[len(versions)-1] != sqliteMigrationSet.latest() {
+		t.Fatalf("recorded versions %v, want 1..%d", versions, sqliteMigrationSet.latest())
+	}
+	db.Close()
+
+	// Opening an up-to-date database again must not re-run anything
+	db, err = NewConnection(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if again := migrationRows(t, db); len(again) != len(versions) {
+		t.Fatalf("recorded versions after reopening %v, want %v", again, versions)
+	}
+}
+
+func TestMigrateStampsLegacySchema(t *testing.T) {
+	// A database from before schema_migrations, with guild_role_id but no guild_id, is version 2
+	path := createLegacyDB(t, "1")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`ALTER TABLE members ADD COLUMN guild_role_id TEXT DEFAULT 'role-1'`); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	db, err := NewConnection(path, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if versions := migrationRows(t, db); len(versions) != sqliteMigrationSet.latest() {
+		t.Fatalf("recorded versions %v, want 1..%d", versions, sqliteMigrationSet.latest())
+	}
+	members, err := db.GetAllMembers(t.Context(), "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].GuildRoleID != "role-1" {
+		t.Fatalf("members after migrating = %+v, want the role kept", members)
+	}
+}
+
+func TestMigrateRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newer.db")
+	db, err := NewConnection(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, 'from the future', 0)`, sqliteMigrationSet.latest()+1); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	if _, err := NewConnection(path, ""); err == nil || !strings.Contains(err.Error(), "newer than this binary supports") {
+		t.Fatalf("opening a newer schema = %v, want it refused", err)
+	}
+}
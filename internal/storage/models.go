@@ -1,11 +1,310 @@
 package storage
 
+import "time"
+
 // Member maps to the members table.
 type Member struct {
+	GuildID      string
 	DiscordID    string
 	InGameName   string
-	WarOrders    int
-	Lumber       int
-	Availability string
+	Resources    map[string]int // main character's amounts by resource key; missing keys are 0
+	Availability Schedule
+	TimeZone     string // IANA zone name; empty means UTC
 	GuildRoleID  string
+	UpdatedAt    time.Time // last name, resource or availability change; zero if never recorded
+}
+
+// Character maps to the characters table: an additional in-game account (alt) owned by a member.
+// The member's own InGameName and Resources describe their main character.
+type Character struct {
+	GuildID   string
+	DiscordID string
+	Name      string
+	Resources map[string]int // amounts by resource key; missing keys are 0
+	UpdatedAt time.Time
+}
+
+// ImportMember is one validated roster row for ImportMembers. Nil or missing fields leave the stored value unchanged.
+type ImportMember struct {
+	DiscordID    string
+	InGameName   string
+	Resources    map[string]int // resource key -> amount to set
+	Availability Schedule       // replaces the whole week when non-nil
+}
+
+// Schedule is a member's weekly availability from the member_availability table: the slots they
+// can play on each weekday. Days without slots are not set.
+type Schedule map[time.Weekday][]string
+
+// AvailabilitySlot is a time window members can pick for a weekday. Start and End are minutes
+// after midnight UTC; an End at or before Start ends on the next day. Label is the value stored
+// in member schedules.
+type AvailabilitySlot struct {
+	Label string
+	Start int
+	End   int
+}
+
+// Duration returns the slot's length.
+func (s AvailabilitySlot) Duration() time.Duration {
+	d := (s.End - s.Start + 24*60) % (24 * 60)
+	if d == 0 {
+		d = 24 * 60
+	}
+	return time.Duration(d) * time.Minute
+}
+
+// DefaultAvailabilitySlots returns the four 2-hour evening windows Wartracker has always offered.
+func DefaultAvailabilitySlots() []AvailabilitySlot {
+	return []AvailabilitySlot{
+		{Label: "16:00-18:00 GMT", Start: 16 * 60, End: 18 * 60},
+		{Label: "18:00-20:00 GMT", Start: 18 * 60, End: 20 * 60},
+		{Label: "20:00-22:00 GMT", Start: 20 * 60, End: 22 * 60},
+		{Label: "22:00-00:00 GMT", Start: 22 * 60, End: 0},
+	}
+}
+
+// Keys of the default resource types, which guilds track until they configure their own.
+const (
+	ResourceOrders = "orders"
+	ResourceLumber = "lumber"
+)
+
+// ResourceType maps to the resource_types table: a resource a guild tracks for every character.
+// In config.json it is written as {"Key", "Name", "Emoji", "Min", "Max"}.
+type ResourceType struct {
+	GuildID string `json:"-"`
+	Key     string `json:"Key"`
+	Name    string `json:"Name"`
+	Emoji   string `json:"Emoji,omitempty"`
+	Bounds
+	Position int `json:"-"`
+}
+
+// Label returns the display name prefixed with the emoji, if any.
+func (t ResourceType) Label() string {
+	if t.Emoji == "" {
+		return t.Name
+	}
+	return t.Emoji + " " + t.Name
+}
+
+// DefaultResourceTypes returns the resources Wartracker has always tracked: War Orders and Lumber.
+func DefaultResourceTypes(guildID string) []ResourceType {
+	return []ResourceType{
+		{GuildID: guildID, Key: ResourceOrders, Name: "War Orders", Emoji: "📜", Bounds: DefaultBounds, Position: 0},
+		{GuildID: guildID, Key: ResourceLumber, Name: "Lumber", Emoji: "🪵", Bounds: DefaultBounds, Position: 1},
+	}
+}
+
+// Bounds is the inclusive range a resource amount must stay within.
+type Bounds struct {
+	Min int `json:"Min"`
+	Max int `json:"Max"`
+}
+
+// DefaultBounds applies when a guild configures none: no negative amounts, and a ceiling well
+// inside the range of a 32-bit INTEGER column.
+var DefaultBounds = Bounds{Min: 0, Max: 1_000_000_000}
+
+// Contains reports whether v is within the bounds.
+func (b Bounds) Contains(v int) bool { return v >= b.Min && v <= b.Max }
+
+// HistoryEntry maps to a resource_history row joined with the member's current name.
+type HistoryEntry struct {
+	GuildID    string
+	DiscordID  string
+	InGameName string
+	Character  string // alt name; empty for the member's main character
+	Resource   string
+	OldValue   int
+	NewValue   int
+	ActorID    string
+	ChangedAt  time.Time
+}
+
+// Tier is a permission level. Higher tiers include everything lower tiers may do.
+type Tier int
+
+const (
+	TierNone Tier = iota
+	TierMember
+	TierOfficer
+	TierLeader
+)
+
+var tierNames = map[Tier]string{
+	TierNone:    "none",
+	TierMember:  "member",
+	TierOfficer: "officer",
+	TierLeader:  "leader",
+}
+
+func (t Tier) String() string {
+	if n, ok := tierNames[t]; ok {
+		return n
+	}
+	return "unknown"
+}
+
+// ParseTier maps a stored tier name back to a Tier.
+func ParseTier(name string) (Tier, bool) {
+	for t, n := range tierNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return TierNone, false
+}
+
+// Permission subject types.
+const (
+	SubjectUser = "user"
+	SubjectRole = "role"
+)
+
+// PermissionGrant maps to the permissions table: a tier granted to a user or role in a guild.
+type PermissionGrant struct {
+	GuildID     string
+	SubjectType string
+	SubjectID   string
+	Tier        Tier
+}
+
+// War statuses. Members can change their signup only while a war is open; a war is complete
+// once its result is recorded.
+const (
+	WarOpen     = "open"
+	WarLocked   = "locked"
+	WarComplete = "complete"
+)
+
+// War results.
+const (
+	ResultWin  = "win"
+	ResultLoss = "loss"
+	ResultDraw = "draw"
+)
+
+// Signup responses from the war message buttons.
+const (
+	SignupJoin    = "join"
+	SignupMaybe   = "maybe"
+	SignupDecline = "decline"
+)
+
+// War maps to the wars table: a scheduled war and the public message members sign up on.
+type War struct {
+	ID        int64
+	GuildID   string
+	StartsAt  time.Time
+	Slot      string // availability slot label; empty if the war starts outside the guild's slots
+	Opponent  string
+	Status    string
+	ChannelID string
+	MessageID string
+	CreatedBy string
+	CreatedAt time.Time
+	Result    string // empty until the war is complete
+	Score     string
+	Notes     string
+}
+
+// WarSignup maps to the war_signups table: one member's answer to a war.
+type WarSignup struct {
+	WarID     int64
+	DiscordID string
+	Response  string
+	UpdatedAt time.Time
+}
+
+// Attendance statuses recorded for a war after it starts.
+const (
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+	AttendanceExcused = "excused"
+)
+
+// Attendance maps to the war_attendance table: whether a member showed up for a war. GetAttendance
+// and ListAttendance fill in the war's start, the member's in-game name and their signup response.
+type Attendance struct {
+	WarID       int64
+	DiscordID   string
+	Status      string
+	RecordedBy  string
+	RecordedAt  time.Time
+	WarStartsAt time.Time
+	InGameName  string
+	Signup      string // signup response, empty if the member never answered
+}
+
+// GuildSettings maps to the guild_settings table: rules leaders set with /settings. The zero
+// value is what guilds that never changed a setting get.
+type GuildSettings struct {
+	GuildID string
+	// WarOrdersCost is deducted from the main character of each member present when a war is
+	// completed; 0 turns the rule off.
+	WarOrdersCost int
+	// StaleAfterDays is how long a member's resources or availability may go unchanged before
+	// they are reminded; 0 turns reminders off.
+	StaleAfterDays int
+	// ReminderDays is when reminders go out, as a /availability day option: "all", "weekdays",
+	// "weekend" or a weekday such as "mon".
+	ReminderDays string
+	// ReminderAt is the time of day reminders go out, in minutes after midnight UTC.
+	ReminderAt int
+	// ReminderChannelID is where reminders ping members; empty means they are sent as DMs.
+	ReminderChannelID string
+	LastReminderAt    time.Time // zero until reminders first run
+}
+
+// MemberActivity is when a member last changed each kind of data, for stale-data reminders.
+type MemberActivity struct {
+	DiscordID    string
+	InGameName   string
+	Resources    map[string]time.Time // latest update per resource key over main and alts; missing keys were never set
+	Availability time.Time            // zero if never set
+	RemindersOff bool
+}
+
+// ReminderLog maps to the reminder_log table: one member reminded in a run.
+type ReminderLog struct {
+	GuildID    string
+	DiscordID  string
+	InGameName string
+	Stale      []string // what was stale: resource keys and "availability"
+	Delivered  bool     // false if the DM or channel message could not be sent
+	SentAt     time.Time
+}
+
+// ReminderAvailability stands for a member's schedule in ReminderLog.Stale.
+const ReminderAvailability = "availability"
+
+// Season maps to the seasons table: a named period wars roll up into. A guild has at most one
+// active season, whose EndedAt is zero.
+type Season struct {
+	ID        int64
+	GuildID   string
+	Name      string
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// Active reports whether the season has not ended yet.
+func (s Season) Active() bool { return s.EndedAt.IsZero() }
+
+// Contains reports whether a war starting at t belongs to the season.
+func (s Season) Contains(t time.Time) bool {
+	return !t.Before(s.StartedAt) && (s.Active() || t.Before(s.EndedAt))
+}
+
+// ArchivedResource maps to the season_archive table: one resource amount of a member's main
+// (Character "") or alt, snapshotted when the season ended.
+type ArchivedResource struct {
+	SeasonID   int64
+	DiscordID  string
+	InGameName string
+	Character  string
+	Resource   string
+	Amount     int
 }
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// GrantPermission assigns a tier to a user or role, replacing any previous grant for that subject.
+func (d *DB) GrantPermission(ctx context.Context, guildID, subjectType, subjectID string, tier Tier) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO permissions(guild_id, subject_type, subject_id, tier) VALUES(?,?,?,?)
+		ON CONFLICT(guild_id, subject_type, subject_id) DO UPDATE SET tier=excluded.tier`), guildID, subjectType, subjectID, tier.String())
+	return err
+}
+
+// RevokePermission removes the grant for a user or role.
+func (d *DB) RevokePermission(ctx context.Context, guildID, subjectType, subjectID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.conn.ExecContext(ctx, d.q(`DELETE FROM permissions WHERE guild_id=? AND subject_type=? AND subject_id=?`), guildID, subjectType, subjectID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoPermissionGrant
+	}
+	return nil
+}
+
+// ListPermissions returns every grant in a guild, highest tier first.
+func (d *DB) ListPermissions(ctx context.Context, guildID string) ([]PermissionGrant, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT guild_id, subject_type, subject_id, tier FROM permissions WHERE guild_id=?
+		ORDER BY CASE tier WHEN 'leader' THEN 0 WHEN 'officer' THEN 1 ELSE 2 END, subject_type, subject_id`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []PermissionGrant
+	for rows.Next() {
+		var g PermissionGrant
+		var tier string
+		if err := rows.Scan(&g.GuildID, &g.SubjectType, &g.SubjectID, &tier); err != nil {
+			return nil, err
+		}
+		t, ok := ParseTier(tier)
+		if !ok {
+			return nil, fmt.Errorf("unknown tier %q for %s %s", tier, g.SubjectType, g.SubjectID)
+		}
+		g.Tier = t
+		list = append(list, g)
+	}
+	return list, rows.Err()
+}
+
+// SeedPermissions inserts grants for a guild that has none yet, so config-provided leaders
+// bootstrap the tier table without overriding later /perm changes.
+func (d *DB) SeedPermissions(ctx context.Context, guildID string, grants []PermissionGrant) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM permissions WHERE guild_id=?`), guildID).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	for _, g := range grants {
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO permissions(guild_id, subject_type, subject_id, tier) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`),
+			guildID, g.SubjectType, g.SubjectID, g.Tier.String()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
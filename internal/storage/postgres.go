@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+// leaderLockKey is the pg_advisory_lock key guarding the active bot instance ("WART").
+const leaderLockKey int64 = 0x57415254
+
+// NewPostgresConnection opens a PostgreSQL database from a DSN/URL and applies pending schema migrations.
+func NewPostgresConnection(dsn string) (*DB, error) {
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := WithTimeout(context.Background())
+	defer cancel()
+	if err := conn.PingContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("connect postgres: %w", err)
+	}
+	if err := migrate(conn, postgresMigrationSet, migrationEnv{}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &DB{conn: conn, dialect: dialectPostgres}, nil
+}
+
+var postgresMigrationSet = migrationSet{migrations: postgresMigrations, dialect: dialectPostgres}
+
+// postgresMigrations is the ordered list of PostgreSQL up-migrations. PostgreSQL deployments
+// never ran the pre-guild SQLite schemas, so version 1 starts from the guild-scoped layout.
+// Append only; never renumber or edit a released entry.
+var postgresMigrations = []migration{
+	{version: 1, name: "initial schema", up: pgMigrateInitial},
+	{version: 2, name: "members updated_at", up: pgMigrateMemberUpdatedAt},
+	{version: 3, name: "characters", up: migrateCharacters},
+	{version: 4, name: "per-guild resource types", up: migrateResourceTypes},
+	{version: 5, name: "weekly availability", up: migrateWeeklyAvailability},
+	{version: 6, name: "members time_zone", up: migrateMemberTimeZone},
+	{version: 7, name: "per-guild availability slots", up: migrateAvailabilitySlots},
+	{version: 8, name: "wars and signups", up: pgMigrateWars},
+	{version: 9, name: "war attendance", up: migrateWarAttendance},
+	{version: 10, name: "war results and seasons", up: pgMigrateWarResults},
+	{version: 11, name: "guild settings", up: migrateGuildSettings},
+	{version: 12, name: "reminders", up: migrateReminders},
+}
+
+func pgMigrateInitial(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE members (
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		in_game_name TEXT NOT NULL,
+		war_orders INTEGER DEFAULT 0,
+		lumber INTEGER DEFAULT 0,
+		availability TEXT DEFAULT 'Not Set',
+		guild_role_id TEXT DEFAULT '',
+		PRIMARY KEY (guild_id, discord_id)
+	);
+	CREATE TABLE resource_history (
+		id BIGSERIAL PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		discord_id TEXT NOT NULL,
+		resource TEXT NOT NULL,
+		old_value INTEGER NOT NULL,
+		new_value INTEGER NOT NULL,
+		actor_id TEXT NOT NULL,
+		changed_at BIGINT NOT NULL
+	);
+	CREATE INDEX idx_resource_history_member ON resource_history(guild_id, discord_id, changed_at);
+	CREATE TABLE permissions (
+		guild_id TEXT NOT NULL,
+		subject_type TEXT NOT NULL CHECK (subject_type IN ('user', 'role')),
+		subject_id TEXT NOT NULL,
+		tier TEXT NOT NULL,
+		PRIMARY KEY (guild_id, subject_type, subject_id)
+	);`)
+	return err
+}
+
+func pgMigrateMemberUpdatedAt(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE members ADD COLUMN updated_at BIGINT NOT NULL DEFAULT 0`)
+	return err
+}
+
+func pgMigrateWars(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`CREATE TABLE wars (
+		id BIGSERIAL PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		starts_at BIGINT NOT NULL,
+		slot TEXT NOT NULL DEFAULT '',
+		opponent TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		channel_id TEXT NOT NULL DEFAULT '',
+		message_id TEXT NOT NULL DEFAULT '',
+		created_by TEXT NOT NULL,
+		created_at BIGINT NOT NULL
+	);
+	CREATE INDEX idx_wars_guild ON wars(guild_id, starts_at);
+	CREATE TABLE war_signups (
+		war_id BIGINT NOT NULL,
+		discord_id TEXT NOT NULL,
+		response TEXT NOT NULL,
+		updated_at BIGINT NOT NULL,
+		PRIMARY KEY (war_id, discord_id)
+	);`)
+	return err
+}
+
+func pgMigrateWarResults(tx *sql.Tx, _ migrationEnv) error {
+	_, err := tx.Exec(`ALTER TABLE wars ADD COLUMN result TEXT NOT NULL DEFAULT '';
+	ALTER TABLE wars ADD COLUMN score TEXT NOT NULL DEFAULT '';
+	ALTER TABLE wars ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+	CREATE TABLE seasons (
+		id BIGSERIAL PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		started_at BIGINT NOT NULL,
+		ended_at BIGINT NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_seasons_guild ON seasons(guild_id, started_at);
+	CREATE TABLE season_archive (
+		season_id BIGINT NOT NULL,
+		discord_id TEXT NOT NULL,
+		in_game_name TEXT NOT NULL,
+		character_name TEXT NOT NULL DEFAULT '',
+		resource_key TEXT NOT NULL,
+		amount INTEGER NOT NULL,
+		PRIMARY KEY (season_id, discord_id, character_name, resource_key)
+	);`)
+	return err
+}
+
+// pgTryAcquireLeader takes a session-level advisory lock on a dedicated connection. The lock is
+// released by PostgreSQL when that session ends, so a crashed leader never needs a stale-lease takeover.
+// Callers hold d.mu.
+func (d *DB) pgTryAcquireLeader(ctx context.Context) (bool, error) {
+	if d.leaderConn != nil {
+		return true, nil
+	}
+	c, err := d.conn.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	var ok bool
+	if err := c.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, leaderLockKey).Scan(&ok); err != nil {
+		_ = c.Close()
+		return false, err
+	}
+	if !ok {
+		_ = c.Close()
+		return false, nil
+	}
+	d.leaderConn = c
+	return true, nil
+}
+
+// pgRenewLeader checks the lock-holding session is still alive. Callers hold d.mu.
+func (d *DB) pgRenewLeader(ctx context.Context) error {
+	if d.leaderConn == nil {
+		return ErrLeadershipLost
+	}
+	if err := d.leaderConn.PingContext(ctx); err != nil {
+		_ = d.leaderConn.Close()
+		d.leaderConn = nil
+		return fmt.Errorf("%w: %v", ErrLeadershipLost, err)
+	}
+	return nil
+}
+
+// pgReleaseLeader unlocks and returns the dedicated session to the pool. Callers hold d.mu.
+func (d *DB) pgReleaseLeader(ctx context.Context) error {
+	if d.leaderConn == nil {
+		return nil
+	}
+	_, err := d.leaderConn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, leaderLockKey)
+	_ = d.leaderConn.Close()
+	d.leaderConn = nil
+	return err
+}
+
+// pgLeaderStatus reports whether any session holds the leader advisory lock. Callers hold d.mu.
+func (d *DB) pgLeaderStatus(ctx context.Context) (string, bool, error) {
+	if d.leaderConn != nil {
+		return "", true, nil
+	}
+	var held bool
+	// A bigint advisory key is split across classid (high 32 bits) and objid (low 32 bits), with objsubid 1.
+	err := d.conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pg_locks WHERE locktype='advisory'
+		AND classid=$1 AND objid=$2 AND objsubid=1 AND granted)`, leaderLockKey>>32, leaderLockKey&0xFFFFFFFF).Scan(&held)
+	return "", held, err
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"os"
+	"testing"
+)
+
+// testDSNEnv names the PostgreSQL database the tests run against, e.g. the container from
+// docker-compose.postgres.yml. PostgreSQL tests are skipped when it is unset. Tests use fresh
+// guild IDs, so the database may be reused, but no bot may be running against it.
+const testDSNEnv = "WARTRACKER_TEST_DSN"
+
+func openPostgres(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv + " is not set")
+	}
+	db, err := NewPostgresConnection(dsn)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestPostgresMigrations(t *testing.T) {
+	db := openPostgres(t)
+	versions := migrationRows(t, db)
+	if want := postgresMigrationSet.latest(); len(versions) == 0 || versions[len(versions)-1] != want {
+		t.Fatalf("recorded versions %v, want up to %d", versions, want)
+	}
+	// Opening an up-to-date database again must not re-run anything
+	again := openPostgres(t)
+	if got := migrationRows(t, again); len(got) != len(versions) {
+		t.Fatalf("recorded versions after reopening %v, want %v", got, versions)
+	}
+}
+
+func TestPostgresMemberRoles(t *testing.T) {
+	db := openPostgres(t)
+	ctx, g := t.Context(), testGuildID()
+	must(t, db.UpsertMember(ctx, g, "1", "alpha"))
+	must(t, db.UpdateMemberRole(ctx, g, "1", "role-1"))
+	wantErr(t, db.UpdateMemberRole(ctx, g, "2", "role-1"), ErrMemberNotRegistered)
+	must(t, db.UpsertMember(ctx, g, "1", "renamed"))
+	if m, ok := findMember(t, db, g, "1"); !ok || m.InGameName != "renamed" || m.GuildRoleID != "role-1" {
+		t.Fatalf("member = %+v, want renamed with its role kept", m)
+	}
+	must(t, db.DeleteMember(ctx, g, "1"))
+	if ok, err := db.EnsureMemberExists(ctx, g, "1"); ok || err != nil {
+		t.Fatalf("EnsureMemberExists after delete = %v, %v", ok, err)
+	}
+}
+
+func TestPostgresLeaderLock(t *testing.T) {
+	a, b := openPostgres(t), openPostgres(t)
+	ctx := t.Context()
+	if ok, err := a.TryAcquireLeader(ctx, "a", 0, false); !ok || err != nil {
+		t.Fatalf("a acquire = %v, %v", ok, err)
+	}
+	if ok, err := b.TryAcquireLeader(ctx, "b", 0, true); ok || err != nil {
+		t.Fatalf("b acquire while a holds the lock = %v, %v", ok, err)
+	}
+	must(t, a.RenewLeader(ctx, "a"))
+	wantErr(t, b.RenewLeader(ctx, "b"), ErrLeadershipLost)
+
+	must(t, a.ReleaseLeader(ctx, "a"))
+	wantErr(t, a.RenewLeader(ctx, "a"), ErrLeadershipLost)
+	if ok, err := b.TryAcquireLeader(ctx, "b", 0, false); !ok || err != nil {
+		t.Fatalf("b acquire after a released = %v, %v", ok, err)
+	}
+	// Closing the leader's connection ends its session, which releases the lock
+	must(t, b.Close())
+	if ok, err := a.TryAcquireLeader(ctx, "a", 0, false); !ok || err != nil {
+		t.Fatalf("a acquire after b closed = %v, %v", ok, err)
+	}
+	must(t, a.ReleaseLeader(ctx, "a"))
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// reminderLogRetention is how long reminder_log keeps a run before RecordReminderRun prunes it.
+const reminderLogRetention = 90 * 24 * time.Hour
+
+// ListMemberActivity returns when each roster member last changed their resources and availability.
+func (d *DB) ListMemberActivity(ctx context.Context, guildID string) ([]MemberActivity, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT discord_id, in_game_name, availability_updated_at, reminders_off FROM members
+		WHERE guild_id=? ORDER BY LOWER(in_game_name), discord_id`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []MemberActivity
+	index := make(map[string]int)
+	for rows.Next() {
+		var a MemberActivity
+		var availability int64
+		var off int
+		if err := rows.Scan(&a.DiscordID, &a.InGameName, &availability, &off); err != nil {
+			return nil, err
+		}
+		a.Availability, a.RemindersOff = unixTime(availability), off != 0
+		a.Resources = make(map[string]time.Time)
+		index[a.DiscordID] = len(list)
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	res, err := d.conn.QueryContext(ctx, d.q(`SELECT discord_id, resource_key, MAX(updated_at) FROM member_resources
+		WHERE guild_id=? AND updated_at>0 GROUP BY discord_id, resource_key`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	for res.Next() {
+		var discordID, key string
+		var ts int64
+		if err := res.Scan(&discordID, &key, &ts); err != nil {
+			return nil, err
+		}
+		if n, ok := index[discordID]; ok {
+			list[n].Resources[key] = unixTime(ts)
+		}
+	}
+	return list, res.Err()
+}
+
+// SetRemindersOff opts a member out of stale-data reminders, or back in.
+func (d *DB) SetRemindersOff(ctx context.Context, guildID, discordID string, off bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	v := 0
+	if off {
+		v = 1
+	}
+	res, err := d.conn.ExecContext(ctx, d.q(`UPDATE members SET reminders_off=? WHERE guild_id=? AND discord_id=?`), v, guildID, discordID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMemberNotRegistered
+	}
+	return nil
+}
+
+// RecordReminderRun logs who a reminder run at at reached and marks it as the guild's last run.
+// Runs older than reminderLogRetention are pruned.
+func (d *DB) RecordReminderRun(ctx context.Context, guildID string, at time.Time, sent []ReminderLog) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	for _, r := range sent {
+		delivered := 0
+		if r.Delivered {
+			delivered = 1
+		}
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO reminder_log(guild_id, sent_at, discord_id, in_game_name, stale, delivered) VALUES(?,?,?,?,?,?)`),
+			guildID, at.Unix(), r.DiscordID, r.InGameName, strings.Join(r.Stale, ","), delivered); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO guild_settings(guild_id, last_reminder_at) VALUES(?,?)
+		ON CONFLICT(guild_id) DO UPDATE SET last_reminder_at=excluded.last_reminder_at`), guildID, at.Unix()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM reminder_log WHERE guild_id=? AND sent_at<?`), guildID, at.Add(-reminderLogRetention).Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetLastReminderRun returns who the guild's last reminder run reached, by in-game name.
+func (d *DB) GetLastReminderRun(ctx context.Context, guildID string) ([]ReminderLog, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT r.guild_id, r.discord_id, r.in_game_name, r.stale, r.delivered, r.sent_at FROM reminder_log r
+		JOIN guild_settings s ON s.guild_id=r.guild_id AND s.last_reminder_at=r.sent_at
+		WHERE r.guild_id=? ORDER BY LOWER(r.in_game_name), r.discord_id`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []ReminderLog
+	for rows.Next() {
+		var r ReminderLog
+		var stale string
+		var delivered int
+		var ts int64
+		if err := rows.Scan(&r.GuildID, &r.DiscordID, &r.InGameName, &stale, &delivered, &ts); err != nil {
+			return nil, err
+		}
+		r.Stale, r.Delivered, r.SentAt = strings.Split(stale, ","), delivered != 0, unixTime(ts)
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+)
+
+// resourceOwner identifies whose amounts a member_resources row holds: a member's main
+// character (character "") or one of their alts.
+type resourceOwner struct{ discordID, character string }
+
+// loadResources reads amounts for every character in a guild, or one member's characters when
+// discordID is set. Callers hold d.mu.
+func (d *DB) loadResources(ctx context.Context, guildID, discordID string) (map[resourceOwner]map[string]int, error) {
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT discord_id, character_name, resource_key, amount FROM member_resources
+		WHERE guild_id=? AND (CAST(? AS TEXT)='' OR discord_id=?)`), guildID, discordID, discordID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[resourceOwner]map[string]int)
+	for rows.Next() {
+		var o resourceOwner
+		var key string
+		var amount int
+		if err := rows.Scan(&o.discordID, &o.character, &key, &amount); err != nil {
+			return nil, err
+		}
+		if out[o] == nil {
+			out[o] = make(map[string]int)
+		}
+		out[o][key] = amount
+	}
+	return out, rows.Err()
+}
+
+// SetResource sets a resource on the member's main character (character "") or the named alt
+// and records the change in resource_history.
+func (d *DB) SetResource(ctx context.Context, guildID, discordID, character, actorID, resource string, amount int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := d.setResourceTx(ctx, tx, guildID, discordID, character, actorID, resource, amount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkOwnerTx returns ErrMemberNotRegistered or ErrCharacterNotFound if the character does not exist.
+func (d *DB) checkOwnerTx(ctx context.Context, tx *sql.Tx, guildID, discordID, character string) error {
+	var one int
+	err := tx.QueryRowContext(ctx, d.q(`SELECT 1 FROM members WHERE guild_id=? AND discord_id=?`), guildID, discordID).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMemberNotRegistered
+	}
+	if err != nil || character == "" {
+		return err
+	}
+	err = tx.QueryRowContext(ctx, d.q(`SELECT 1 FROM characters WHERE guild_id=? AND discord_id=? AND name=?`), guildID, discordID, character).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCharacterNotFound
+	}
+	return err
+}
+
+// setResourceTx overwrites one amount and records the ledger entry inside tx.
+func (d *DB) setResourceTx(ctx context.Context, tx *sql.Tx, guildID, discordID, character, actorID, resource string, amount int) error {
+	if err := d.checkOwnerTx(ctx, tx, guildID, discordID, character); err != nil {
+		return err
+	}
+	var old int
+	err := tx.QueryRowContext(ctx, d.q(`SELECT amount FROM member_resources WHERE guild_id=? AND discord_id=? AND character_name=? AND resource_key=?`),
+		guildID, discordID, character, resource).Scan(&old)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	now := time.Now().Unix()
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key, amount, updated_at) VALUES(?,?,?,?,?,?)
+		ON CONFLICT(guild_id, discord_id, character_name, resource_key) DO UPDATE SET amount=excluded.amount, updated_at=excluded.updated_at`),
+		guildID, discordID, character, resource, amount, now); err != nil {
+		return err
+	}
+	return d.recordChangeTx(ctx, tx, guildID, discordID, character, actorID, resource, old, amount, now)
+}
+
+// AdjustResource adds delta (negative to subtract) to a resource on the main character or an alt and
+// returns the new amount. The change is applied in SQL, so concurrent adjustments never lose updates.
+// If the result would fall outside bounds nothing changes, and the current amount is returned
+// with ErrOutOfBounds.
+func (d *DB) AdjustResource(ctx context.Context, guildID, discordID, character, actorID, resource string, delta int, bounds Bounds) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if err := d.checkOwnerTx(ctx, tx, guildID, discordID, character); err != nil {
+		return 0, err
+	}
+	key := []any{guildID, discordID, character, resource}
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO member_resources(guild_id, discord_id, character_name, resource_key) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`), key...); err != nil {
+		return 0, err
+	}
+	now := time.Now().Unix()
+	var amount int
+	err = tx.QueryRowContext(ctx, d.q(`UPDATE member_resources SET amount=amount+?, updated_at=?
+		WHERE guild_id=? AND discord_id=? AND character_name=? AND resource_key=? AND amount+? BETWEEN ? AND ? RETURNING amount`),
+		append(append([]any{delta, now}, key...), delta, bounds.Min, bounds.Max)...).Scan(&amount)
+	if errors.Is(err, sql.ErrNoRows) {
+		var current int
+		if err := tx.QueryRowContext(ctx, d.q(`SELECT amount FROM member_resources WHERE guild_id=? AND discord_id=? AND character_name=? AND resource_key=?`), key...).Scan(&current); err != nil {
+			return 0, err
+		}
+		return current, ErrOutOfBounds
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := d.recordChangeTx(ctx, tx, guildID, discordID, character, actorID, resource, amount-delta, amount, now); err != nil {
+		return 0, err
+	}
+	return amount, tx.Commit()
+}
+
+// recordChangeTx appends a ledger entry and marks the member (and alt) as updated.
+func (d *DB) recordChangeTx(ctx context.Context, tx *sql.Tx, guildID, discordID, character, actorID, resource string, old, amount int, now int64) error {
+	if _, err := tx.ExecContext(ctx, d.q(`UPDATE members SET updated_at=? WHERE guild_id=? AND discord_id=?`), now, guildID, discordID); err != nil {
+		return err
+	}
+	if character != "" {
+		if _, err := tx.ExecContext(ctx, d.q(`UPDATE characters SET updated_at=? WHERE guild_id=? AND discord_id=? AND name=?`), now, guildID, discordID, character); err != nil {
+			return err
+		}
+	}
+	_, err := tx.ExecContext(ctx, d.q(`INSERT INTO resource_history(guild_id, discord_id, character_name, resource, old_value, new_value, actor_id, changed_at) VALUES(?,?,?,?,?,?,?,?)`),
+		guildID, discordID, character, resource, old, amount, actorID, now)
+	return err
+}
+
+// ListResourceTypes returns the guild's configured resource types in display order. An empty
+// result means the guild has not configured any; callers fall back to DefaultResourceTypes.
+func (d *DB) ListResourceTypes(ctx context.Context, guildID string) ([]ResourceType, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT guild_id, resource_key, name, emoji, min_value, max_value, sort_order FROM resource_types
+		WHERE guild_id=? ORDER BY sort_order, resource_key`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []ResourceType
+	for rows.Next() {
+		var t ResourceType
+		if err := rows.Scan(&t.GuildID, &t.Key, &t.Name, &t.Emoji, &t.Min, &t.Max, &t.Position); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// UpsertResourceType adds a resource type or updates the name, emoji and bounds of an existing one.
+// New types are placed after the existing ones; Position is ignored.
+func (d *DB) UpsertResourceType(ctx context.Context, t ResourceType) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO resource_types(guild_id, resource_key, name, emoji, min_value, max_value, sort_order)
+		VALUES(?,?,?,?,?,?,(SELECT COALESCE(MAX(sort_order), -1)+1 FROM resource_types WHERE guild_id=?))
+		ON CONFLICT(guild_id, resource_key) DO UPDATE SET name=excluded.name, emoji=excluded.emoji, min_value=excluded.min_value, max_value=excluded.max_value`),
+		t.GuildID, t.Key, t.Name, t.Emoji, t.Min, t.Max, t.GuildID)
+	return err
+}
+
+// DeleteResourceType removes a resource type and every stored amount of it. The ledger keeps its history.
+func (d *DB) DeleteResourceType(ctx context.Context, guildID, key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	res, err := tx.ExecContext(ctx, d.q(`DELETE FROM resource_types WHERE guild_id=? AND resource_key=?`), guildID, key)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrResourceTypeNotFound
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM member_resources WHERE guild_id=? AND resource_key=?`), guildID, key); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SeedResourceTypes stores types for a guild that has none yet, keeping their order.
+func (d *DB) SeedResourceTypes(ctx context.Context, guildID string, types []ResourceType) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM resource_types WHERE guild_id=?`), guildID).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	for pos, t := range types {
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO resource_types(guild_id, resource_key, name, emoji, min_value, max_value, sort_order) VALUES(?,?,?,?,?,?,?)`),
+			guildID, t.Key, t.Name, t.Emoji, t.Min, t.Max, pos); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// sortedKeys returns m's keys in order, so batch writes are deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// StartSeason opens a new season named name starting now. It fails with ErrSeasonActive while
+// another season is still running.
+func (d *DB) StartSeason(ctx context.Context, guildID, name string) (Season, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return Season{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM seasons WHERE guild_id=? AND ended_at=0`), guildID).Scan(&n); err != nil {
+		return Season{}, err
+	}
+	if n > 0 {
+		return Season{}, ErrSeasonActive
+	}
+	s := Season{GuildID: guildID, Name: name, StartedAt: time.Now().Truncate(time.Second).UTC()}
+	if err := tx.QueryRowContext(ctx, d.q(`INSERT INTO seasons(guild_id, name, started_at) VALUES(?,?,?) RETURNING id`),
+		guildID, name, s.StartedAt.Unix()).Scan(&s.ID); err != nil {
+		return Season{}, err
+	}
+	return s, tx.Commit()
+}
+
+// EndSeason ends the active season and snapshots every member's resources, mains and alts, into
+// its archive. It fails with ErrNoActiveSeason when no season is running.
+func (d *DB) EndSeason(ctx context.Context, guildID string) (Season, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return Season{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	s, err := scanSeason(tx.QueryRowContext(ctx, d.q(`SELECT id, guild_id, name, started_at, ended_at FROM seasons WHERE guild_id=? AND ended_at=0`), guildID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Season{}, ErrNoActiveSeason
+	}
+	if err != nil {
+		return Season{}, err
+	}
+	s.EndedAt = time.Now().Truncate(time.Second).UTC()
+	if _, err := tx.ExecContext(ctx, d.q(`UPDATE seasons SET ended_at=? WHERE id=?`), s.EndedAt.Unix(), s.ID); err != nil {
+		return Season{}, err
+	}
+	// PostgreSQL types an uncast parameter in a SELECT list as text, which season_id (BIGINT) rejects
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO season_archive(season_id, discord_id, in_game_name, character_name, resource_key, amount)
+		SELECT CAST(? AS BIGINT), r.discord_id, m.in_game_name, r.character_name, r.resource_key, r.amount FROM member_resources r
+		JOIN members m ON m.guild_id=r.guild_id AND m.discord_id=r.discord_id WHERE r.guild_id=?`), s.ID, guildID); err != nil {
+		return Season{}, err
+	}
+	return s, tx.Commit()
+}
+
+func scanSeason(row scanner) (Season, error) {
+	var s Season
+	var startedAt, endedAt int64
+	err := row.Scan(&s.ID, &s.GuildID, &s.Name, &startedAt, &endedAt)
+	s.StartedAt, s.EndedAt = unixTime(startedAt), unixTime(endedAt)
+	return s, err
+}
+
+// ListSeasons returns a guild's seasons, latest first.
+func (d *DB) ListSeasons(ctx context.Context, guildID string) ([]Season, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT id, guild_id, name, started_at, ended_at FROM seasons WHERE guild_id=? ORDER BY started_at DESC, id DESC`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Season
+	for rows.Next() {
+		s, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// GetSeasonArchive returns the resources snapshotted when a season ended, ordered by member and
+// character. It is empty for the active season.
+func (d *DB) GetSeasonArchive(ctx context.Context, guildID string, seasonID int64) ([]ArchivedResource, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT a.season_id, a.discord_id, a.in_game_name, a.character_name, a.resource_key, a.amount FROM season_archive a
+		JOIN seasons s ON s.id=a.season_id WHERE s.guild_id=? AND a.season_id=?
+		ORDER BY a.in_game_name, a.discord_id, a.character_name, a.resource_key`), guildID, seasonID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []ArchivedResource
+	for rows.Next() {
+		var a ArchivedResource
+		if err := rows.Scan(&a.SeasonID, &a.DiscordID, &a.InGameName, &a.Character, &a.Resource, &a.Amount); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
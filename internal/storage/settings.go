@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+const settingsColumns = `guild_id, war_orders_cost, stale_after_days, reminder_days, reminder_at, reminder_channel_id, last_reminder_at`
+
+func scanGuildSettings(row scanner) (GuildSettings, error) {
+	var s GuildSettings
+	var last int64
+	err := row.Scan(&s.GuildID, &s.WarOrdersCost, &s.StaleAfterDays, &s.ReminderDays, &s.ReminderAt, &s.ReminderChannelID, &last)
+	s.LastReminderAt = unixTime(last)
+	return s, err
+}
+
+// GetGuildSettings returns a guild's settings, or the defaults if it never saved any.
+func (d *DB) GetGuildSettings(ctx context.Context, guildID string) (GuildSettings, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, err := scanGuildSettings(d.conn.QueryRowContext(ctx, d.q(`SELECT `+settingsColumns+` FROM guild_settings WHERE guild_id=?`), guildID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return GuildSettings{GuildID: guildID, ReminderDays: "all"}, nil
+	}
+	return s, err
+}
+
+// SaveGuildSettings stores all of a guild's settings except LastReminderAt, which only
+// RecordReminderRun moves.
+func (d *DB) SaveGuildSettings(ctx context.Context, s GuildSettings) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO guild_settings(guild_id, war_orders_cost, stale_after_days, reminder_days, reminder_at, reminder_channel_id)
+		VALUES(?,?,?,?,?,?)
+		ON CONFLICT(guild_id) DO UPDATE SET war_orders_cost=excluded.war_orders_cost, stale_after_days=excluded.stale_after_days,
+		reminder_days=excluded.reminder_days, reminder_at=excluded.reminder_at, reminder_channel_id=excluded.reminder_channel_id`),
+		s.GuildID, s.WarOrdersCost, s.StaleAfterDays, s.ReminderDays, s.ReminderAt, s.ReminderChannelID)
+	return err
+}
+
+// ListGuildSettings returns the settings of every guild that saved any.
+func (d *DB) ListGuildSettings(ctx context.Context) ([]GuildSettings, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, `SELECT `+settingsColumns+` FROM guild_settings ORDER BY guild_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []GuildSettings
+	for rows.Next() {
+		s, err := scanGuildSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
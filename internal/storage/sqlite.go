@@ -11,137 +11,139 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// DB wraps sql.DB for concurrency control.
+// DB wraps sql.DB for concurrency control. It is backed by SQLite (NewConnection) or
+// PostgreSQL (NewPostgresConnection); queries are shared and adapted through the dialect.
 type DB struct {
-	conn *sql.DB
-	mu   sync.RWMutex
+	conn    *sql.DB
+	mu      sync.RWMutex
+	dialect dialect
+	env     migrationEnv
+	// leaderConn holds the PostgreSQL session owning the leader advisory lock, if any.
+	leaderConn *sql.Conn
 }
 
-// NewConnection initializes the SQLite database and creates schema if missing.
-func NewConnection(path string) (*DB, error) {
+// NewConnection initializes the SQLite database and applies pending schema migrations.
+// legacyGuildID is assigned to member rows created before rosters were scoped per guild.
+func NewConnection(path, legacyGuildID string) (*DB, error) {
 	conn, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_foreign_keys=ON")
 	if err != nil {
 		return nil, err
 	}
 	conn.SetMaxOpenConns(1)
-	if err := initSchema(conn); err != nil {
+	env := migrationEnv{legacyGuildID: legacyGuildID}
+	if err := migrate(conn, sqliteMigrationSet, env); err != nil {
+		_ = conn.Close()
 		return nil, err
 	}
 	// Best-effort WAL for robustness and online backups
 	_, _ = conn.Exec(`PRAGMA journal_mode=WAL;`)
-	return &DB{conn: conn}, nil
-}
-
-func initSchema(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS members (
-		discord_id TEXT PRIMARY KEY,
-		in_game_name TEXT NOT NULL,
-		war_orders INTEGER DEFAULT 0,
-		lumber INTEGER DEFAULT 0,
-	availability TEXT DEFAULT 'Not Set',
-	guild_role_id TEXT DEFAULT ''
-	);
-	CREATE TABLE IF NOT EXISTS leader (
-		id INTEGER PRIMARY KEY CHECK (id=1),
-		owner TEXT NOT NULL,
-		updated_at INTEGER NOT NULL
-	);`)
-	if err != nil {
-		return err
-	}
-	// Ensure column exists for older databases
-	return ensureGuildRoleColumn(db)
-}
-
-func ensureGuildRoleColumn(db *sql.DB) error {
-	rows, err := db.Query(`PRAGMA table_info(members);`)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var cid int
-		var name, ctype string
-		var notnull, pk int
-		var dflt sql.NullString
-		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
-			return err
-		}
-		if name == "guild_role_id" {
-			return nil
-		}
-	}
-	_, err = db.Exec(`ALTER TABLE members ADD COLUMN guild_role_id TEXT DEFAULT ''`)
-	return err
+	return &DB{conn: conn, dialect: dialectSQLite, env: env}, nil
 }
 
 // UpsertMember inserts or updates member name.
-func (d *DB) UpsertMember(ctx context.Context, discordID, inGameName string) error {
+func (d *DB) UpsertMember(ctx context.Context, guildID, discordID, inGameName string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	_, err := d.conn.ExecContext(ctx, `INSERT INTO members(discord_id, in_game_name) VALUES(?,?)
-		ON CONFLICT(discord_id) DO UPDATE SET in_game_name=excluded.in_game_name`, discordID, inGameName)
+	_, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO members(guild_id, discord_id, in_game_name, updated_at) VALUES(?,?,?,?)
+		ON CONFLICT(guild_id, discord_id) DO UPDATE SET in_game_name=excluded.in_game_name, updated_at=excluded.updated_at`), guildID, discordID, inGameName, time.Now().Unix())
 	return err
 }
 
-func (d *DB) UpdateOrders(ctx context.Context, discordID string, amount int) error {
+// ImportMembers applies a batch of roster rows in a single transaction: every row is upserted and
+// any provided resources/availability are set (resource changes are recorded with actorID), or nothing is.
+func (d *DB) ImportMembers(ctx context.Context, guildID, actorID string, rows []ImportMember) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	res, err := d.conn.ExecContext(ctx, `UPDATE members SET war_orders=? WHERE discord_id=?`, amount, discordID)
+	tx, err := d.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if n, _ := res.RowsAffected(); n == 0 {
-		return errors.New("member not registered")
+	defer func() { _ = tx.Rollback() }()
+	for _, r := range rows {
+		now := time.Now().Unix()
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO members(guild_id, discord_id, in_game_name, updated_at) VALUES(?,?,?,?)
+			ON CONFLICT(guild_id, discord_id) DO UPDATE SET in_game_name=excluded.in_game_name, updated_at=excluded.updated_at`), guildID, r.DiscordID, r.InGameName, now); err != nil {
+			return fmt.Errorf("import %s: %w", r.DiscordID, err)
+		}
+		for _, key := range sortedKeys(r.Resources) {
+			if err := d.setResourceTx(ctx, tx, guildID, r.DiscordID, "", actorID, key, r.Resources[key]); err != nil {
+				return fmt.Errorf("import %s: %w", r.DiscordID, err)
+			}
+		}
+		if r.Availability != nil {
+			if _, err := tx.ExecContext(ctx, d.q(`UPDATE members SET availability_updated_at=? WHERE guild_id=? AND discord_id=?`), now, guildID, r.DiscordID); err != nil {
+				return fmt.Errorf("import %s: %w", r.DiscordID, err)
+			}
+			for _, day := range Week {
+				if err := d.setAvailabilityTx(ctx, tx, guildID, r.DiscordID, day, r.Availability[day]); err != nil {
+					return fmt.Errorf("import %s: %w", r.DiscordID, err)
+				}
+			}
+		}
 	}
-	return nil
+	return tx.Commit()
 }
 
-func (d *DB) UpdateLumber(ctx context.Context, discordID string, amount int) error {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	res, err := d.conn.ExecContext(ctx, `UPDATE members SET lumber=? WHERE discord_id=?`, amount, discordID)
+// GetResourceHistory returns ledger entries for a guild since the given time, newest first.
+// Empty discordID or resource means no filter on that field.
+func (d *DB) GetResourceHistory(ctx context.Context, guildID, discordID, resource string, since time.Time) ([]HistoryEntry, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT h.guild_id, h.discord_id, COALESCE(m.in_game_name, ''), h.character_name, h.resource, h.old_value, h.new_value, h.actor_id, h.changed_at
+		FROM resource_history h
+		LEFT JOIN members m ON m.guild_id=h.guild_id AND m.discord_id=h.discord_id
+		WHERE h.guild_id=? AND (CAST(? AS TEXT)='' OR h.discord_id=?) AND (CAST(? AS TEXT)='' OR h.resource=?) AND h.changed_at>=?
+		ORDER BY h.changed_at DESC, h.id DESC`),
+		guildID, discordID, discordID, resource, resource, since.Unix())
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if n, _ := res.RowsAffected(); n == 0 {
-		return errors.New("member not registered")
+	defer rows.Close()
+	var list []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		var ts int64
+		if err := rows.Scan(&e.GuildID, &e.DiscordID, &e.InGameName, &e.Character, &e.Resource, &e.OldValue, &e.NewValue, &e.ActorID, &ts); err != nil {
+			return nil, err
+		}
+		e.ChangedAt = time.Unix(ts, 0).UTC()
+		list = append(list, e)
 	}
-	return nil
+	return list, rows.Err()
 }
 
 // InsertMemberIfMissing inserts a new member with name if not present; existing records are left unchanged.
-func (d *DB) InsertMemberIfMissing(ctx context.Context, discordID, inGameName string) error {
+func (d *DB) InsertMemberIfMissing(ctx context.Context, guildID, discordID, inGameName string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	_, err := d.conn.ExecContext(ctx, `INSERT OR IGNORE INTO members(discord_id, in_game_name) VALUES(?,?)`, discordID, inGameName)
+	_, err := d.conn.ExecContext(ctx, d.q(`INSERT INTO members(guild_id, discord_id, in_game_name, updated_at) VALUES(?,?,?,?) ON CONFLICT DO NOTHING`), guildID, discordID, inGameName, time.Now().Unix())
 	return err
 }
 
-func (d *DB) UpdateAvailability(ctx context.Context, discordID, slot string) error {
+// DeleteMember removes the member, their alts, resource amounts and availability.
+func (d *DB) DeleteMember(ctx context.Context, guildID, discordID string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	res, err := d.conn.ExecContext(ctx, `UPDATE members SET availability=? WHERE discord_id=?`, slot, discordID)
+	tx, err := d.conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if n, _ := res.RowsAffected(); n == 0 {
-		return errors.New("member not registered")
+	defer func() { _ = tx.Rollback() }()
+	for _, table := range []string{"member_resources", "member_availability", "characters"} {
+		if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM `+table+` WHERE guild_id=? AND discord_id=?`), guildID, discordID); err != nil {
+			return err
+		}
 	}
-	return nil
-}
-
-func (d *DB) DeleteMember(ctx context.Context, discordID string) error {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-	_, err := d.conn.ExecContext(ctx, `DELETE FROM members WHERE discord_id=?`, discordID)
-	return err
+	if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM members WHERE guild_id=? AND discord_id=?`), guildID, discordID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (d *DB) GetAllMembers(ctx context.Context) ([]Member, error) {
+func (d *DB) GetAllMembers(ctx context.Context, guildID string) ([]Member, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
-	rows, err := d.conn.QueryContext(ctx, `SELECT discord_id, in_game_name, war_orders, lumber, availability, guild_role_id FROM members ORDER BY in_game_name COLLATE NOCASE`)
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT guild_id, discord_id, in_game_name, time_zone, guild_role_id, updated_at FROM members WHERE guild_id=? ORDER BY LOWER(in_game_name), discord_id`), guildID)
 	if err != nil {
 		return nil, err
 	}
@@ -149,40 +151,73 @@ func (d *DB) GetAllMembers(ctx context.Context) ([]Member, error) {
 	var list []Member
 	for rows.Next() {
 		var m Member
-		if err := rows.Scan(&m.DiscordID, &m.InGameName, &m.WarOrders, &m.Lumber, &m.Availability, &m.GuildRoleID); err != nil {
+		var updated int64
+		if err := rows.Scan(&m.GuildID, &m.DiscordID, &m.InGameName, &m.TimeZone, &m.GuildRoleID, &updated); err != nil {
 			return nil, err
 		}
+		m.UpdatedAt = unixTime(updated)
 		list = append(list, m)
 	}
-	return list, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	amounts, err := d.loadResources(ctx, guildID, "")
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := d.loadAvailability(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+	for n := range list {
+		list[n].Resources = amounts[resourceOwner{list[n].DiscordID, ""}]
+		list[n].Availability = schedules[list[n].DiscordID]
+	}
+	return list, nil
 }
 
 // UpdateMemberRole sets the member's guild role id.
-func (d *DB) UpdateMemberRole(ctx context.Context, discordID, roleID string) error {
+func (d *DB) UpdateMemberRole(ctx context.Context, guildID, discordID, roleID string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
-	res, err := d.conn.ExecContext(ctx, `UPDATE members SET guild_role_id=? WHERE discord_id=?`, roleID, discordID)
+	res, err := d.conn.ExecContext(ctx, d.q(`UPDATE members SET guild_role_id=? WHERE guild_id=? AND discord_id=?`), roleID, guildID, discordID)
 	if err != nil {
 		return err
 	}
 	if n, _ := res.RowsAffected(); n == 0 {
-		return errors.New("member not registered")
+		return ErrMemberNotRegistered
 	}
 	return nil
 }
 
-func (d *DB) EnsureMemberExists(ctx context.Context, discordID string) (bool, error) {
+func (d *DB) EnsureMemberExists(ctx context.Context, guildID, discordID string) (bool, error) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 	var id string
-	err := d.conn.QueryRowContext(ctx, `SELECT discord_id FROM members WHERE discord_id=?`, discordID).Scan(&id)
+	err := d.conn.QueryRowContext(ctx, d.q(`SELECT discord_id FROM members WHERE guild_id=? AND discord_id=?`), guildID, discordID).Scan(&id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
 	}
 	return err == nil, err
 }
 
-func (d *DB) Close() error { return d.conn.Close() }
+// unixTime converts a stored unix timestamp, mapping 0 (never set) to the zero time.
+func unixTime(ts int64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0).UTC()
+}
+
+func (d *DB) Close() error {
+	d.mu.Lock()
+	if d.leaderConn != nil {
+		_ = d.leaderConn.Close()
+		d.leaderConn = nil
+	}
+	d.mu.Unlock()
+	return d.conn.Close()
+}
 
 // WithTimeout provides standard timeout context for DB operations.
 func WithTimeout(parent context.Context) (context.Context, context.CancelFunc) {
@@ -200,11 +235,11 @@ func FormatMembers(ms []Member, formatter func(m Member) string) string {
 	return out
 }
 
-func (d *DB) DebugPrint(ctx context.Context) {
-	members, _ := d.GetAllMembers(ctx)
+func (d *DB) DebugPrint(ctx context.Context, guildID string) {
+	members, _ := d.GetAllMembers(ctx, guildID)
 	fmt.Println("Roster dump:")
 	for _, m := range members {
-		fmt.Printf("%s => %s Orders:%d Lumber:%d Av:%s\n", m.DiscordID, m.InGameName, m.WarOrders, m.Lumber, m.Availability)
+		fmt.Printf("%s => %s Resources:%v Av:%v\n", m.DiscordID, m.InGameName, m.Resources, m.Availability)
 	}
 }
 
@@ -212,9 +247,13 @@ func (d *DB) DebugPrint(ctx context.Context) {
 
 // TryAcquireLeader attempts to acquire or renew leadership with a lease.
 // If takeoverIfStale is true, it will replace a stale owner (updated_at older than lease).
+// On PostgreSQL an advisory lock is used instead; see pgTryAcquireLeader.
 func (d *DB) TryAcquireLeader(ctx context.Context, instanceID string, lease time.Duration, takeoverIfStale bool) (bool, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if d.dialect == dialectPostgres {
+		return d.pgTryAcquireLeader(ctx)
+	}
 
 	tx, err := d.conn.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
@@ -254,10 +293,39 @@ func (d *DB) TryAcquireLeader(ctx context.Context, instanceID string, lease time
 	return true, tx.Commit()
 }
 
+// LeaderStatus reports the current leader and whether its lease is still live (renewed within lease).
+// On PostgreSQL the owner is unknown and held reflects whether any session holds the advisory lock.
+func (d *DB) LeaderStatus(ctx context.Context, lease time.Duration) (owner string, held bool, err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.dialect == dialectPostgres {
+		return d.pgLeaderStatus(ctx)
+	}
+	var updated int64
+	err = d.conn.QueryRowContext(ctx, `SELECT owner, updated_at FROM leader WHERE id=1`).Scan(&owner, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return owner, updated > time.Now().Add(-lease).Unix(), nil
+}
+
+// SchemaVersion returns the highest applied migration version.
+func (d *DB) SchemaVersion(ctx context.Context) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return schemaVersion(d.conn)
+}
+
 // RenewLeader refreshes the lease timestamp for the current owner.
 func (d *DB) RenewLeader(ctx context.Context, instanceID string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if d.dialect == dialectPostgres {
+		return d.pgRenewLeader(ctx)
+	}
 	_, err := d.conn.ExecContext(ctx, `UPDATE leader SET updated_at=? WHERE id=1 AND owner=?`, time.Now().Unix(), instanceID)
 	return err
 }
@@ -266,6 +334,9 @@ func (d *DB) RenewLeader(ctx context.Context, instanceID string) error {
 func (d *DB) ReleaseLeader(ctx context.Context, instanceID string) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if d.dialect == dialectPostgres {
+		return d.pgReleaseLeader(ctx)
+	}
 	_, err := d.conn.ExecContext(ctx, `DELETE FROM leader WHERE id=1 AND owner=?`, instanceID)
 	return err
 }
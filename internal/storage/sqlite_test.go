@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createLegacyDB writes a database with the single-roster members table used before rosters
+// were scoped per guild, holding the given discord IDs.
+func createLegacyDB(t *testing.T, discordIDs ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(`CREATE TABLE members (
+		discord_id TEXT PRIMARY KEY,
+		in_game_name TEXT NOT NULL,
+		war_orders INTEGER DEFAULT 0,
+		lumber INTEGER DEFAULT 0,
+		availability TEXT DEFAULT 'Not Set'
+	)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range discordIDs {
+		if _, err := conn.Exec(`INSERT INTO members(discord_id, in_game_name, war_orders) VALUES(?, ?, 7)`, id, "name-"+id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func TestLegacyRosterMigration(t *testing.T) {
+	ctx := t.Context()
+	db, err := NewConnection(createLegacyDB(t, "1", "2"), "g1")
+	if err != nil {
+		t.Fatalf("migrate legacy roster: %v", err)
+	}
+	defer db.Close()
+	members, err := db.GetAllMembers(ctx, "g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0].GuildID != "g1" || members[0].InGameName != "name-1" || members[0].Resources[ResourceOrders] != 7 {
+		t.Fatalf("migrated members = %+v", members)
+	}
+	if other, err := db.GetAllMembers(ctx, "g2"); err != nil || len(other) != 0 {
+		t.Fatalf("members of another guild = %+v, %v", other, err)
+	}
+}
+
+func TestLegacyRosterMigrationWithoutGuild(t *testing.T) {
+	_, err := NewConnection(createLegacyDB(t, "1"), "")
+	if err == nil || !strings.Contains(err.Error(), "no guild is configured") {
+		t.Fatalf("migrating a populated roster without a guild = %v, want an error", err)
+	}
+	// An empty legacy table has nothing to assign, so it migrates without a guild
+	db, err := NewConnection(createLegacyDB(t), "")
+	if err != nil {
+		t.Fatalf("migrate empty legacy table: %v", err)
+	}
+	defer db.Close()
+	if err := db.UpsertMember(t.Context(), "g1", "1", "alpha"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMemberNotRegistered is returned when updating a member that has no roster row in the guild.
+var ErrMemberNotRegistered = errors.New("member not registered")
+
+// ErrCharacterNotFound is returned when a member has no alt with the given name.
+var ErrCharacterNotFound = errors.New("character not found")
+
+// ErrCharacterExists is returned when a member already has a character (main or alt) with the given name.
+var ErrCharacterExists = errors.New("character already exists")
+
+// ErrOutOfBounds is returned when an adjustment would leave a resource outside its bounds.
+var ErrOutOfBounds = errors.New("resource amount out of bounds")
+
+// ErrResourceTypeNotFound is returned when deleting a resource type the guild does not define.
+var ErrResourceTypeNotFound = errors.New("resource type not found")
+
+// ErrAvailabilitySlotExists is returned when adding a slot whose label the guild already uses.
+var ErrAvailabilitySlotExists = errors.New("availability slot already exists")
+
+// ErrAvailabilitySlotNotFound is returned when deleting a slot the guild does not define.
+var ErrAvailabilitySlotNotFound = errors.New("availability slot not found")
+
+// ErrWarNotFound is returned when a guild has no war with the given ID.
+var ErrWarNotFound = errors.New("war not found")
+
+// ErrWarLocked is returned when changing a signup after the war's lineup was locked.
+var ErrWarLocked = errors.New("war lineup is locked")
+
+// ErrSeasonActive is returned when starting a season while another one is still running.
+var ErrSeasonActive = errors.New("a season is already active")
+
+// ErrNoActiveSeason is returned when ending a season while none is running.
+var ErrNoActiveSeason = errors.New("no active season")
+
+// ErrNoPermissionGrant is returned when revoking a subject that has no stored grant.
+var ErrNoPermissionGrant = errors.New("no permission granted")
+
+// ErrLeadershipLost is returned by RenewLeader when this instance can no longer prove it holds leadership.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+// Store is the persistence API the bot depends on. DB (SQLite) and MemoryStore implement it.
+type Store interface {
+	// Members
+	UpsertMember(ctx context.Context, guildID, discordID, inGameName string) error
+	InsertMemberIfMissing(ctx context.Context, guildID, discordID, inGameName string) error
+	EnsureMemberExists(ctx context.Context, guildID, discordID string) (bool, error)
+	DeleteMember(ctx context.Context, guildID, discordID string) error
+	GetAllMembers(ctx context.Context, guildID string) ([]Member, error)
+	SetResource(ctx context.Context, guildID, discordID, character, actorID, resource string, amount int) error
+	AdjustResource(ctx context.Context, guildID, discordID, character, actorID, resource string, delta int, bounds Bounds) (int, error)
+	SetAvailability(ctx context.Context, guildID, discordID string, sched Schedule) error
+	SetTimeZone(ctx context.Context, guildID, discordID, zone string) error
+	GetResourceHistory(ctx context.Context, guildID, discordID, resource string, since time.Time) ([]HistoryEntry, error)
+	ImportMembers(ctx context.Context, guildID, actorID string, rows []ImportMember) error
+
+	// Characters (alts)
+	AddCharacter(ctx context.Context, guildID, discordID, name string) error
+	RenameCharacter(ctx context.Context, guildID, discordID, oldName, newName string) error
+	GetCharacters(ctx context.Context, guildID, discordID string) ([]Character, error)
+
+	// Resource types
+	ListResourceTypes(ctx context.Context, guildID string) ([]ResourceType, error)
+	UpsertResourceType(ctx context.Context, t ResourceType) error
+	DeleteResourceType(ctx context.Context, guildID, key string) error
+	SeedResourceTypes(ctx context.Context, guildID string, types []ResourceType) error
+
+	// Availability slots
+	ListAvailabilitySlots(ctx context.Context, guildID string) ([]AvailabilitySlot, error)
+	AddAvailabilitySlot(ctx context.Context, guildID string, slot AvailabilitySlot) error
+	DeleteAvailabilitySlot(ctx context.Context, guildID, label string) ([]string, error)
+	SeedAvailabilitySlots(ctx context.Context, guildID string, slots []AvailabilitySlot) error
+
+	// Wars
+	CreateWar(ctx context.Context, w War) (int64, error)
+	GetWar(ctx context.Context, guildID string, id int64) (War, error)
+	ListWars(ctx context.Context, guildID string) ([]War, error)
+	SetWarMessage(ctx context.Context, guildID string, id int64, channelID, messageID string) error
+	SetWarStatus(ctx context.Context, guildID string, id int64, status string) error
+	SetSignup(ctx context.Context, guildID string, warID int64, discordID, response string) error
+	GetSignups(ctx context.Context, guildID string, warID int64) ([]WarSignup, error)
+	SetAttendance(ctx context.Context, guildID string, warID int64, actorID string, statuses map[string]string) error
+	GetAttendance(ctx context.Context, guildID string, warID int64) ([]Attendance, error)
+	ListAttendance(ctx context.Context, guildID string, since time.Time) ([]Attendance, error)
+	SetWarResult(ctx context.Context, guildID string, id int64, result, score, notes string) error
+	SettleWarOrders(ctx context.Context, guildID string, warID int64, actorID string, cost int, bounds Bounds) ([]HistoryEntry, error)
+
+	// Guild settings
+	GetGuildSettings(ctx context.Context, guildID string) (GuildSettings, error)
+	SaveGuildSettings(ctx context.Context, s GuildSettings) error
+	ListGuildSettings(ctx context.Context) ([]GuildSettings, error)
+
+	// Reminders
+	ListMemberActivity(ctx context.Context, guildID string) ([]MemberActivity, error)
+	SetRemindersOff(ctx context.Context, guildID, discordID string, off bool) error
+	RecordReminderRun(ctx context.Context, guildID string, at time.Time, sent []ReminderLog) error
+	GetLastReminderRun(ctx context.Context, guildID string) ([]ReminderLog, error)
+
+	// Seasons
+	StartSeason(ctx context.Context, guildID, name string) (Season, error)
+	EndSeason(ctx context.Context, guildID string) (Season, error)
+	ListSeasons(ctx context.Context, guildID string) ([]Season, error)
+	GetSeasonArchive(ctx context.Context, guildID string, seasonID int64) ([]ArchivedResource, error)
+
+	// Roles and permissions
+	UpdateMemberRole(ctx context.Context, guildID, discordID, roleID string) error
+	GrantPermission(ctx context.Context, guildID, subjectType, subjectID string, tier Tier) error
+	RevokePermission(ctx context.Context, guildID, subjectType, subjectID string) error
+	ListPermissions(ctx context.Context, guildID string) ([]PermissionGrant, error)
+	SeedPermissions(ctx context.Context, guildID string, grants []PermissionGrant) error
+
+	// Leader lease
+	TryAcquireLeader(ctx context.Context, instanceID string, lease time.Duration, takeoverIfStale bool) (bool, error)
+	RenewLeader(ctx context.Context, instanceID string) error
+	ReleaseLeader(ctx context.Context, instanceID string) error
+
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,664 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// storeFactories opens an empty store per backend. Every Store implementation must pass the
+// tests in this file against each of them.
+var storeFactories = []struct {
+	name string
+	open func(t *testing.T) Store
+}{
+	{"memory", func(*testing.T) Store { return NewMemoryStore() }},
+	{"sqlite", openTestSQLite},
+	{"postgres", func(t *testing.T) Store { return openPostgres(t) }},
+}
+
+func openTestSQLite(t *testing.T) Store {
+	db, err := NewConnection(filepath.Join(t.TempDir(), "test.db"), "")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	return db
+}
+
+// forEachStore runs fn once per backend, each against a fresh store.
+func forEachStore(t *testing.T, fn func(t *testing.T, s Store, guildID string)) {
+	t.Helper()
+	for _, f := range storeFactories {
+		t.Run(f.name, func(t *testing.T) {
+			s := f.open(t)
+			t.Cleanup(func() { _ = s.Close() })
+			fn(t, s, testGuildID())
+		})
+	}
+}
+
+var guildSeq atomic.Int64
+
+// testGuildID returns a guild ID no other test uses, so tests can share a database.
+func testGuildID() string {
+	return fmt.Sprintf("g%d-%d", time.Now().UnixNano(), guildSeq.Add(1))
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func wantErr(t *testing.T, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+}
+
+func findMember(t *testing.T, s Store, guildID, discordID string) (Member, bool) {
+	t.Helper()
+	members, err := s.GetAllMembers(t.Context(), guildID)
+	must(t, err)
+	for _, m := range members {
+		if m.DiscordID == discordID {
+			return m, true
+		}
+	}
+	return Member{}, false
+}
+
+func TestMembers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		must(t, s.UpsertMember(ctx, g, "2", "bravo"))
+		must(t, s.UpsertMember(ctx, g, "1", "Alpha"))
+		must(t, s.InsertMemberIfMissing(ctx, g, "1", "placeholder"))
+		must(t, s.InsertMemberIfMissing(ctx, g, "3", "charlie"))
+		must(t, s.UpsertMember(ctx, "other-"+g, "4", "delta"))
+
+		members, err := s.GetAllMembers(ctx, g)
+		must(t, err)
+		var names []string
+		for _, m := range members {
+			names = append(names, m.InGameName)
+			if m.GuildID != g || len(m.Availability) != 0 {
+				t.Errorf("member %+v: want guild %s and the default availability", m, g)
+			}
+		}
+		if want := []string{"Alpha", "bravo", "charlie"}; !slices.Equal(names, want) {
+			t.Fatalf("members %v, want %v", names, want)
+		}
+		if ok, err := s.EnsureMemberExists(ctx, g, "1"); !ok || err != nil {
+			t.Fatalf("EnsureMemberExists(1) = %v, %v", ok, err)
+		}
+		if ok, err := s.EnsureMemberExists(ctx, g, "4"); ok || err != nil {
+			t.Fatalf("EnsureMemberExists(4) in the wrong guild = %v, %v", ok, err)
+		}
+
+		must(t, s.UpdateMemberRole(ctx, g, "1", "role-1"))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Monday: {"Evening"}}))
+		wantErr(t, s.UpdateMemberRole(ctx, g, "9", "role-1"), ErrMemberNotRegistered)
+		wantErr(t, s.SetAvailability(ctx, g, "9", Schedule{time.Monday: {"Evening"}}), ErrMemberNotRegistered)
+		if m, _ := findMember(t, s, g, "1"); m.GuildRoleID != "role-1" || m.Availability.String() != "Mon: Evening" {
+			t.Fatalf("member 1 = %+v, want role and availability set", m)
+		}
+
+		must(t, s.DeleteMember(ctx, g, "1"))
+		if ok, err := s.EnsureMemberExists(ctx, g, "1"); ok || err != nil {
+			t.Fatalf("EnsureMemberExists after DeleteMember = %v, %v", ok, err)
+		}
+	})
+}
+
+func TestDeleteMemberForgetsEverything(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.AddCharacter(ctx, g, "1", "alt"))
+		must(t, s.SetResource(ctx, g, "1", "", "1", ResourceOrders, 10))
+		must(t, s.SetResource(ctx, g, "1", "alt", "1", ResourceLumber, 5))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Monday: {"a"}}))
+		must(t, s.SetRemindersOff(ctx, g, "1", true))
+
+		must(t, s.DeleteMember(ctx, g, "1"))
+		if _, ok := findMember(t, s, g, "1"); ok {
+			t.Fatal("member still listed after DeleteMember")
+		}
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		m, _ := findMember(t, s, g, "1")
+		if len(m.Resources) != 0 || len(m.Availability) != 0 {
+			t.Fatalf("re-added member kept data: %+v", m)
+		}
+		chars, err := s.GetCharacters(ctx, g, "1")
+		must(t, err)
+		if len(chars) != 0 {
+			t.Fatalf("re-added member kept alts: %+v", chars)
+		}
+		activity, err := s.ListMemberActivity(ctx, g)
+		must(t, err)
+		if len(activity) != 1 || len(activity[0].Resources) != 0 || !activity[0].Availability.IsZero() || activity[0].RemindersOff {
+			t.Fatalf("re-added member kept activity: %+v", activity)
+		}
+	})
+}
+
+func TestResources(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		wantErr(t, s.SetResource(ctx, g, "1", "", "1", ResourceOrders, 5), ErrMemberNotRegistered)
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.UpsertMember(ctx, "other-"+g, "1", "alpha elsewhere"))
+		must(t, s.SetResource(ctx, g, "1", "", "officer", ResourceOrders, 40))
+		must(t, s.SetResource(ctx, g, "1", "", "1", ResourceOrders, 90))
+		must(t, s.SetResource(ctx, "other-"+g, "1", "", "1", ResourceLumber, 8))
+		if m, _ := findMember(t, s, g, "1"); m.Resources[ResourceOrders] != 90 || m.Resources[ResourceLumber] != 0 {
+			t.Fatalf("member = %+v, want orders 90 and no lumber", m)
+		}
+
+		history, err := s.GetResourceHistory(ctx, g, "1", "", time.Time{})
+		must(t, err)
+		if len(history) != 2 {
+			t.Fatalf("history = %+v, want 2 entries", history)
+		}
+		if e := history[0]; e.OldValue != 40 || e.NewValue != 90 || e.ActorID != "1" || e.InGameName != "alpha" {
+			t.Fatalf("newest entry = %+v", e)
+		}
+		if e := history[1]; e.OldValue != 0 || e.NewValue != 40 || e.ActorID != "officer" {
+			t.Fatalf("oldest entry = %+v", e)
+		}
+		history, err = s.GetResourceHistory(ctx, g, "", ResourceLumber, time.Time{})
+		must(t, err)
+		if len(history) != 0 {
+			t.Fatalf("lumber history = %+v, want none", history)
+		}
+		history, err = s.GetResourceHistory(ctx, g, "", "", time.Now().Add(time.Hour))
+		must(t, err)
+		if len(history) != 0 {
+			t.Fatalf("future history = %+v, want none", history)
+		}
+	})
+}
+
+func TestAdjustResources(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		bounds := Bounds{Min: -10, Max: 100}
+		if _, err := s.AdjustResource(ctx, g, "1", "", "1", ResourceOrders, 5, bounds); !errors.Is(err, ErrMemberNotRegistered) {
+			t.Fatalf("adjust an unregistered member = %v", err)
+		}
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.AddCharacter(ctx, g, "1", "alt"))
+		if _, err := s.AdjustResource(ctx, g, "1", "ghost", "1", ResourceLumber, 5, bounds); !errors.Is(err, ErrCharacterNotFound) {
+			t.Fatalf("adjust an unknown alt = %v", err)
+		}
+
+		total, err := s.AdjustResource(ctx, g, "1", "", "1", ResourceOrders, 100, bounds)
+		if err != nil || total != 100 {
+			t.Fatalf("add up to the ceiling = %d, %v", total, err)
+		}
+		// Refused changes leave the amount alone and report the current one
+		if total, err = s.AdjustResource(ctx, g, "1", "", "1", ResourceOrders, 1, bounds); !errors.Is(err, ErrOutOfBounds) || total != 100 {
+			t.Fatalf("add past the ceiling = %d, %v", total, err)
+		}
+		if total, err = s.AdjustResource(ctx, g, "1", "", "1", ResourceOrders, -111, bounds); !errors.Is(err, ErrOutOfBounds) || total != 100 {
+			t.Fatalf("subtract past the floor = %d, %v", total, err)
+		}
+		if total, err = s.AdjustResource(ctx, g, "1", "", "officer", ResourceOrders, -110, bounds); err != nil || total != -10 {
+			t.Fatalf("subtract down to the floor = %d, %v", total, err)
+		}
+		if total, err = s.AdjustResource(ctx, g, "1", "alt", "1", ResourceLumber, 7, bounds); err != nil || total != 7 {
+			t.Fatalf("add to the alt = %d, %v", total, err)
+		}
+
+		if m, _ := findMember(t, s, g, "1"); m.Resources[ResourceOrders] != -10 || m.Resources[ResourceLumber] != 0 {
+			t.Fatalf("member = %+v, want orders -10 and the alt's lumber kept apart", m)
+		}
+		history, err := s.GetResourceHistory(ctx, g, "1", ResourceOrders, time.Time{})
+		must(t, err)
+		if len(history) != 2 || history[0].OldValue != 100 || history[0].NewValue != -10 || history[0].ActorID != "officer" {
+			t.Fatalf("history = %+v, want only the applied changes", history)
+		}
+	})
+}
+
+func TestResourceTypes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		must(t, s.SeedResourceTypes(ctx, g, DefaultResourceTypes(g)))
+		must(t, s.SeedResourceTypes(ctx, g, []ResourceType{{Key: "gold", Name: "Gold"}}))
+		must(t, s.UpsertResourceType(ctx, ResourceType{GuildID: g, Key: "gold", Name: "Gold", Bounds: Bounds{Min: -5, Max: 50}}))
+		must(t, s.UpsertResourceType(ctx, ResourceType{GuildID: g, Key: ResourceOrders, Name: "Orders", Emoji: "📜", Bounds: DefaultBounds, Position: 9}))
+		types, err := s.ListResourceTypes(ctx, g)
+		must(t, err)
+		var keys []string
+		for _, rt := range types {
+			keys = append(keys, rt.Key)
+		}
+		if want := []string{ResourceOrders, ResourceLumber, "gold"}; !slices.Equal(keys, want) {
+			t.Fatalf("types %v, want %v", keys, want)
+		}
+		if types[0].Name != "Orders" || types[2].Min != -5 || types[2].Max != 50 {
+			t.Fatalf("types = %+v", types)
+		}
+
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.SetResource(ctx, g, "1", "", "1", "gold", 3))
+		must(t, s.DeleteResourceType(ctx, g, "gold"))
+		wantErr(t, s.DeleteResourceType(ctx, g, "gold"), ErrResourceTypeNotFound)
+		if m, _ := findMember(t, s, g, "1"); len(m.Resources) != 0 {
+			t.Fatalf("resources after deleting the type = %v", m.Resources)
+		}
+	})
+}
+
+func TestAvailability(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		wantErr(t, s.SetAvailability(ctx, g, "1", Schedule{}), ErrMemberNotRegistered)
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Monday: {"b", "a", "b"}, time.Friday: {"b"}, time.Sunday: {}}))
+		m, _ := findMember(t, s, g, "1")
+		if got := m.Availability.String(); got != "Mon: a, b; Fri: b" {
+			t.Fatalf("availability = %q, want sorted, distinct slots and no empty days", got)
+		}
+
+		// The whole week is replaced, and members read earlier keep their schedule
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Tuesday: {"c"}}))
+		if got := m.Availability.String(); got != "Mon: a, b; Fri: b" {
+			t.Fatalf("earlier read changed to %q", got)
+		}
+		m, _ = findMember(t, s, g, "1")
+		if got := m.Availability.String(); got != "Tue: c" {
+			t.Fatalf("availability after replacing = %q", got)
+		}
+
+		wantErr(t, s.SetTimeZone(ctx, g, "9", "Europe/Berlin"), ErrMemberNotRegistered)
+		must(t, s.SetTimeZone(ctx, g, "1", "Europe/Berlin"))
+		if m, _ := findMember(t, s, g, "1"); m.TimeZone != "Europe/Berlin" {
+			t.Fatalf("time zone = %q", m.TimeZone)
+		}
+		must(t, s.SetTimeZone(ctx, g, "1", ""))
+		if m, _ := findMember(t, s, g, "1"); m.TimeZone != "" {
+			t.Fatalf("time zone after clearing = %q", m.TimeZone)
+		}
+
+		must(t, s.UpsertMember(ctx, g, "2", "bravo"))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Monday: {"a", "b"}, time.Friday: {"b"}}))
+		must(t, s.SetAvailability(ctx, g, "2", Schedule{time.Monday: {"a"}}))
+		must(t, s.SeedAvailabilitySlots(ctx, g, []AvailabilitySlot{{Label: "b", Start: 600, End: 720}, {Label: "a", Start: 60, End: 120}}))
+		// Seeding only applies to a guild without slots
+		must(t, s.SeedAvailabilitySlots(ctx, g, DefaultAvailabilitySlots()))
+		wantErr(t, s.AddAvailabilitySlot(ctx, g, AvailabilitySlot{Label: "a", Start: 0, End: 60}), ErrAvailabilitySlotExists)
+		must(t, s.AddAvailabilitySlot(ctx, g, AvailabilitySlot{Label: "c", Start: 300, End: 360}))
+		slots, err := s.ListAvailabilitySlots(ctx, g)
+		must(t, err)
+		var labels []string
+		for _, sl := range slots {
+			labels = append(labels, sl.Label)
+		}
+		if want := []string{"a", "c", "b"}; !slices.Equal(labels, want) {
+			t.Fatalf("slots %v, want %v ordered by start", labels, want)
+		}
+		if other, err := s.ListAvailabilitySlots(ctx, "other-"+g); err != nil || len(other) != 0 {
+			t.Fatalf("slots of another guild = %v, %v", other, err)
+		}
+
+		affected, err := s.DeleteAvailabilitySlot(ctx, g, "b")
+		must(t, err)
+		if !slices.Equal(affected, []string{"1"}) {
+			t.Fatalf("affected = %v, want [1]", affected)
+		}
+		_, err = s.DeleteAvailabilitySlot(ctx, g, "b")
+		wantErr(t, err, ErrAvailabilitySlotNotFound)
+		if m, _ := findMember(t, s, g, "1"); m.Availability.String() != "Mon: a" {
+			t.Fatalf("availability after deleting slot b = %q, want Friday Not Set", m.Availability.String())
+		}
+	})
+}
+
+func TestImportMembers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.SetResource(ctx, g, "1", "", "1", ResourceLumber, 8))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Sunday: {"Not Available"}}))
+		avail := Schedule{time.Saturday: {"18:00-20:00 GMT"}}
+		must(t, s.ImportMembers(ctx, g, "officer", []ImportMember{
+			{DiscordID: "1", InGameName: "alpha renamed", Resources: map[string]int{ResourceOrders: 30}},
+			{DiscordID: "2", InGameName: "bravo", Resources: map[string]int{ResourceLumber: 5}, Availability: avail},
+		}))
+		// Fields left unset keep their stored values
+		if m, _ := findMember(t, s, g, "1"); m.InGameName != "alpha renamed" || m.Resources[ResourceOrders] != 30 || m.Resources[ResourceLumber] != 8 || len(m.Availability[time.Sunday]) != 1 {
+			t.Fatalf("updated member = %+v", m)
+		}
+		if m, ok := findMember(t, s, g, "2"); !ok || m.Resources[ResourceLumber] != 5 || m.Resources[ResourceOrders] != 0 || m.Availability.String() != "Sat: 18:00-20:00 GMT" {
+			t.Fatalf("new member = %+v, %v", m, ok)
+		}
+		history, err := s.GetResourceHistory(ctx, g, "", "", time.Time{})
+		must(t, err)
+		var imported int
+		for _, e := range history {
+			if e.ActorID == "officer" {
+				imported++
+			}
+		}
+		if imported != 2 {
+			t.Fatalf("history = %+v, want one entry per imported amount", history)
+		}
+	})
+}
+
+func TestWarsAndAttendance(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		start := time.Now().Add(-2 * time.Hour).Truncate(time.Second).UTC()
+		first, err := s.CreateWar(ctx, War{GuildID: g, StartsAt: start.Add(-24 * time.Hour), Opponent: "Foes", CreatedBy: "1"})
+		must(t, err)
+		id, err := s.CreateWar(ctx, War{GuildID: g, StartsAt: start, Slot: "a", Opponent: "Rivals", CreatedBy: "1"})
+		must(t, err)
+		w, err := s.GetWar(ctx, g, id)
+		must(t, err)
+		if w.Status != WarOpen || !w.StartsAt.Equal(start) || w.Slot != "a" || w.CreatedAt.IsZero() {
+			t.Fatalf("war = %+v", w)
+		}
+		_, err = s.GetWar(ctx, "other-"+g, id)
+		wantErr(t, err, ErrWarNotFound)
+		wars, err := s.ListWars(ctx, g)
+		must(t, err)
+		if len(wars) != 2 || wars[0].ID != id || wars[1].ID != first {
+			t.Fatalf("wars = %+v, want latest first", wars)
+		}
+
+		must(t, s.SetWarMessage(ctx, g, id, "chan", "msg"))
+		wantErr(t, s.SetWarMessage(ctx, g, id+100, "chan", "msg"), ErrWarNotFound)
+		must(t, s.SetSignup(ctx, g, id, "1", SignupJoin))
+		must(t, s.SetSignup(ctx, g, id, "2", SignupMaybe))
+		must(t, s.SetSignup(ctx, g, id, "2", SignupDecline))
+		signups, err := s.GetSignups(ctx, g, id)
+		must(t, err)
+		if len(signups) != 2 || signups[1].DiscordID != "2" || signups[1].Response != SignupDecline {
+			t.Fatalf("signups = %+v", signups)
+		}
+		must(t, s.SetWarStatus(ctx, g, id, WarLocked))
+		wantErr(t, s.SetSignup(ctx, g, id, "3", SignupJoin), ErrWarLocked)
+		w, err = s.GetWar(ctx, g, id)
+		must(t, err)
+		if w.Status != WarLocked || w.ChannelID != "chan" || w.MessageID != "msg" {
+			t.Fatalf("war after locking = %+v", w)
+		}
+
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.UpsertMember(ctx, g, "2", "bravo"))
+		wantErr(t, s.SetAttendance(ctx, g, id+100, "officer", map[string]string{"1": AttendancePresent}), ErrWarNotFound)
+		must(t, s.SetAttendance(ctx, g, id, "officer", map[string]string{"1": AttendancePresent, "2": AttendanceAbsent, "gone": AttendancePresent}))
+		must(t, s.SetAttendance(ctx, g, first, "officer", map[string]string{"1": AttendanceExcused}))
+		attendance, err := s.GetAttendance(ctx, g, id)
+		must(t, err)
+		if len(attendance) != 2 || attendance[0].InGameName != "alpha" || attendance[0].Signup != SignupJoin || attendance[1].Status != AttendanceAbsent {
+			t.Fatalf("attendance = %+v, want members on the roster with their signup", attendance)
+		}
+		// Recording again replaces a member's status
+		must(t, s.SetAttendance(ctx, g, id, "officer", map[string]string{"2": AttendanceExcused}))
+		attendance, err = s.GetAttendance(ctx, g, id)
+		must(t, err)
+		if len(attendance) != 2 || attendance[1].Status != AttendanceExcused {
+			t.Fatalf("attendance after re-recording = %+v", attendance)
+		}
+		all, err := s.ListAttendance(ctx, g, start.Add(-48*time.Hour))
+		must(t, err)
+		if len(all) != 3 || all[0].WarID != first || !all[0].WarStartsAt.Equal(start.Add(-24*time.Hour)) {
+			t.Fatalf("all attendance = %+v, want oldest war first", all)
+		}
+		recent, err := s.ListAttendance(ctx, g, start)
+		must(t, err)
+		if len(recent) != 2 {
+			t.Fatalf("attendance since the second war = %+v", recent)
+		}
+
+		must(t, s.SetWarResult(ctx, g, id, ResultWin, "3-1", "close"))
+		wantErr(t, s.SetWarResult(ctx, g, id+100, ResultWin, "", ""), ErrWarNotFound)
+		w, err = s.GetWar(ctx, g, id)
+		must(t, err)
+		if w.Status != WarComplete || w.Result != ResultWin || w.Score != "3-1" || w.Notes != "close" || w.MessageID != "msg" {
+			t.Fatalf("war after result = %+v", w)
+		}
+	})
+}
+
+func TestSettleWarOrders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		bounds := Bounds{Min: 0, Max: 100}
+		for id, orders := range map[string]int{"1": 10, "2": 2, "3": 10, "4": 0} {
+			must(t, s.UpsertMember(ctx, g, id, "m"+id))
+			must(t, s.SetResource(ctx, g, id, "", id, ResourceOrders, orders))
+		}
+		id, err := s.CreateWar(ctx, War{GuildID: g, StartsAt: time.Now().Add(-time.Hour)})
+		must(t, err)
+		must(t, s.SetAttendance(ctx, g, id, "officer", map[string]string{"1": AttendancePresent, "2": AttendancePresent, "3": AttendanceAbsent, "4": AttendancePresent}))
+		changes, err := s.SettleWarOrders(ctx, g, id, "officer", 5, bounds)
+		if err != nil || len(changes) != 0 {
+			t.Fatalf("settling an incomplete war = %+v, %v; want nothing", changes, err)
+		}
+
+		must(t, s.SetWarResult(ctx, g, id, ResultLoss, "", ""))
+		changes, err = s.SettleWarOrders(ctx, g, id, "officer", 5, bounds)
+		must(t, err)
+		if len(changes) != 2 || changes[0].DiscordID != "1" || changes[0].NewValue != 5 || changes[1].DiscordID != "2" || changes[1].NewValue != 0 {
+			t.Fatalf("first settle = %+v, want m1 10->5, m2 2->0 and m4 left at the minimum", changes)
+		}
+		// m4 was recorded as charged nothing, so orders gained since are not charged either
+		must(t, s.SetResource(ctx, g, "4", "", "4", ResourceOrders, 20))
+		changes, err = s.SettleWarOrders(ctx, g, id, "officer", 5, bounds)
+		if err != nil || len(changes) != 0 {
+			t.Fatalf("settling again = %+v, %v; want nobody charged twice", changes, err)
+		}
+
+		must(t, s.SetAttendance(ctx, g, id, "officer", map[string]string{"2": AttendanceExcused, "3": AttendancePresent, "4": AttendanceExcused}))
+		changes, err = s.SettleWarOrders(ctx, g, id, "officer", 5, bounds)
+		must(t, err)
+		if len(changes) != 2 || changes[0].DiscordID != "2" || changes[0].NewValue != 2 || changes[1].DiscordID != "3" || changes[1].NewValue != 5 {
+			t.Fatalf("settle after attendance change = %+v, want m2 refunded to 2, m3 charged to 5 and m4 unchanged", changes)
+		}
+		history, err := s.GetResourceHistory(ctx, g, "2", ResourceOrders, time.Time{})
+		must(t, err)
+		if len(history) != 3 || history[0].ActorID != "officer" {
+			t.Fatalf("m2 history = %+v", history)
+		}
+
+		// Once excused and settled, m4 is charged when marked present again
+		must(t, s.SetAttendance(ctx, g, id, "officer", map[string]string{"4": AttendancePresent}))
+		changes, err = s.SettleWarOrders(ctx, g, id, "officer", 5, bounds)
+		must(t, err)
+		if len(changes) != 1 || changes[0].DiscordID != "4" || changes[0].OldValue != 20 || changes[0].NewValue != 15 {
+			t.Fatalf("settle after m4 is present again = %+v, want m4 20->15", changes)
+		}
+	})
+}
+
+func TestSeasons(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		_, err := s.EndSeason(ctx, g)
+		wantErr(t, err, ErrNoActiveSeason)
+		se, err := s.StartSeason(ctx, g, "Spring")
+		must(t, err)
+		if !se.Active() || se.Name != "Spring" || se.StartedAt.IsZero() {
+			t.Fatalf("started season = %+v", se)
+		}
+		_, err = s.StartSeason(ctx, g, "Summer")
+		wantErr(t, err, ErrSeasonActive)
+
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.AddCharacter(ctx, g, "1", "alt"))
+		must(t, s.SetResource(ctx, g, "1", "", "1", ResourceOrders, 10))
+		must(t, s.SetResource(ctx, g, "1", "alt", "1", ResourceOrders, 3))
+		must(t, s.UpsertMember(ctx, "other-"+g, "2", "bravo"))
+		must(t, s.SetResource(ctx, "other-"+g, "2", "", "2", ResourceOrders, 99))
+
+		// The archive copy binds the season id in a SELECT list, which PostgreSQL needs cast
+		ended, err := s.EndSeason(ctx, g)
+		must(t, err)
+		if ended.ID != se.ID || ended.Active() {
+			t.Fatalf("ended season = %+v", ended)
+		}
+		archive, err := s.GetSeasonArchive(ctx, g, se.ID)
+		must(t, err)
+		want := []ArchivedResource{
+			{SeasonID: se.ID, DiscordID: "1", InGameName: "alpha", Character: "", Resource: ResourceOrders, Amount: 10},
+			{SeasonID: se.ID, DiscordID: "1", InGameName: "alpha", Character: "alt", Resource: ResourceOrders, Amount: 3},
+		}
+		if !slices.Equal(archive, want) {
+			t.Fatalf("archive = %+v, want %+v", archive, want)
+		}
+		if other, err := s.GetSeasonArchive(ctx, "other-"+g, se.ID); err != nil || len(other) != 0 {
+			t.Fatalf("archive read from another guild = %+v, %v", other, err)
+		}
+
+		next, err := s.StartSeason(ctx, g, "Summer")
+		must(t, err)
+		seasons, err := s.ListSeasons(ctx, g)
+		must(t, err)
+		if len(seasons) != 2 || seasons[0].ID != next.ID || seasons[1].ID != se.ID {
+			t.Fatalf("seasons = %+v, want latest first", seasons)
+		}
+	})
+}
+
+func TestCharacters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		wantErr(t, s.AddCharacter(ctx, g, "1", "alt"), ErrMemberNotRegistered)
+		must(t, s.UpsertMember(ctx, g, "1", "Main"))
+		must(t, s.AddCharacter(ctx, g, "1", "Alt"))
+		must(t, s.AddCharacter(ctx, g, "1", "bank"))
+		wantErr(t, s.AddCharacter(ctx, g, "1", "alt"), ErrCharacterExists)
+		wantErr(t, s.AddCharacter(ctx, g, "1", "main"), ErrCharacterExists)
+		must(t, s.SetResource(ctx, g, "1", "Alt", "1", ResourceOrders, 7))
+		wantErr(t, s.SetResource(ctx, g, "1", "ghost", "1", ResourceOrders, 7), ErrCharacterNotFound)
+
+		wantErr(t, s.RenameCharacter(ctx, g, "1", "Alt", "BANK"), ErrCharacterExists)
+		wantErr(t, s.RenameCharacter(ctx, g, "1", "ghost", "spare"), ErrCharacterNotFound)
+		must(t, s.RenameCharacter(ctx, g, "1", "Alt", "Scout"))
+		chars, err := s.GetCharacters(ctx, g, "1")
+		must(t, err)
+		if len(chars) != 2 || chars[0].Name != "bank" || chars[1].Name != "Scout" || chars[1].Resources[ResourceOrders] != 7 {
+			t.Fatalf("characters after rename = %+v", chars)
+		}
+		history, err := s.GetResourceHistory(ctx, g, "1", ResourceOrders, time.Time{})
+		must(t, err)
+		if len(history) != 1 || history[0].Character != "Scout" {
+			t.Fatalf("history after rename = %+v, want it to follow the alt", history)
+		}
+		if m, _ := findMember(t, s, g, "1"); m.Resources[ResourceOrders] != 0 {
+			t.Fatalf("main orders = %d, want the alt's amount kept apart", m.Resources[ResourceOrders])
+		}
+	})
+}
+
+func TestGuildSettingsAndReminders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		gs, err := s.GetGuildSettings(ctx, g)
+		must(t, err)
+		if gs != (GuildSettings{GuildID: g, ReminderDays: "all"}) {
+			t.Fatalf("default settings = %+v", gs)
+		}
+		gs.WarOrdersCost, gs.StaleAfterDays, gs.ReminderAt, gs.ReminderChannelID = 5, 7, 18*60, "chan"
+		must(t, s.SaveGuildSettings(ctx, gs))
+
+		must(t, s.UpsertMember(ctx, g, "1", "alpha"))
+		must(t, s.UpsertMember(ctx, g, "2", "bravo"))
+		wantErr(t, s.SetRemindersOff(ctx, g, "9", true), ErrMemberNotRegistered)
+		must(t, s.SetRemindersOff(ctx, g, "2", true))
+		must(t, s.SetResource(ctx, g, "1", "", "1", ResourceLumber, 1))
+		must(t, s.SetAvailability(ctx, g, "1", Schedule{time.Monday: {"a"}}))
+		activity, err := s.ListMemberActivity(ctx, g)
+		must(t, err)
+		if len(activity) != 2 || activity[0].Resources[ResourceLumber].IsZero() || activity[0].Availability.IsZero() || activity[0].RemindersOff || !activity[1].RemindersOff {
+			t.Fatalf("activity = %+v", activity)
+		}
+
+		first := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+		must(t, s.RecordReminderRun(ctx, g, first, []ReminderLog{{DiscordID: "1", InGameName: "alpha", Stale: []string{ResourceOrders}, Delivered: true}}))
+		last := first.Add(time.Minute)
+		must(t, s.RecordReminderRun(ctx, g, last, []ReminderLog{
+			{DiscordID: "2", InGameName: "bravo", Stale: []string{ResourceOrders, ReminderAvailability}},
+			{DiscordID: "1", InGameName: "alpha", Stale: []string{ReminderAvailability}, Delivered: true},
+		}))
+		run, err := s.GetLastReminderRun(ctx, g)
+		must(t, err)
+		if len(run) != 2 || run[0].DiscordID != "1" || !run[0].Delivered || run[1].Delivered ||
+			!slices.Equal(run[1].Stale, []string{ResourceOrders, ReminderAvailability}) || !run[0].SentAt.Equal(last) {
+			t.Fatalf("last run = %+v", run)
+		}
+
+		must(t, s.SaveGuildSettings(ctx, GuildSettings{GuildID: g, ReminderDays: "weekdays"}))
+		gs, err = s.GetGuildSettings(ctx, g)
+		must(t, err)
+		if !gs.LastReminderAt.Equal(last) || gs.ReminderDays != "weekdays" || gs.WarOrdersCost != 0 {
+			t.Fatalf("settings after save = %+v, want LastReminderAt kept", gs)
+		}
+		list, err := s.ListGuildSettings(ctx)
+		must(t, err)
+		if !slices.ContainsFunc(list, func(o GuildSettings) bool { return o.GuildID == g && o.LastReminderAt.Equal(last) }) {
+			t.Fatalf("ListGuildSettings = %+v, want guild %s", list, g)
+		}
+	})
+}
+
+func TestPermissions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, g string) {
+		ctx := t.Context()
+		must(t, s.SeedPermissions(ctx, g, []PermissionGrant{{SubjectType: SubjectRole, SubjectID: "r1", Tier: TierLeader}}))
+		// Seeding only fills a guild without grants, so later /perm changes survive restarts
+		must(t, s.SeedPermissions(ctx, g, []PermissionGrant{{SubjectType: SubjectRole, SubjectID: "r2", Tier: TierLeader}}))
+		must(t, s.GrantPermission(ctx, g, SubjectUser, "u1", TierMember))
+		must(t, s.GrantPermission(ctx, g, SubjectUser, "u2", TierOfficer))
+		must(t, s.GrantPermission(ctx, g, SubjectUser, "u1", TierOfficer))
+		must(t, s.GrantPermission(ctx, "other-"+g, SubjectUser, "u3", TierLeader))
+		grants, err := s.ListPermissions(ctx, g)
+		must(t, err)
+		want := []PermissionGrant{
+			{GuildID: g, SubjectType: SubjectRole, SubjectID: "r1", Tier: TierLeader},
+			{GuildID: g, SubjectType: SubjectUser, SubjectID: "u1", Tier: TierOfficer},
+			{GuildID: g, SubjectType: SubjectUser, SubjectID: "u2", Tier: TierOfficer},
+		}
+		if !slices.Equal(grants, want) {
+			t.Fatalf("grants = %+v, want %+v", grants, want)
+		}
+		must(t, s.RevokePermission(ctx, g, SubjectUser, "u1"))
+		wantErr(t, s.RevokePermission(ctx, g, SubjectUser, "u1"), ErrNoPermissionGrant)
+	})
+}
+
+func TestLeaderLease(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store, _ string) {
+		if db, ok := s.(*DB); ok && db.dialect == dialectPostgres {
+			t.Skip("PostgreSQL elects a leader with an advisory lock; see TestPostgresLeaderLock")
+		}
+		ctx := t.Context()
+		if ok, err := s.TryAcquireLeader(ctx, "a", time.Minute, true); !ok || err != nil {
+			t.Fatalf("a acquire = %v, %v", ok, err)
+		}
+		if ok, err := s.TryAcquireLeader(ctx, "b", time.Minute, true); ok || err != nil {
+			t.Fatalf("b acquire while a holds a live lease = %v, %v", ok, err)
+		}
+		must(t, s.RenewLeader(ctx, "a"))
+		must(t, s.ReleaseLeader(ctx, "b"))
+		if ok, err := s.TryAcquireLeader(ctx, "b", time.Minute, false); ok || err != nil {
+			t.Fatalf("b acquire after releasing someone else's lease = %v, %v", ok, err)
+		}
+		must(t, s.ReleaseLeader(ctx, "a"))
+		if ok, err := s.TryAcquireLeader(ctx, "b", time.Minute, false); !ok || err != nil {
+			t.Fatalf("b acquire after a released = %v, %v", ok, err)
+		}
+	})
+}
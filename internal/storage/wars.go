@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const warColumns = `id, guild_id, starts_at, slot, opponent, status, channel_id, message_id, created_by, created_at, result, score, notes`
+
+// scanner is satisfied by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWar(row scanner) (War, error) {
+	var w War
+	var startsAt, createdAt int64
+	err := row.Scan(&w.ID, &w.GuildID, &startsAt, &w.Slot, &w.Opponent, &w.Status, &w.ChannelID, &w.MessageID, &w.CreatedBy, &createdAt, &w.Result, &w.Score, &w.Notes)
+	w.StartsAt, w.CreatedAt = unixTime(startsAt), unixTime(createdAt)
+	return w, err
+}
+
+// CreateWar stores a new war and returns its ID. ID and CreatedAt are assigned here; an empty
+// Status means WarOpen.
+func (d *DB) CreateWar(ctx context.Context, w War) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if w.Status == "" {
+		w.Status = WarOpen
+	}
+	var id int64
+	err := d.conn.QueryRowContext(ctx, d.q(`INSERT INTO wars(guild_id, starts_at, slot, opponent, status, channel_id, message_id, created_by, created_at)
+		VALUES(?,?,?,?,?,?,?,?,?) RETURNING id`),
+		w.GuildID, w.StartsAt.Unix(), w.Slot, w.Opponent, w.Status, w.ChannelID, w.MessageID, w.CreatedBy, time.Now().Unix()).Scan(&id)
+	return id, err
+}
+
+// GetWar returns one of a guild's wars.
+func (d *DB) GetWar(ctx context.Context, guildID string, id int64) (War, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	w, err := scanWar(d.conn.QueryRowContext(ctx, d.q(`SELECT `+warColumns+` FROM wars WHERE guild_id=? AND id=?`), guildID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return War{}, ErrWarNotFound
+	}
+	return w, err
+}
+
+// ListWars returns a guild's wars, latest start first.
+func (d *DB) ListWars(ctx context.Context, guildID string) ([]War, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT `+warColumns+` FROM wars WHERE guild_id=? ORDER BY starts_at DESC, id DESC`), guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []War
+	for rows.Next() {
+		w, err := scanWar(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+// SetWarMessage records where a war's signup message was posted.
+func (d *DB) SetWarMessage(ctx context.Context, guildID string, id int64, channelID, messageID string) error {
+	return d.updateWar(ctx, `UPDATE wars SET channel_id=?, message_id=? WHERE guild_id=? AND id=?`, channelID, messageID, guildID, id)
+}
+
+// SetWarStatus moves a war to status, e.g. WarLocked.
+func (d *DB) SetWarStatus(ctx context.Context, guildID string, id int64, status string) error {
+	return d.updateWar(ctx, `UPDATE wars SET status=? WHERE guild_id=? AND id=?`, status, guildID, id)
+}
+
+// SetWarResult records a war's outcome and marks it WarComplete. Recording again replaces the result.
+func (d *DB) SetWarResult(ctx context.Context, guildID string, id int64, result, score, notes string) error {
+	return d.updateWar(ctx, `UPDATE wars SET result=?, score=?, notes=?, status=? WHERE guild_id=? AND id=?`, result, score, notes, WarComplete, guildID, id)
+}
+
+func (d *DB) updateWar(ctx context.Context, query string, args ...any) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res, err := d.conn.ExecContext(ctx, d.q(query), args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWarNotFound
+	}
+	return nil
+}
+
+// SetSignup records a member's response to a war, replacing any earlier one. It fails with
+// ErrWarLocked once the war is no longer open.
+func (d *DB) SetSignup(ctx context.Context, guildID string, warID int64, discordID, response string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var status string
+	err = tx.QueryRowContext(ctx, d.q(`SELECT status FROM wars WHERE guild_id=? AND id=?`), guildID, warID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWarNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != WarOpen {
+		return ErrWarLocked
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO war_signups(war_id, discord_id, response, updated_at) VALUES(?,?,?,?)
+		ON CONFLICT(war_id, discord_id) DO UPDATE SET response=excluded.response, updated_at=excluded.updated_at`),
+		warID, discordID, response, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSignups returns a war's signups, earliest first.
+func (d *DB) GetSignups(ctx context.Context, guildID string, warID int64) ([]WarSignup, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(`SELECT s.war_id, s.discord_id, s.response, s.updated_at FROM war_signups s
+		JOIN wars w ON w.id=s.war_id WHERE w.guild_id=? AND s.war_id=? ORDER BY s.updated_at, s.discord_id`), guildID, warID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []WarSignup
+	for rows.Next() {
+		var s WarSignup
+		var ts int64
+		if err := rows.Scan(&s.WarID, &s.DiscordID, &s.Response, &ts); err != nil {
+			return nil, err
+		}
+		s.UpdatedAt = unixTime(ts)
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// SetAttendance records statuses (Discord ID -> AttendancePresent, ...) for a war, replacing
+// earlier records of the same members.
+func (d *DB) SetAttendance(ctx context.Context, guildID string, warID int64, actorID string, statuses map[string]string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var n int
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT COUNT(*) FROM wars WHERE guild_id=? AND id=?`), guildID, warID).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWarNotFound
+	}
+	now := time.Now().Unix()
+	for _, id := range sortedKeys(statuses) {
+		if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO war_attendance(war_id, discord_id, status, recorded_by, recorded_at) VALUES(?,?,?,?,?)
+			ON CONFLICT(war_id, discord_id) DO UPDATE SET status=excluded.status, recorded_by=excluded.recorded_by, recorded_at=excluded.recorded_at`),
+			warID, id, statuses[id], actorID, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// attendanceQuery joins attendance with its war, the member's roster row and their signup.
+// Members no longer on the roster are left out.
+const attendanceQuery = `SELECT a.war_id, a.discord_id, a.status, a.recorded_by, a.recorded_at, w.starts_at, m.in_game_name, COALESCE(s.response, '')
+	FROM war_attendance a
+	JOIN wars w ON w.id=a.war_id
+	JOIN members m ON m.guild_id=w.guild_id AND m.discord_id=a.discord_id
+	LEFT JOIN war_signups s ON s.war_id=a.war_id AND s.discord_id=a.discord_id`
+
+// GetAttendance returns the attendance recorded for one war.
+func (d *DB) GetAttendance(ctx context.Context, guildID string, warID int64) ([]Attendance, error) {
+	return d.queryAttendance(ctx, attendanceQuery+` WHERE w.guild_id=? AND a.war_id=? ORDER BY m.in_game_name`, guildID, warID)
+}
+
+// ListAttendance returns the attendance of every war starting at or after since, oldest war first.
+func (d *DB) ListAttendance(ctx context.Context, guildID string, since time.Time) ([]Attendance, error) {
+	return d.queryAttendance(ctx, attendanceQuery+` WHERE w.guild_id=? AND w.starts_at>=? ORDER BY w.starts_at, w.id, a.discord_id`, guildID, since.Unix())
+}
+
+func (d *DB) queryAttendance(ctx context.Context, query string, args ...any) ([]Attendance, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	rows, err := d.conn.QueryContext(ctx, d.q(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []Attendance
+	for rows.Next() {
+		var a Attendance
+		var recordedAt, startsAt int64
+		if err := rows.Scan(&a.WarID, &a.DiscordID, &a.Status, &a.RecordedBy, &recordedAt, &startsAt, &a.InGameName, &a.Signup); err != nil {
+			return nil, err
+		}
+		a.RecordedAt, a.WarStartsAt = unixTime(recordedAt), unixTime(startsAt)
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// SettleWarOrders brings the War Orders deducted for a completed war in line with its attendance:
+// members present who were not charged yet lose cost from their main character, never going
+// below bounds.Min, and members charged earlier but no longer present get the deduction back.
+// Every change is recorded in resource_history under actorID and returned. Changing cost later
+// does not touch members already charged. A member present while already at bounds.Min is
+// recorded as charged 0 and later settles leave them alone even once their orders rise, unless
+// they are marked absent or excused and settled in between. Wars that are not complete are left alone.
+func (d *DB) SettleWarOrders(ctx context.Context, guildID string, warID int64, actorID string, cost int, bounds Bounds) ([]HistoryEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tx, err := d.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	var status string
+	err = tx.QueryRowContext(ctx, d.q(`SELECT status FROM wars WHERE guild_id=? AND id=?`), guildID, warID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWarNotFound
+	}
+	if err != nil || status != WarComplete {
+		return nil, err
+	}
+	type record struct {
+		discordID, name, status string
+		charged                 sql.NullInt64
+		amount                  int
+	}
+	rows, err := tx.QueryContext(ctx, d.q(`SELECT a.discord_id, m.in_game_name, a.status, a.orders_charged, COALESCE(r.amount, 0) FROM war_attendance a
+		JOIN members m ON m.guild_id=? AND m.discord_id=a.discord_id
+		LEFT JOIN member_resources r ON r.guild_id=m.guild_id AND r.discord_id=a.discord_id AND r.character_name='' AND r.resource_key=?
+		WHERE a.war_id=? ORDER BY m.in_game_name, a.discord_id`), guildID, ResourceOrders, warID)
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.discordID, &r.name, &r.status, &r.charged, &r.amount); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	var changes []HistoryEntry
+	for _, r := range records {
+		var amount int
+		var charged sql.NullInt64
+		switch {
+		case r.status == AttendancePresent && !r.charged.Valid && cost > 0:
+			amount = r.amount
+			if amount > bounds.Min {
+				amount = max(amount-cost, bounds.Min)
+			}
+			charged = sql.NullInt64{Int64: int64(r.amount - amount), Valid: true}
+		case r.status != AttendancePresent && r.charged.Valid:
+			amount = min(r.amount+int(r.charged.Int64), max(bounds.Max, r.amount))
+		default:
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, d.q(`UPDATE war_attendance SET orders_charged=? WHERE war_id=? AND discord_id=?`), charged, warID, r.discordID); err != nil {
+			return nil, err
+		}
+		if amount == r.amount {
+			continue
+		}
+		if err := d.setResourceTx(ctx, tx, guildID, r.discordID, "", actorID, ResourceOrders, amount); err != nil {
+			return nil, err
+		}
+		changes = append(changes, HistoryEntry{GuildID: guildID, DiscordID: r.discordID, InGameName: r.name, Resource: ResourceOrders, OldValue: r.amount, NewValue: amount, ActorID: actorID})
+	}
+	return changes, tx.Commit()
+}